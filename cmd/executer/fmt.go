@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dyxgou/parser/src/format"
+)
+
+// Formats the files given in args. They are printed, written back with -w or, with -check, the ones that aren't
+// formatted are listed. Returns the exit status, 1 when -check found a file to format and 2 for any other error
+func runFmt(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	write := flags.Bool("w", false, "write the result to the files instead of printing it")
+	check := flags.Bool("check", false, "list the files that aren't formatted and exit with status 1 if there is any")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "fmt expected at least 1 file")
+		return 2
+	}
+
+	status := 0
+	for _, path := range flags.Args() {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 2
+			continue
+		}
+
+		formatted, err := format.Source(path, string(src))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 2
+			continue
+		}
+
+		changed := formatted != string(src)
+
+		if *check && changed {
+			fmt.Println(path)
+			status = max(status, 1)
+		}
+
+		if *write && changed {
+			info, err := os.Stat(path)
+			if err == nil {
+				err = os.WriteFile(path, []byte(formatted), info.Mode().Perm())
+			}
+
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				status = 2
+			}
+		}
+
+		if !*check && !*write {
+			os.Stdout.WriteString(formatted)
+		}
+	}
+
+	return status
+}
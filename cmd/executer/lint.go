@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dyxgou/parser/src/lint"
+)
+
+// Checks the files given in args and prints their diagnostics. Returns the exit status, 1 when a diagnostic was
+// found and 2 for any other error
+func runLint(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "lint expected at least 1 file")
+		return 2
+	}
+
+	status := 0
+	for _, path := range args {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 2
+			continue
+		}
+
+		diags, err := lint.Source(path, string(src))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 2
+			continue
+		}
+
+		for _, d := range diags {
+			fmt.Println(d)
+			status = max(status, 1)
+		}
+	}
+
+	return status
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"os"
 
@@ -8,7 +9,22 @@ import (
 )
 
 func main() {
-	args := os.Args[1:]
+	if len(os.Args) > 1 && os.Args[1] == "fmt" {
+		os.Exit(runFmt(os.Args[2:]))
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "lint" {
+		os.Exit(runLint(os.Args[2:]))
+	}
+
+	engine := flag.String("engine", string(repl.EngineEval), "engine that runs the file: eval or vm, which doesn't support import")
+	maxSteps := flag.Int("max-steps", 0, "maximum number of evaluation steps, 0 for no limit")
+	maxDepth := flag.Int("max-depth", 0, "maximum number of nested function calls, 0 for the default of 10000")
+	timeout := flag.Duration("timeout", 0, "maximum running time like 5s, 0 for no limit")
+	optimize := flag.Bool("optimize", false, "fold the constant expressions and drop the code that never runs before running the file")
+	flag.Parse()
+
+	args := flag.Args()
 
 	if len(args) != 1 {
 		log.Fatalf("args expected 1 argument. got=%d", len(args))
@@ -22,5 +38,14 @@ func main() {
 		log.Fatal(err)
 	}
 
-	repl.Execute(string(file), os.Stdout)
+	cfg := repl.Config{
+		Filename:     path,
+		Engine:       repl.Engine(*engine),
+		MaxSteps:     *maxSteps,
+		MaxCallDepth: *maxDepth,
+		Timeout:      *timeout,
+		Optimize:     *optimize,
+	}
+
+	repl.ExecuteWith(cfg, string(file), os.Stdout)
 }
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/dyxgou/parser/src/lsp"
+)
+
+// Runs the language server over the standard streams, the editors start it and talk with it through them
+func main() {
+	if err := lsp.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -2,11 +2,16 @@ package ast
 
 import (
 	"strings"
+
+	"github.com/dyxgou/parser/src/token"
 )
 
 type Node interface {
 	TokenLiteral() string
 	String() string
+
+	Pos() token.Position // position of the first character of the node
+	End() token.Position // position right after the last character of the node
 }
 
 type Statement interface {
@@ -48,3 +53,19 @@ func (p *Program) TokenLiteral() string {
 
 	return ""
 }
+
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+
+	return token.Position{}
+}
+
+func (p *Program) End() token.Position {
+	if n := len(p.Statements); n > 0 {
+		return p.Statements[n-1].End()
+	}
+
+	return token.Position{}
+}
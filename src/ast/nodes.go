@@ -23,6 +23,9 @@ func (i *Identifier) String() string {
 	return i.TokenLiteral()
 }
 
+func (i *Identifier) Pos() token.Position { return i.Token.Pos }
+func (i *Identifier) End() token.Position { return i.Token.End }
+
 type LetStatement struct {
 	Token token.Token
 	Name  *Identifier
@@ -31,6 +34,18 @@ type LetStatement struct {
 
 func (s *LetStatement) statementNode()       {}
 func (s *LetStatement) TokenLiteral() string { return s.Token.Literal }
+func (s *LetStatement) Pos() token.Position  { return s.Token.Pos }
+func (s *LetStatement) End() token.Position {
+	if s.Value != nil {
+		return s.Value.End()
+	}
+
+	if s.Name != nil {
+		return s.Name.End()
+	}
+
+	return s.Token.End
+}
 func (s *LetStatement) String() string {
 	var sb strings.Builder
 
@@ -54,6 +69,14 @@ type ReturnStatement struct {
 
 func (s *ReturnStatement) statementNode()       {}
 func (s *ReturnStatement) TokenLiteral() string { return s.Token.Literal }
+func (s *ReturnStatement) Pos() token.Position  { return s.Token.Pos }
+func (s *ReturnStatement) End() token.Position {
+	if s.Value != nil {
+		return s.Value.End()
+	}
+
+	return s.Token.End
+}
 func (s *ReturnStatement) String() string {
 	var sb strings.Builder
 
@@ -75,6 +98,14 @@ type ExpressionStatement struct {
 
 func (s *ExpressionStatement) statementNode()       {}
 func (s *ExpressionStatement) TokenLiteral() string { return s.Token.Literal }
+func (s *ExpressionStatement) Pos() token.Position  { return s.Token.Pos }
+func (s *ExpressionStatement) End() token.Position {
+	if s.Expression != nil {
+		return s.Expression.End()
+	}
+
+	return s.Token.End
+}
 func (s *ExpressionStatement) String() string {
 	var sb strings.Builder
 
@@ -93,6 +124,19 @@ type IntegerLiteral struct {
 func (e *IntegerLiteral) expressionNode()      {}
 func (e *IntegerLiteral) TokenLiteral() string { return e.Token.Literal }
 func (e *IntegerLiteral) String() string       { return e.TokenLiteral() }
+func (e *IntegerLiteral) Pos() token.Position  { return e.Token.Pos }
+func (e *IntegerLiteral) End() token.Position  { return e.Token.End }
+
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (e *FloatLiteral) expressionNode()      {}
+func (e *FloatLiteral) TokenLiteral() string { return e.Token.Literal }
+func (e *FloatLiteral) String() string       { return e.TokenLiteral() }
+func (e *FloatLiteral) Pos() token.Position  { return e.Token.Pos }
+func (e *FloatLiteral) End() token.Position  { return e.Token.End }
 
 type StringLiteral struct {
 	Token token.Token
@@ -102,6 +146,8 @@ func (s *StringLiteral) expressionNode()      {}
 func (s *StringLiteral) TokenLiteral() string { return s.Token.Literal }
 func (s *StringLiteral) String() string       { return s.TokenLiteral() }
 func (s *StringLiteral) Value() string        { return s.Token.Literal }
+func (s *StringLiteral) Pos() token.Position  { return s.Token.Pos }
+func (s *StringLiteral) End() token.Position  { return s.Token.End }
 
 type PrefixExpression struct {
 	Token token.Token
@@ -111,6 +157,14 @@ type PrefixExpression struct {
 func (e *PrefixExpression) expressionNode()      {}
 func (e *PrefixExpression) TokenLiteral() string { return e.Token.Literal }
 func (e *PrefixExpression) Operator() string     { return e.TokenLiteral() }
+func (e *PrefixExpression) Pos() token.Position  { return e.Token.Pos }
+func (e *PrefixExpression) End() token.Position {
+	if e.Right != nil {
+		return e.Right.End()
+	}
+
+	return e.Token.End
+}
 
 func (e *PrefixExpression) String() string {
 	var sb strings.Builder
@@ -132,6 +186,21 @@ type InfixExpression struct {
 func (e *InfixExpression) expressionNode()      {}
 func (e *InfixExpression) TokenLiteral() string { return e.Token.Literal }
 func (e *InfixExpression) Operator() string     { return e.TokenLiteral() }
+func (e *InfixExpression) Pos() token.Position {
+	if e.Left != nil {
+		return e.Left.Pos()
+	}
+
+	return e.Token.Pos
+}
+
+func (e *InfixExpression) End() token.Position {
+	if e.Right != nil {
+		return e.Right.End()
+	}
+
+	return e.Token.End
+}
 
 func (e *InfixExpression) String() string {
 	var sb strings.Builder
@@ -153,6 +222,53 @@ func (e *InfixExpression) String() string {
 	return sb.String()
 }
 
+// Assigns a value to an identifier or to an index of an array or hash
+type AssignExpression struct {
+	Token  token.Token // token.ASSIGN or a compound assignment like token.PLUS_ASSIGN
+	Target Expression  // *Identifier or *IndexExpression
+	Value  Expression
+}
+
+func (e *AssignExpression) expressionNode()      {}
+func (e *AssignExpression) TokenLiteral() string { return e.Token.Literal }
+func (e *AssignExpression) Operator() string     { return e.TokenLiteral() }
+func (e *AssignExpression) Pos() token.Position {
+	if e.Target != nil {
+		return e.Target.Pos()
+	}
+
+	return e.Token.Pos
+}
+
+func (e *AssignExpression) End() token.Position {
+	if e.Value != nil {
+		return e.Value.End()
+	}
+
+	return e.Token.End
+}
+
+func (e *AssignExpression) String() string {
+	var sb strings.Builder
+
+	sb.WriteByte('(')
+	if e.Target != nil {
+		sb.WriteString(e.Target.String())
+	}
+
+	sb.WriteByte(' ')
+	sb.WriteString(e.Operator())
+	sb.WriteByte(' ')
+
+	if e.Value != nil {
+		sb.WriteString(e.Value.String())
+	}
+
+	sb.WriteByte(')')
+
+	return sb.String()
+}
+
 type Boolean struct {
 	Token token.Token
 	Value bool
@@ -161,14 +277,25 @@ type Boolean struct {
 func (e *Boolean) expressionNode()      {}
 func (e *Boolean) TokenLiteral() string { return e.Token.Literal }
 func (e *Boolean) String() string       { return e.TokenLiteral() }
+func (e *Boolean) Pos() token.Position  { return e.Token.Pos }
+func (e *Boolean) End() token.Position  { return e.Token.End }
 
 type BlockStatement struct {
 	Token      token.Token // token.LBRACE "{"
 	Statements []Statement
+	Rbrace     token.Position // position of the closing "}"
 }
 
 func (s *BlockStatement) statementNode()       {}
 func (s *BlockStatement) TokenLiteral() string { return s.Token.Literal }
+func (s *BlockStatement) Pos() token.Position  { return s.Token.Pos }
+func (s *BlockStatement) End() token.Position {
+	if n := len(s.Statements); !s.Rbrace.IsValid() && n > 0 {
+		return s.Statements[n-1].End()
+	}
+
+	return closingEnd(s.Rbrace, s.Token)
+}
 func (s *BlockStatement) String() string {
 	var sb strings.Builder
 
@@ -188,6 +315,18 @@ type IfExpression struct {
 
 func (e *IfExpression) expressionNode()      {}
 func (e *IfExpression) TokenLiteral() string { return e.Token.Literal }
+func (e *IfExpression) Pos() token.Position  { return e.Token.Pos }
+func (e *IfExpression) End() token.Position {
+	if e.Alternative != nil {
+		return e.Alternative.End()
+	}
+
+	if e.Consequence != nil {
+		return e.Consequence.End()
+	}
+
+	return e.Token.End
+}
 
 func (e *IfExpression) String() string {
 	var sb strings.Builder
@@ -217,6 +356,14 @@ type FunctionLiteral struct {
 
 func (e *FunctionLiteral) expressionNode()      {}
 func (e *FunctionLiteral) TokenLiteral() string { return e.Token.Literal }
+func (e *FunctionLiteral) Pos() token.Position  { return e.Token.Pos }
+func (e *FunctionLiteral) End() token.Position {
+	if e.Body != nil {
+		return e.Body.End()
+	}
+
+	return e.Token.End
+}
 func (e *FunctionLiteral) String() string {
 	var sb strings.Builder
 
@@ -240,13 +387,16 @@ func (e *FunctionLiteral) String() string {
 }
 
 type CallExpression struct {
-	Token     token.Token
+	Token     token.Token // token.LPAREN "("
 	Function  Expression
 	Arguments []Expression
+	Rparen    token.Position // position of the closing ")"
 }
 
 func (e *CallExpression) expressionNode()      {}
 func (e *CallExpression) TokenLiteral() string { return e.Token.Literal }
+func (e *CallExpression) Pos() token.Position  { return e.Function.Pos() }
+func (e *CallExpression) End() token.Position  { return closingEnd(e.Rparen, e.Token) }
 
 func (e *CallExpression) String() string {
 	var sb strings.Builder
@@ -269,10 +419,13 @@ func (e *CallExpression) String() string {
 type ArrayLiteral struct {
 	Token    token.Token // token [
 	Elements []Expression
+	Rbracket token.Position // position of the closing "]"
 }
 
 func (a *ArrayLiteral) expressionNode()      {}
 func (a *ArrayLiteral) TokenLiteral() string { return a.Token.Literal }
+func (a *ArrayLiteral) Pos() token.Position  { return a.Token.Pos }
+func (a *ArrayLiteral) End() token.Position  { return closingEnd(a.Rbracket, a.Token) }
 func (a *ArrayLiteral) String() string {
 	var sb strings.Builder
 
@@ -291,13 +444,16 @@ func (a *ArrayLiteral) String() string {
 }
 
 type IndexExpression struct {
-	Token token.Token
-	Left  Expression
-	Index Expression
+	Token    token.Token // token [
+	Left     Expression
+	Index    Expression
+	Rbracket token.Position // position of the closing "]"
 }
 
 func (*IndexExpression) expressionNode()        {}
 func (i *IndexExpression) TokenLiteral() string { return i.Token.Literal }
+func (i *IndexExpression) Pos() token.Position  { return i.Left.Pos() }
+func (i *IndexExpression) End() token.Position  { return closingEnd(i.Rbracket, i.Token) }
 func (i *IndexExpression) String() string {
 	var sb strings.Builder
 
@@ -309,3 +465,181 @@ func (i *IndexExpression) String() string {
 
 	return sb.String()
 }
+
+// SliceExpression is left[low:high], the bounds that are left out are nil
+type SliceExpression struct {
+	Token    token.Token // token [
+	Left     Expression
+	Low      Expression
+	High     Expression
+	Rbracket token.Position // position of the closing "]"
+}
+
+func (*SliceExpression) expressionNode()        {}
+func (s *SliceExpression) TokenLiteral() string { return s.Token.Literal }
+func (s *SliceExpression) Pos() token.Position  { return s.Left.Pos() }
+func (s *SliceExpression) End() token.Position  { return closingEnd(s.Rbracket, s.Token) }
+func (s *SliceExpression) String() string {
+	var sb strings.Builder
+
+	sb.WriteByte('(')
+	sb.WriteString(s.Left.String())
+	sb.WriteByte('[')
+	if s.Low != nil {
+		sb.WriteString(s.Low.String())
+	}
+	sb.WriteByte(':')
+	if s.High != nil {
+		sb.WriteString(s.High.String())
+	}
+	sb.WriteString("])")
+
+	return sb.String()
+}
+
+type MemberExpression struct {
+	Token    token.Token // token .
+	Object   Expression
+	Property *Identifier
+}
+
+func (*MemberExpression) expressionNode()        {}
+func (m *MemberExpression) TokenLiteral() string { return m.Token.Literal }
+func (m *MemberExpression) Pos() token.Position  { return m.Object.Pos() }
+func (m *MemberExpression) End() token.Position  { return m.Property.End() }
+func (m *MemberExpression) String() string {
+	var sb strings.Builder
+
+	sb.WriteByte('(')
+	sb.WriteString(m.Object.String())
+	sb.WriteByte('.')
+	sb.WriteString(m.Property.String())
+	sb.WriteByte(')')
+
+	return sb.String()
+}
+
+type ImportExpression struct {
+	Token  token.Token // token.IMPORT
+	Path   *StringLiteral
+	Rparen token.Position // position of the closing ")"
+}
+
+func (*ImportExpression) expressionNode()        {}
+func (i *ImportExpression) TokenLiteral() string { return i.Token.Literal }
+func (i *ImportExpression) Pos() token.Position  { return i.Token.Pos }
+func (i *ImportExpression) End() token.Position  { return closingEnd(i.Rparen, i.Token) }
+func (i *ImportExpression) String() string {
+	return "import(" + i.Path.String() + ")"
+}
+
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
+type HashLiteral struct {
+	Token  token.Token // token {
+	Pairs  []HashPair  // pairs in the order they were written
+	Rbrace token.Position
+}
+
+func (h *HashLiteral) expressionNode()      {}
+func (h *HashLiteral) TokenLiteral() string { return h.Token.Literal }
+func (h *HashLiteral) Pos() token.Position  { return h.Token.Pos }
+func (h *HashLiteral) End() token.Position  { return closingEnd(h.Rbrace, h.Token) }
+func (h *HashLiteral) String() string {
+	var sb strings.Builder
+
+	sb.WriteByte('{')
+	for i, pair := range h.Pairs {
+		if i != 0 {
+			sb.WriteString(", ")
+		}
+
+		sb.WriteString(pair.Key.String())
+		sb.WriteString(": ")
+		sb.WriteString(pair.Value.String())
+	}
+	sb.WriteByte('}')
+
+	return sb.String()
+}
+
+type WhileStatement struct {
+	Token     token.Token // token.WHILE
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (s *WhileStatement) statementNode()       {}
+func (s *WhileStatement) TokenLiteral() string { return s.Token.Literal }
+func (s *WhileStatement) Pos() token.Position  { return s.Token.Pos }
+func (s *WhileStatement) End() token.Position  { return s.Body.End() }
+func (s *WhileStatement) String() string {
+	var sb strings.Builder
+
+	sb.WriteString("while")
+	sb.WriteString(s.Condition.String())
+	sb.WriteByte(' ')
+	sb.WriteString(s.Body.String())
+
+	return sb.String()
+}
+
+type ForStatement struct {
+	Token    token.Token // token.FOR
+	Variable *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (s *ForStatement) statementNode()       {}
+func (s *ForStatement) TokenLiteral() string { return s.Token.Literal }
+func (s *ForStatement) Pos() token.Position  { return s.Token.Pos }
+func (s *ForStatement) End() token.Position  { return s.Body.End() }
+func (s *ForStatement) String() string {
+	var sb strings.Builder
+
+	sb.WriteString("for")
+	sb.WriteByte('(')
+	sb.WriteString(s.Variable.String())
+	sb.WriteString(" in ")
+	sb.WriteString(s.Iterable.String())
+	sb.WriteString(") ")
+	sb.WriteString(s.Body.String())
+
+	return sb.String()
+}
+
+type BreakStatement struct {
+	Token token.Token // token.BREAK
+}
+
+func (s *BreakStatement) statementNode()       {}
+func (s *BreakStatement) TokenLiteral() string { return s.Token.Literal }
+func (s *BreakStatement) Pos() token.Position  { return s.Token.Pos }
+func (s *BreakStatement) End() token.Position  { return s.Token.End }
+func (s *BreakStatement) String() string       { return s.TokenLiteral() + ";" }
+
+type ContinueStatement struct {
+	Token token.Token // token.CONTINUE
+}
+
+func (s *ContinueStatement) statementNode()       {}
+func (s *ContinueStatement) TokenLiteral() string { return s.Token.Literal }
+func (s *ContinueStatement) Pos() token.Position  { return s.Token.Pos }
+func (s *ContinueStatement) End() token.Position  { return s.Token.End }
+func (s *ContinueStatement) String() string       { return s.TokenLiteral() + ";" }
+
+// Returns the position right after a closing delimiter, or the end of the opening token if the delimiter is unknown
+func closingEnd(closing token.Position, open token.Token) token.Position {
+	if !closing.IsValid() {
+		return open.End
+	}
+
+	closing.Offset++
+	closing.Column++
+
+	return closing
+}
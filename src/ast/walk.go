@@ -0,0 +1,322 @@
+package ast
+
+import "fmt"
+
+// Visitor has its Visit method called for every node found by Walk. When the returned visitor w is not nil,
+// Walk visits the children of the node with it and then calls w.Visit(nil)
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walks the tree of the node in the order it's written, depth first. The missing children, like the
+// alternative of an if without else, are skipped
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		walkList(v, n.Statements)
+	case *LetStatement:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+	case *ReturnStatement:
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+	case *ExpressionStatement:
+		if n.Expression != nil {
+			Walk(v, n.Expression)
+		}
+	case *BlockStatement:
+		walkList(v, n.Statements)
+	case *WhileStatement:
+		if n.Condition != nil {
+			Walk(v, n.Condition)
+		}
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+	case *ForStatement:
+		if n.Variable != nil {
+			Walk(v, n.Variable)
+		}
+		if n.Iterable != nil {
+			Walk(v, n.Iterable)
+		}
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+	case *PrefixExpression:
+		if n.Right != nil {
+			Walk(v, n.Right)
+		}
+	case *InfixExpression:
+		if n.Left != nil {
+			Walk(v, n.Left)
+		}
+		if n.Right != nil {
+			Walk(v, n.Right)
+		}
+	case *AssignExpression:
+		if n.Target != nil {
+			Walk(v, n.Target)
+		}
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+	case *IfExpression:
+		if n.Condition != nil {
+			Walk(v, n.Condition)
+		}
+		if n.Consequence != nil {
+			Walk(v, n.Consequence)
+		}
+		if n.Alternative != nil {
+			Walk(v, n.Alternative)
+		}
+	case *FunctionLiteral:
+		walkList(v, n.Params)
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+	case *CallExpression:
+		if n.Function != nil {
+			Walk(v, n.Function)
+		}
+		walkList(v, n.Arguments)
+	case *ArrayLiteral:
+		walkList(v, n.Elements)
+	case *HashLiteral:
+		for _, pair := range n.Pairs {
+			if pair.Key != nil {
+				Walk(v, pair.Key)
+			}
+			if pair.Value != nil {
+				Walk(v, pair.Value)
+			}
+		}
+	case *IndexExpression:
+		if n.Left != nil {
+			Walk(v, n.Left)
+		}
+		if n.Index != nil {
+			Walk(v, n.Index)
+		}
+	case *SliceExpression:
+		if n.Left != nil {
+			Walk(v, n.Left)
+		}
+		if n.Low != nil {
+			Walk(v, n.Low)
+		}
+		if n.High != nil {
+			Walk(v, n.High)
+		}
+	case *MemberExpression:
+		if n.Object != nil {
+			Walk(v, n.Object)
+		}
+		if n.Property != nil {
+			Walk(v, n.Property)
+		}
+	case *ImportExpression:
+		if n.Path != nil {
+			Walk(v, n.Path)
+		}
+	case *Identifier, *IntegerLiteral, *FloatLiteral, *StringLiteral, *Boolean, *BreakStatement, *ContinueStatement:
+		// they have no children
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+func walkList[T Node](v Visitor, nodes []T) {
+	for _, node := range nodes {
+		Walk(v, node)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+
+	return nil
+}
+
+// Walks the tree of the node calling f for every node, the children of a node are skipped when f returns
+// false for it. After the children of a node f is called with nil
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// Rewrites the tree of the node from the leaves up: the children of a node are rewritten before f is called
+// with it, and the node f returns takes its place. Returning the same node keeps it, returning nil removes
+// it from its list, like the statements of a block, or leaves its field empty. The new node must fit in
+// the place of the old one, an expression can't replace a statement. Returns the node replacing the root
+func Rewrite(node Node, f func(Node) Node) Node {
+	switch n := node.(type) {
+	case *Program:
+		n.Statements = rewriteList(n.Statements, f)
+	case *LetStatement:
+		if n.Name != nil {
+			n.Name = rewriteChild(n.Name, f)
+		}
+		if n.Value != nil {
+			n.Value = rewriteChild(n.Value, f)
+		}
+	case *ReturnStatement:
+		if n.Value != nil {
+			n.Value = rewriteChild(n.Value, f)
+		}
+	case *ExpressionStatement:
+		if n.Expression != nil {
+			n.Expression = rewriteChild(n.Expression, f)
+		}
+	case *BlockStatement:
+		n.Statements = rewriteList(n.Statements, f)
+	case *WhileStatement:
+		if n.Condition != nil {
+			n.Condition = rewriteChild(n.Condition, f)
+		}
+		if n.Body != nil {
+			n.Body = rewriteChild(n.Body, f)
+		}
+	case *ForStatement:
+		if n.Variable != nil {
+			n.Variable = rewriteChild(n.Variable, f)
+		}
+		if n.Iterable != nil {
+			n.Iterable = rewriteChild(n.Iterable, f)
+		}
+		if n.Body != nil {
+			n.Body = rewriteChild(n.Body, f)
+		}
+	case *PrefixExpression:
+		if n.Right != nil {
+			n.Right = rewriteChild(n.Right, f)
+		}
+	case *InfixExpression:
+		if n.Left != nil {
+			n.Left = rewriteChild(n.Left, f)
+		}
+		if n.Right != nil {
+			n.Right = rewriteChild(n.Right, f)
+		}
+	case *AssignExpression:
+		if n.Target != nil {
+			n.Target = rewriteChild(n.Target, f)
+		}
+		if n.Value != nil {
+			n.Value = rewriteChild(n.Value, f)
+		}
+	case *IfExpression:
+		if n.Condition != nil {
+			n.Condition = rewriteChild(n.Condition, f)
+		}
+		if n.Consequence != nil {
+			n.Consequence = rewriteChild(n.Consequence, f)
+		}
+		if n.Alternative != nil {
+			n.Alternative = rewriteChild(n.Alternative, f)
+		}
+	case *FunctionLiteral:
+		n.Params = rewriteList(n.Params, f)
+		if n.Body != nil {
+			n.Body = rewriteChild(n.Body, f)
+		}
+	case *CallExpression:
+		if n.Function != nil {
+			n.Function = rewriteChild(n.Function, f)
+		}
+		n.Arguments = rewriteList(n.Arguments, f)
+	case *ArrayLiteral:
+		n.Elements = rewriteList(n.Elements, f)
+	case *HashLiteral:
+		for i := range n.Pairs {
+			if n.Pairs[i].Key != nil {
+				n.Pairs[i].Key = rewriteChild(n.Pairs[i].Key, f)
+			}
+			if n.Pairs[i].Value != nil {
+				n.Pairs[i].Value = rewriteChild(n.Pairs[i].Value, f)
+			}
+		}
+	case *IndexExpression:
+		if n.Left != nil {
+			n.Left = rewriteChild(n.Left, f)
+		}
+		if n.Index != nil {
+			n.Index = rewriteChild(n.Index, f)
+		}
+	case *SliceExpression:
+		if n.Left != nil {
+			n.Left = rewriteChild(n.Left, f)
+		}
+		if n.Low != nil {
+			n.Low = rewriteChild(n.Low, f)
+		}
+		if n.High != nil {
+			n.High = rewriteChild(n.High, f)
+		}
+	case *MemberExpression:
+		if n.Object != nil {
+			n.Object = rewriteChild(n.Object, f)
+		}
+		if n.Property != nil {
+			n.Property = rewriteChild(n.Property, f)
+		}
+	case *ImportExpression:
+		if n.Path != nil {
+			n.Path = rewriteChild(n.Path, f)
+		}
+	case *Identifier, *IntegerLiteral, *FloatLiteral, *StringLiteral, *Boolean, *BreakStatement, *ContinueStatement:
+		// they have no children
+	default:
+		panic(fmt.Sprintf("ast.Rewrite: unexpected node type %T", n))
+	}
+
+	return f(node)
+}
+
+// Rewrites a child of a node, the node replacing it must have the type of its field
+func rewriteChild[T Node](child T, f func(Node) Node) T {
+	replaced := Rewrite(child, f)
+	if replaced == nil {
+		var zero T
+		return zero
+	}
+
+	return as(replaced, child)
+}
+
+// Rewrites the nodes of a list, leaving out the ones replaced with nil
+func rewriteList[T Node](nodes []T, f func(Node) Node) []T {
+	rewritten := nodes[:0]
+
+	for _, node := range nodes {
+		if replaced := Rewrite(node, f); replaced != nil {
+			rewritten = append(rewritten, as(replaced, node))
+		}
+	}
+
+	return rewritten
+}
+
+func as[T Node](replaced Node, old T) T {
+	t, ok := replaced.(T)
+	if !ok {
+		panic(fmt.Sprintf("ast.Rewrite: %T can't take the place of %T", replaced, old))
+	}
+
+	return t
+}
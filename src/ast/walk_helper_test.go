@@ -0,0 +1,99 @@
+package ast
+
+import (
+	"strconv"
+
+	"github.com/dyxgou/parser/src/token"
+)
+
+// The helpers build the nodes without positions, like the parser would from their source
+
+func tok(kind token.TokenKind, literal string) token.Token {
+	return token.Token{Kind: kind, Literal: literal}
+}
+
+func ident(name string) *Identifier {
+	return &Identifier{Token: tok(token.IDENT, name)}
+}
+
+func integer(v int64) *IntegerLiteral {
+	return &IntegerLiteral{Token: tok(token.INT, strconv.FormatInt(v, 10)), Value: v}
+}
+
+func str(s string) *StringLiteral {
+	return &StringLiteral{Token: tok(token.STRING, s)}
+}
+
+func block(stmts ...Statement) *BlockStatement {
+	return &BlockStatement{Token: tok(token.LBRACE, "{"), Statements: stmts}
+}
+
+func expr(exp Expression) *ExpressionStatement {
+	return &ExpressionStatement{Token: tok(token.IDENT, exp.TokenLiteral()), Expression: exp}
+}
+
+// Returns a program with every kind of node:
+//
+//	let f = fn(a) { return -a; };
+//	f(1.5)[0] + s[1:2];
+//	x = if (true) { 1 } else { "s" };
+//	while (b) { break; }
+//	for (i in [1]) { continue; }
+//	import("m").k;
+//	{"a": 2};
+func everyNode() *Program {
+	return &Program{Statements: []Statement{
+		&LetStatement{
+			Token: tok(token.LET, "let"),
+			Name:  ident("f"),
+			Value: &FunctionLiteral{
+				Token:  tok(token.FUNCTION, "fn"),
+				Params: []*Identifier{ident("a")},
+				Body: block(&ReturnStatement{
+					Token: tok(token.RETURN, "return"),
+					Value: &PrefixExpression{Token: tok(token.MINUS, "-"), Right: ident("a")},
+				}),
+			},
+		},
+		expr(&InfixExpression{
+			Token: tok(token.PLUS, "+"),
+			Left: &IndexExpression{
+				Token: tok(token.LBRACKET, "["),
+				Left: &CallExpression{
+					Token:     tok(token.LPAREN, "("),
+					Function:  ident("f"),
+					Arguments: []Expression{&FloatLiteral{Token: tok(token.FLOAT, "1.5"), Value: 1.5}},
+				},
+				Index: integer(0),
+			},
+			Right: &SliceExpression{Token: tok(token.LBRACKET, "["), Left: ident("s"), Low: integer(1), High: integer(2)},
+		}),
+		expr(&AssignExpression{
+			Token:  tok(token.ASSIGN, "="),
+			Target: ident("x"),
+			Value: &IfExpression{
+				Token:       tok(token.IF, "if"),
+				Condition:   &Boolean{Token: tok(token.TRUE, "true"), Value: true},
+				Consequence: block(expr(integer(1))),
+				Alternative: block(expr(str("s"))),
+			},
+		}),
+		&WhileStatement{
+			Token:     tok(token.WHILE, "while"),
+			Condition: ident("b"),
+			Body:      block(&BreakStatement{Token: tok(token.BREAK, "break")}),
+		},
+		&ForStatement{
+			Token:    tok(token.FOR, "for"),
+			Variable: ident("i"),
+			Iterable: &ArrayLiteral{Token: tok(token.LBRACKET, "["), Elements: []Expression{integer(1)}},
+			Body:     block(&ContinueStatement{Token: tok(token.CONTINUE, "continue")}),
+		},
+		expr(&MemberExpression{
+			Token:    tok(token.DOT, "."),
+			Object:   &ImportExpression{Token: tok(token.IMPORT, "import"), Path: str("m")},
+			Property: ident("k"),
+		}),
+		expr(&HashLiteral{Token: tok(token.LBRACE, "{"), Pairs: []HashPair{{Key: str("a"), Value: integer(2)}}}),
+	}}
+}
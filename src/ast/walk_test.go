@@ -0,0 +1,214 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dyxgou/parser/src/token"
+)
+
+// The nodes of everyNode in the order they are written
+var everyNodeOrder = []string{
+	"Program",
+	"LetStatement", "Identifier", "FunctionLiteral", "Identifier", "BlockStatement", "ReturnStatement", "PrefixExpression", "Identifier",
+	"ExpressionStatement", "InfixExpression", "IndexExpression", "CallExpression", "Identifier", "FloatLiteral", "IntegerLiteral",
+	"SliceExpression", "Identifier", "IntegerLiteral", "IntegerLiteral",
+	"ExpressionStatement", "AssignExpression", "Identifier", "IfExpression", "Boolean",
+	"BlockStatement", "ExpressionStatement", "IntegerLiteral", "BlockStatement", "ExpressionStatement", "StringLiteral",
+	"WhileStatement", "Identifier", "BlockStatement", "BreakStatement",
+	"ForStatement", "Identifier", "ArrayLiteral", "IntegerLiteral", "BlockStatement", "ContinueStatement",
+	"ExpressionStatement", "MemberExpression", "ImportExpression", "StringLiteral", "Identifier",
+	"ExpressionStatement", "HashLiteral", "StringLiteral", "IntegerLiteral",
+}
+
+// The types of nodes.go and the program
+const nodeTypes = 26
+
+func typeName(node Node) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", node), "*ast.")
+}
+
+type recorder struct {
+	visited []string
+	ends    int
+}
+
+func (r *recorder) Visit(node Node) Visitor {
+	if node == nil {
+		r.ends++
+		return nil
+	}
+
+	r.visited = append(r.visited, typeName(node))
+	return r
+}
+
+func TestWalk(t *testing.T) {
+	r := &recorder{}
+	Walk(r, everyNode())
+
+	if strings.Join(r.visited, " ") != strings.Join(everyNodeOrder, " ") {
+		t.Errorf("wrong order.\nexpected=%v\ngot=%v", everyNodeOrder, r.visited)
+	}
+
+	// every node has its children walked and then the nil visit
+	if r.ends != len(r.visited) {
+		t.Errorf("expected %d visits with nil. got=%d", len(r.visited), r.ends)
+	}
+
+	types := make(map[string]bool)
+	for _, name := range r.visited {
+		types[name] = true
+	}
+
+	if len(types) != nodeTypes {
+		t.Errorf("expected every type of node to be walked. got=%d types", len(types))
+	}
+}
+
+func TestWalkMissingChildren(t *testing.T) {
+	nodes := []Node{
+		&LetStatement{Token: tok(token.LET, "let"), Name: ident("x")},
+		&ReturnStatement{Token: tok(token.RETURN, "return")},
+		&IfExpression{Token: tok(token.IF, "if"), Condition: ident("c"), Consequence: block()},
+		&SliceExpression{Token: tok(token.LBRACKET, "["), Left: ident("s")},
+		&FunctionLiteral{Token: tok(token.FUNCTION, "fn"), Body: block()},
+		&CallExpression{Token: tok(token.LPAREN, "("), Function: ident("f")},
+	}
+
+	expected := [][]string{
+		{"LetStatement", "Identifier"},
+		{"ReturnStatement"},
+		{"IfExpression", "Identifier", "BlockStatement"},
+		{"SliceExpression", "Identifier"},
+		{"FunctionLiteral", "BlockStatement"},
+		{"CallExpression", "Identifier"},
+	}
+
+	for i, node := range nodes {
+		r := &recorder{}
+		Walk(r, node)
+
+		if strings.Join(r.visited, " ") != strings.Join(expected[i], " ") {
+			t.Errorf("%T: expected=%v. got=%v", node, expected[i], r.visited)
+		}
+	}
+}
+
+func TestInspect(t *testing.T) {
+	var visited []string
+	nils := 0
+
+	Inspect(everyNode(), func(node Node) bool {
+		if node == nil {
+			nils++
+			return false
+		}
+
+		visited = append(visited, typeName(node))
+
+		// the functions and the loops are skipped
+		switch node.(type) {
+		case *FunctionLiteral, *WhileStatement, *ForStatement:
+			return false
+		}
+
+		return true
+	})
+
+	for _, name := range visited {
+		switch name {
+		case "ReturnStatement", "BreakStatement", "ContinueStatement":
+			t.Errorf("%s should have been skipped", name)
+		}
+	}
+
+	if visited[3] != "FunctionLiteral" || visited[4] != "ExpressionStatement" {
+		t.Errorf("expected the function without its children. got=%v", visited[:5])
+	}
+
+	// the skipped nodes have no nil visit
+	if nils != len(visited)-3 {
+		t.Errorf("expected %d visits with nil. got=%d", len(visited)-3, nils)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	var rewritten []string
+	types := make(map[string]bool)
+
+	program := Rewrite(everyNode(), func(node Node) Node {
+		rewritten = append(rewritten, typeName(node))
+		types[typeName(node)] = true
+
+		switch node := node.(type) {
+		case *IntegerLiteral:
+			return integer(node.Value * 10)
+		case *BreakStatement:
+			return nil
+		case *Identifier:
+			if node.Value() == "a" {
+				return ident("n")
+			}
+		}
+
+		return node
+	}).(*Program)
+
+	expected := `let f = fn(n){return (-n);};((f(1.5)[0]) + (s[10:20]))(x = iftrue 10 else s)whileb for(i in [10]) continue;(import(m).k){a: 20}`
+	if program.String() != expected {
+		t.Errorf("expected=%q. got=%q", expected, program.String())
+	}
+
+	if len(types) != nodeTypes {
+		t.Errorf("expected every type of node to be rewritten. got=%d types", len(types))
+	}
+
+	// the children are rewritten before their parent
+	if strings.Join(rewritten[:5], " ") != "Identifier Identifier Identifier PrefixExpression ReturnStatement" {
+		t.Errorf("expected the leaves first. got=%v", rewritten[:5])
+	}
+
+	if rewritten[len(rewritten)-1] != "Program" {
+		t.Errorf("expected the program last. got=%s", rewritten[len(rewritten)-1])
+	}
+}
+
+func TestRewriteStatements(t *testing.T) {
+	// a statement can be replaced with another kind of statement
+	program := Rewrite(everyNode(), func(node Node) Node {
+		switch node := node.(type) {
+		case *WhileStatement:
+			return node.Body
+		case *ForStatement, *LetStatement:
+			return nil
+		}
+
+		return node
+	}).(*Program)
+
+	if len(program.Statements) != 5 {
+		t.Fatalf("expected 5 statements. got=%d", len(program.Statements))
+	}
+
+	if _, ok := program.Statements[2].(*BlockStatement); !ok {
+		t.Errorf("expected the body of the loop. got=%T", program.Statements[2])
+	}
+}
+
+func TestRewriteWrongType(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "*ast.IntegerLiteral can't take the place of *ast.Identifier") {
+			t.Errorf("expected a panic for the wrong type. got=%v", r)
+		}
+	}()
+
+	Rewrite(everyNode(), func(node Node) Node {
+		if ident, ok := node.(*Identifier); ok && ident.Value() == "f" {
+			return integer(1)
+		}
+
+		return node
+	})
+}
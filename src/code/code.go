@@ -0,0 +1,245 @@
+package code
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+type Instructions []byte
+
+type Opcode byte
+
+const (
+	OpConstant Opcode = iota
+	OpPop
+	OpClearResult
+
+	// Operators
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpMod
+	OpEqual
+	OpNotEqual
+	OpGreaterThan
+	OpGreaterEqual
+	OpLessThan
+	OpLessEqual
+	OpMinus
+	OpBang
+
+	// Literals
+	OpTrue
+	OpFalse
+	OpNull
+	OpArray
+	OpHash
+	OpIndex
+	OpSetIndex
+	OpUpdateIndex
+	OpSlice
+
+	// Control flow
+	OpJumpNotTruthy
+	OpJump
+	OpIter
+	OpIterNext
+
+	// Bindings
+	OpGetGlobal
+	OpSetGlobal
+	OpAssignGlobal
+	OpGetLocal
+	OpSetLocal
+	OpGetLocalCell
+	OpGetBuiltin
+	OpGetFree
+	OpSetFree
+	OpGetFreeCell
+	OpCurrentClosure
+
+	// Functions
+	OpClosure
+	OpCall
+	OpReturnValue
+	OpReturn
+)
+
+type Definition struct {
+	Name          string
+	OperandWidths []int // width in bytes of each operand
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant: {"OpConstant", []int{2}},
+	OpPop:      {"OpPop", []int{}},
+
+	// a statement without value ran in the main program
+	OpClearResult: {"OpClearResult", []int{}},
+
+	OpAdd:          {"OpAdd", []int{}},
+	OpSub:          {"OpSub", []int{}},
+	OpMul:          {"OpMul", []int{}},
+	OpDiv:          {"OpDiv", []int{}},
+	OpMod:          {"OpMod", []int{}},
+	OpEqual:        {"OpEqual", []int{}},
+	OpNotEqual:     {"OpNotEqual", []int{}},
+	OpGreaterThan:  {"OpGreaterThan", []int{}},
+	OpGreaterEqual: {"OpGreaterEqual", []int{}},
+	OpLessThan:     {"OpLessThan", []int{}},
+	OpLessEqual:    {"OpLessEqual", []int{}},
+	OpMinus:        {"OpMinus", []int{}},
+	OpBang:         {"OpBang", []int{}},
+
+	OpTrue:  {"OpTrue", []int{}},
+	OpFalse: {"OpFalse", []int{}},
+	OpNull:  {"OpNull", []int{}},
+	OpArray: {"OpArray", []int{2}},
+	OpHash:  {"OpHash", []int{2}},
+	OpIndex: {"OpIndex", []int{}},
+
+	// OpSetIndex stores a value in an index of an array or hash, OpUpdateIndex first combines
+	// the current value with the given one using the operator opcode in its operand
+	OpSetIndex:    {"OpSetIndex", []int{}},
+	OpUpdateIndex: {"OpUpdateIndex", []int{1}},
+
+	// OpSlice replaces a string or array and its two bounds with the slice between them, a NULL bound is left out
+	OpSlice: {"OpSlice", []int{}},
+
+	OpJumpNotTruthy: {"OpJumpNotTruthy", []int{2}},
+	OpJump:          {"OpJump", []int{2}},
+
+	// OpIter replaces an iterable with its iterator, OpIterNext pushes the next value or
+	// jumps to the operand once the iterator is exhausted, leaving it in the stack
+	OpIter:     {"OpIter", []int{}},
+	OpIterNext: {"OpIterNext", []int{2}},
+
+	OpGetGlobal: {"OpGetGlobal", []int{2}},
+	OpSetGlobal: {"OpSetGlobal", []int{2}},
+
+	// sets a global that must be already defined
+	OpAssignGlobal: {"OpAssignGlobal", []int{2}},
+
+	// the locals and free variables captured by a closure live in an object.Cell, the get and
+	// set instructions go through it. OpGetLocalCell and OpGetFreeCell push the cell itself
+	OpGetLocal:       {"OpGetLocal", []int{1}},
+	OpSetLocal:       {"OpSetLocal", []int{1}},
+	OpGetLocalCell:   {"OpGetLocalCell", []int{1}},
+	OpGetBuiltin:     {"OpGetBuiltin", []int{1}},
+	OpGetFree:        {"OpGetFree", []int{1}},
+	OpSetFree:        {"OpSetFree", []int{1}},
+	OpGetFreeCell:    {"OpGetFreeCell", []int{1}},
+	OpCurrentClosure: {"OpCurrentClosure", []int{}},
+
+	// constant index of the function and number of free variables
+	OpClosure:     {"OpClosure", []int{2, 1}},
+	OpCall:        {"OpCall", []int{1}},
+	OpReturnValue: {"OpReturnValue", []int{}},
+	OpReturn:      {"OpReturn", []int{}},
+}
+
+func Lookup(op byte) (*Definition, error) {
+	def, ok := definitions[Opcode(op)]
+	if !ok {
+		return nil, fmt.Errorf("opcode %d undefined", op)
+	}
+
+	return def, nil
+}
+
+// Creates an instruction with the opcode followed by its operands encoded in big endian
+func Make(op Opcode, operands ...int) []byte {
+	def, ok := definitions[op]
+	if !ok {
+		return []byte{}
+	}
+
+	length := 1
+	for _, w := range def.OperandWidths {
+		length += w
+	}
+
+	instruction := make([]byte, length)
+	instruction[0] = byte(op)
+
+	offset := 1
+	for i, o := range operands {
+		switch w := def.OperandWidths[i]; w {
+		case 2:
+			binary.BigEndian.PutUint16(instruction[offset:], uint16(o))
+		case 1:
+			instruction[offset] = byte(o)
+		}
+
+		offset += def.OperandWidths[i]
+	}
+
+	return instruction
+}
+
+// Decodes the operands of an instruction and returns them with the number of bytes read
+func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(def.OperandWidths))
+	offset := 0
+
+	for i, w := range def.OperandWidths {
+		switch w {
+		case 2:
+			operands[i] = int(ReadUint16(ins[offset:]))
+		case 1:
+			operands[i] = int(ReadUint8(ins[offset:]))
+		}
+
+		offset += w
+	}
+
+	return operands, offset
+}
+
+func ReadUint16(ins Instructions) uint16 {
+	return binary.BigEndian.Uint16(ins)
+}
+
+func ReadUint8(ins Instructions) uint8 {
+	return uint8(ins[0])
+}
+
+func (ins Instructions) String() string {
+	var sb strings.Builder
+
+	i := 0
+	for i < len(ins) {
+		def, err := Lookup(ins[i])
+		if err != nil {
+			fmt.Fprintf(&sb, "ERROR: %s\n", err)
+			i++
+			continue
+		}
+
+		operands, read := ReadOperands(def, ins[i+1:])
+		fmt.Fprintf(&sb, "%04d %s\n", i, ins.fmtInstruction(def, operands))
+
+		i += 1 + read
+	}
+
+	return sb.String()
+}
+
+func (ins Instructions) fmtInstruction(def *Definition, operands []int) string {
+	if n := len(operands); n != len(def.OperandWidths) {
+		return fmt.Sprintf("ERROR: operand len %d does not match defined %d\n", n, len(def.OperandWidths))
+	}
+
+	switch len(operands) {
+	case 0:
+		return def.Name
+	case 1:
+		return fmt.Sprintf("%s %d", def.Name, operands[0])
+	case 2:
+		return fmt.Sprintf("%s %d %d", def.Name, operands[0], operands[1])
+	}
+
+	return fmt.Sprintf("ERROR: unhandled operand count for %s\n", def.Name)
+}
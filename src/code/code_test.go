@@ -0,0 +1,129 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/dyxgou/parser/src/token"
+)
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		op       Opcode
+		operands []int
+		expected []byte
+	}{
+		{OpConstant, []int{65534}, []byte{byte(OpConstant), 255, 254}},
+		{OpAdd, []int{}, []byte{byte(OpAdd)}},
+		{OpGetLocal, []int{255}, []byte{byte(OpGetLocal), 255}},
+		{OpClosure, []int{65534, 255}, []byte{byte(OpClosure), 255, 254, 255}},
+	}
+
+	for _, tt := range tests {
+		instruction := Make(tt.op, tt.operands...)
+
+		if len(instruction) != len(tt.expected) {
+			t.Fatalf("instruction has wrong length. expected=%d. got=%d", len(tt.expected), len(instruction))
+		}
+
+		for i, b := range tt.expected {
+			if instruction[i] != b {
+				t.Errorf("wrong byte at pos %d. expected=%d. got=%d", i, b, instruction[i])
+			}
+		}
+	}
+}
+
+func TestInstructionsString(t *testing.T) {
+	instructions := []Instructions{
+		Make(OpAdd),
+		Make(OpGetLocal, 1),
+		Make(OpConstant, 2),
+		Make(OpConstant, 65535),
+		Make(OpClosure, 65535, 255),
+	}
+
+	expected := `0000 OpAdd
+0001 OpGetLocal 1
+0003 OpConstant 2
+0006 OpConstant 65535
+0009 OpClosure 65535 255
+`
+
+	concatted := Instructions{}
+	for _, ins := range instructions {
+		concatted = append(concatted, ins...)
+	}
+
+	if concatted.String() != expected {
+		t.Errorf("instructions wrongly formatted.\nexpected=%q\ngot=%q", expected, concatted.String())
+	}
+}
+
+func TestReadOperands(t *testing.T) {
+	tests := []struct {
+		op        Opcode
+		operands  []int
+		bytesRead int
+	}{
+		{OpConstant, []int{65535}, 2},
+		{OpGetLocal, []int{255}, 1},
+		{OpClosure, []int{65535, 255}, 3},
+	}
+
+	for _, tt := range tests {
+		instruction := Make(tt.op, tt.operands...)
+
+		def, err := Lookup(byte(tt.op))
+		if err != nil {
+			t.Fatalf("definition not found: %q", err)
+		}
+
+		operandsRead, n := ReadOperands(def, instruction[1:])
+		if n != tt.bytesRead {
+			t.Fatalf("n wrong. expected=%d. got=%d", tt.bytesRead, n)
+		}
+
+		for i, want := range tt.operands {
+			if operandsRead[i] != want {
+				t.Errorf("operand wrong. expected=%d. got=%d", want, operandsRead[i])
+			}
+		}
+	}
+}
+
+func TestSourceMap(t *testing.T) {
+	first := token.Position{Line: 1, Column: 1}
+	second := token.Position{Line: 2, Column: 5}
+
+	var m SourceMap
+	m = m.Add(0, first)
+	m = m.Add(3, first)
+	m = m.Add(4, second)
+	m = m.AddCall(6, second, "f")
+	m = m.Add(8, second)
+
+	if len(m) != 4 {
+		t.Fatalf("wrong number of entries. expected=4, got=%d (%v)", len(m), m)
+	}
+
+	tests := []struct {
+		offset   int
+		expected SourcePosition
+	}{
+		{0, SourcePosition{Offset: 0, Pos: first}},
+		{3, SourcePosition{Offset: 0, Pos: first}},
+		{5, SourcePosition{Offset: 4, Pos: second}},
+		{7, SourcePosition{Offset: 6, Pos: second, Function: "f"}},
+		{10, SourcePosition{Offset: 8, Pos: second}},
+	}
+
+	for _, tt := range tests {
+		if got := m.Lookup(tt.offset); got != tt.expected {
+			t.Errorf("Lookup(%d) wrong. expected=%+v, got=%+v", tt.offset, tt.expected, got)
+		}
+	}
+
+	if m = m.Truncate(6); len(m) != 2 {
+		t.Errorf("wrong number of entries after Truncate. expected=2, got=%d", len(m))
+	}
+}
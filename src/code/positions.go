@@ -0,0 +1,53 @@
+package code
+
+import (
+	"sort"
+
+	"github.com/dyxgou/parser/src/token"
+)
+
+// SourcePosition places in the source the instructions from Offset up to the Offset of the next entry
+type SourcePosition struct {
+	Offset int
+	Pos    token.Position
+
+	// Function is the name a function is called with, set for the OpCall instructions
+	Function string
+}
+
+// SourceMap holds the positions of the instructions of a function sorted by their offset
+type SourceMap []SourcePosition
+
+// Adds the position of the instructions starting at offset, the instructions after it share the entry
+// until another one with a different position is added
+func (m SourceMap) Add(offset int, pos token.Position) SourceMap {
+	if len(m) > 0 && m[len(m)-1].Pos == pos && m[len(m)-1].Function == "" {
+		return m
+	}
+
+	return append(m, SourcePosition{Offset: offset, Pos: pos})
+}
+
+// Adds the entry of an OpCall at offset, every call has its own one
+func (m SourceMap) AddCall(offset int, pos token.Position, function string) SourceMap {
+	return append(m, SourcePosition{Offset: offset, Pos: pos, Function: function})
+}
+
+// Removes the entries of the instructions from offset on, used when they are removed
+func (m SourceMap) Truncate(offset int) SourceMap {
+	return m[:m.search(offset)]
+}
+
+// Returns the entry of the instruction at offset, the zero one when it has no position
+func (m SourceMap) Lookup(offset int) SourcePosition {
+	if i := m.search(offset + 1); i > 0 {
+		return m[i-1]
+	}
+
+	return SourcePosition{}
+}
+
+// Returns the index of the first entry whose offset isn't below offset
+func (m SourceMap) search(offset int) int {
+	return sort.Search(len(m), func(i int) bool { return m[i].Offset >= offset })
+}
@@ -0,0 +1,756 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dyxgou/parser/src/ast"
+	"github.com/dyxgou/parser/src/code"
+	"github.com/dyxgou/parser/src/object"
+	"github.com/dyxgou/parser/src/token"
+)
+
+type EmittedInstruction struct {
+	Opcode   code.Opcode
+	Position int
+}
+
+type CompilationScope struct {
+	instructions        code.Instructions
+	positions           code.SourceMap
+	lastInstruction     EmittedInstruction
+	previousInstruction EmittedInstruction
+
+	loops []*loopScope // loops being compiled, the innermost is the last
+}
+
+type loopScope struct {
+	start  int   // position a continue jumps to
+	breaks []int // positions of the jumps that leave the loop, patched once its end is known
+}
+
+type Compiler struct {
+	constants   []object.Object
+	symbolTable *SymbolTable
+
+	scopes     []CompilationScope
+	scopeIndex int
+
+	pos token.Position // position of the node being compiled, the place of the instructions emitted for it
+}
+
+type Bytecode struct {
+	Instructions code.Instructions
+	Constants    []object.Object
+	Globals      []string       // names of the global slots, used to report undefined identifiers
+	Positions    code.SourceMap // places the instructions in the source, used to place the runtime errors
+}
+
+func New() *Compiler {
+	symbolTable := NewSymbolTable()
+
+	for i, b := range object.Builtins {
+		symbolTable.DefineBuiltin(i, b.Name)
+	}
+
+	return NewWithState(symbolTable, []object.Object{})
+}
+
+// Creates a compiler that keeps the bindings and constants of a previous compilation, used by the REPL
+func NewWithState(s *SymbolTable, constants []object.Object) *Compiler {
+	mainScope := CompilationScope{
+		instructions: code.Instructions{},
+	}
+
+	return &Compiler{
+		constants:   constants,
+		symbolTable: s,
+		scopes:      []CompilationScope{mainScope},
+		scopeIndex:  0,
+	}
+}
+
+func (c *Compiler) SymbolTable() *SymbolTable {
+	return c.symbolTable
+}
+
+func (c *Compiler) Bytecode() *Bytecode {
+	return &Bytecode{
+		Instructions: c.currentInstructions(),
+		Constants:    c.constants,
+		Globals:      c.symbolTable.GlobalNames(),
+		Positions:    c.scopes[c.scopeIndex].positions,
+	}
+}
+
+func (c *Compiler) Compile(node ast.Node) error {
+	// the instructions of a node are placed at it, the ones of its children at the children
+	prev := c.pos
+	c.pos = node.Pos()
+	defer func() { c.pos = prev }()
+
+	switch node := node.(type) {
+	case *ast.Program:
+		for _, s := range node.Statements {
+			if err := c.Compile(s); err != nil {
+				return err
+			}
+		}
+	case *ast.ExpressionStatement:
+		if node.Expression == nil {
+			return nil
+		}
+
+		if err := c.Compile(node.Expression); err != nil {
+			return err
+		}
+		c.emit(code.OpPop)
+	case *ast.BlockStatement:
+		for _, s := range node.Statements {
+			if err := c.Compile(s); err != nil {
+				return err
+			}
+		}
+	case *ast.LetStatement:
+		return c.compileLetStatement(node)
+	case *ast.WhileStatement:
+		return c.compileWhileStatement(node)
+	case *ast.ForStatement:
+		return c.compileForStatement(node)
+	case *ast.BreakStatement:
+		loop := c.currentLoop()
+		if loop == nil {
+			return newError(node, "break outside of a loop")
+		}
+
+		loop.breaks = append(loop.breaks, c.emit(code.OpJump, 9999))
+	case *ast.ContinueStatement:
+		loop := c.currentLoop()
+		if loop == nil {
+			return newError(node, "continue outside of a loop")
+		}
+
+		c.emit(code.OpJump, loop.start)
+	case *ast.ReturnStatement:
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+		c.emit(code.OpReturnValue)
+	case *ast.Identifier:
+		c.loadSymbol(c.resolve(node.Value()))
+	case *ast.IntegerLiteral:
+		integer := &object.Integer{Value: node.Value}
+		c.emit(code.OpConstant, c.addConstant(integer))
+	case *ast.FloatLiteral:
+		float := &object.Float{Value: node.Value}
+		c.emit(code.OpConstant, c.addConstant(float))
+	case *ast.StringLiteral:
+		str := &object.String{Value: node.Value()}
+		c.emit(code.OpConstant, c.addConstant(str))
+	case *ast.Boolean:
+		if node.Value {
+			c.emit(code.OpTrue)
+		} else {
+			c.emit(code.OpFalse)
+		}
+	case *ast.PrefixExpression:
+		return c.compilePrefixExpression(node)
+	case *ast.InfixExpression:
+		return c.compileInfixExpression(node)
+	case *ast.AssignExpression:
+		return c.compileAssignExpression(node)
+	case *ast.IfExpression:
+		return c.compileIfExpression(node)
+	case *ast.FunctionLiteral:
+		return c.compileFunctionLiteral(node, "")
+	case *ast.CallExpression:
+		if err := c.Compile(node.Function); err != nil {
+			return err
+		}
+
+		for _, arg := range node.Arguments {
+			if err := c.Compile(arg); err != nil {
+				return err
+			}
+		}
+
+		c.emitCall(node)
+	case *ast.ArrayLiteral:
+		for _, elem := range node.Elements {
+			if err := c.Compile(elem); err != nil {
+				return err
+			}
+		}
+
+		c.emit(code.OpArray, len(node.Elements))
+	case *ast.HashLiteral:
+		for _, pair := range node.Pairs {
+			if err := c.Compile(pair.Key); err != nil {
+				return err
+			}
+
+			if err := c.Compile(pair.Value); err != nil {
+				return err
+			}
+		}
+
+		c.emit(code.OpHash, len(node.Pairs)*2)
+	case *ast.IndexExpression:
+		if err := c.Compile(node.Left); err != nil {
+			return err
+		}
+
+		if err := c.Compile(node.Index); err != nil {
+			return err
+		}
+
+		c.emit(code.OpIndex)
+	case *ast.SliceExpression:
+		if err := c.Compile(node.Left); err != nil {
+			return err
+		}
+
+		for _, bound := range []ast.Expression{node.Low, node.High} {
+			if bound == nil {
+				c.emit(code.OpNull)
+				continue
+			}
+
+			if err := c.Compile(bound); err != nil {
+				return err
+			}
+		}
+
+		c.emit(code.OpSlice)
+	case *ast.ImportExpression, *ast.MemberExpression:
+		// modules are only loaded by the evaluator
+		return newError(node, "modules are not supported by the vm")
+	default:
+		return newError(node, "node %T not supported by the compiler", node)
+	}
+
+	return nil
+}
+
+func (c *Compiler) compileLetStatement(node *ast.LetStatement) error {
+	if fn, ok := node.Value.(*ast.FunctionLiteral); ok {
+		if err := c.compileFunctionLiteral(fn, node.Name.Value()); err != nil {
+			return err
+		}
+	} else if err := c.Compile(node.Value); err != nil {
+		return err
+	}
+
+	// the name is defined after the value, so the value still sees the outer binding
+	c.setSymbol(c.symbolTable.Define(node.Name.Value()))
+	c.clearResult()
+
+	return nil
+}
+
+func (c *Compiler) compileWhileStatement(node *ast.WhileStatement) error {
+	start := len(c.currentInstructions())
+
+	if err := c.Compile(node.Condition); err != nil {
+		return err
+	}
+
+	jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+	loop := c.enterLoop(start)
+	if err := c.Compile(node.Body); err != nil {
+		return err
+	}
+	c.leaveLoop()
+
+	c.emit(code.OpJump, start)
+
+	end := len(c.currentInstructions())
+	c.changeOperand(jumpNotTruthyPos, end)
+	for _, pos := range loop.breaks {
+		c.changeOperand(pos, end)
+	}
+
+	c.clearResult()
+
+	return nil
+}
+
+func (c *Compiler) compileForStatement(node *ast.ForStatement) error {
+	if err := c.Compile(node.Iterable); err != nil {
+		return err
+	}
+
+	c.emit(code.OpIter)
+
+	start := c.emit(code.OpIterNext, 9999)
+	c.setSymbol(c.symbolTable.Define(node.Variable.Value()))
+
+	loop := c.enterLoop(start)
+	if err := c.Compile(node.Body); err != nil {
+		return err
+	}
+	c.leaveLoop()
+
+	c.emit(code.OpJump, start)
+
+	// the iterator stays in the stack during the loop, every way out removes it. The pop isn't
+	// recorded as the last instruction, it isn't the value of an expression statement
+	end := c.addInstruction(code.Make(code.OpPop))
+	c.changeOperand(start, end)
+	for _, pos := range loop.breaks {
+		c.changeOperand(pos, end)
+	}
+
+	c.clearResult()
+
+	return nil
+}
+
+func (c *Compiler) enterLoop(start int) *loopScope {
+	loop := &loopScope{start: start}
+
+	scope := &c.scopes[c.scopeIndex]
+	scope.loops = append(scope.loops, loop)
+
+	return loop
+}
+
+func (c *Compiler) leaveLoop() {
+	scope := &c.scopes[c.scopeIndex]
+	scope.loops = scope.loops[:len(scope.loops)-1]
+}
+
+func (c *Compiler) currentLoop() *loopScope {
+	loops := c.scopes[c.scopeIndex].loops
+	if len(loops) == 0 {
+		return nil
+	}
+
+	return loops[len(loops)-1]
+}
+
+// A statement without value in the main program leaves it without result, as the evaluator does
+func (c *Compiler) clearResult() {
+	if c.scopeIndex == 0 {
+		c.emit(code.OpClearResult)
+	}
+}
+
+func (c *Compiler) setSymbol(sym Symbol) {
+	if sym.Scope == GlobalScope {
+		c.emit(code.OpSetGlobal, sym.Index)
+	} else {
+		c.emit(code.OpSetLocal, sym.Index)
+	}
+}
+
+func (c *Compiler) compilePrefixExpression(node *ast.PrefixExpression) error {
+	if err := c.Compile(node.Right); err != nil {
+		return err
+	}
+
+	switch node.Operator() {
+	case "!":
+		c.emit(code.OpBang)
+	case "-":
+		c.emit(code.OpMinus)
+	default:
+		return newError(node, "unknown operator %s", node.Operator())
+	}
+
+	return nil
+}
+
+var infixOpcodes = map[string]code.Opcode{
+	"+":  code.OpAdd,
+	"-":  code.OpSub,
+	"*":  code.OpMul,
+	"/":  code.OpDiv,
+	"%":  code.OpMod,
+	">":  code.OpGreaterThan,
+	"<":  code.OpLessThan,
+	">=": code.OpGreaterEqual,
+	"<=": code.OpLessEqual,
+	"==": code.OpEqual,
+	"!=": code.OpNotEqual,
+}
+
+func (c *Compiler) compileInfixExpression(node *ast.InfixExpression) error {
+	if err := c.Compile(node.Left); err != nil {
+		return err
+	}
+
+	if op := node.Operator(); op == "&&" || op == "||" {
+		return c.compileLogicalExpression(op, node.Right)
+	}
+
+	if err := c.Compile(node.Right); err != nil {
+		return err
+	}
+
+	op, ok := infixOpcodes[node.Operator()]
+	if !ok {
+		return newError(node, "unknown operator %s", node.Operator())
+	}
+
+	c.emit(op)
+
+	return nil
+}
+
+// Compiles && and || so the right side only runs when the left one doesn't decide the result,
+// the left side is already in the stack. The result is always a boolean
+func (c *Compiler) compileLogicalExpression(operator string, right ast.Expression) error {
+	shortCircuitPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+	// a truthy left side decides ||, a falsy one decides &&
+	if operator == "||" {
+		c.emit(code.OpTrue)
+		endPos := c.emit(code.OpJump, 9999)
+		c.changeOperand(shortCircuitPos, len(c.currentInstructions()))
+
+		if err := c.compileTruthiness(right); err != nil {
+			return err
+		}
+
+		c.changeOperand(endPos, len(c.currentInstructions()))
+		return nil
+	}
+
+	if err := c.compileTruthiness(right); err != nil {
+		return err
+	}
+
+	endPos := c.emit(code.OpJump, 9999)
+	c.changeOperand(shortCircuitPos, len(c.currentInstructions()))
+	c.emit(code.OpFalse)
+	c.changeOperand(endPos, len(c.currentInstructions()))
+
+	return nil
+}
+
+// Compiles an expression and turns its value into the boolean of its truthiness
+func (c *Compiler) compileTruthiness(exp ast.Expression) error {
+	if err := c.Compile(exp); err != nil {
+		return err
+	}
+
+	c.emit(code.OpBang)
+	c.emit(code.OpBang)
+
+	return nil
+}
+
+func (c *Compiler) compileAssignExpression(node *ast.AssignExpression) error {
+	// a compound assignment like += applies the operator before storing
+	var op code.Opcode
+	compound := node.Operator() != "="
+
+	if compound {
+		var ok bool
+		if op, ok = infixOpcodes[strings.TrimSuffix(node.Operator(), "=")]; !ok {
+			return newError(node, "unknown operator %s", node.Operator())
+		}
+	}
+
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		sym := c.resolve(target.Value())
+
+		switch sym.Scope {
+		case BuiltinScope:
+			return newError(node, "cannot assign to builtin function: %s", sym.Name)
+		case FunctionScope:
+			return newError(node, "cannot assign to function %s inside its own body", sym.Name)
+		}
+
+		if compound {
+			c.loadSymbol(sym)
+		}
+
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+
+		if compound {
+			c.emit(op)
+		}
+
+		switch sym.Scope {
+		case GlobalScope:
+			c.emit(code.OpAssignGlobal, sym.Index)
+		case LocalScope:
+			c.emit(code.OpSetLocal, sym.Index)
+		case FreeScope:
+			c.emit(code.OpSetFree, sym.Index)
+		}
+
+		// the assignment is an expression whose value is the one assigned
+		c.loadSymbol(sym)
+	case *ast.IndexExpression:
+		if err := c.Compile(target.Left); err != nil {
+			return err
+		}
+
+		if err := c.Compile(target.Index); err != nil {
+			return err
+		}
+
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+
+		if compound {
+			c.emit(code.OpUpdateIndex, int(op))
+		} else {
+			c.emit(code.OpSetIndex)
+		}
+	default:
+		return newError(node, "cannot assign to %s", node.Target)
+	}
+
+	return nil
+}
+
+func (c *Compiler) compileIfExpression(node *ast.IfExpression) error {
+	if err := c.Compile(node.Condition); err != nil {
+		return err
+	}
+
+	// the operand is patched once the consequence is compiled
+	jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+	if err := c.compileBlockValue(node.Consequence); err != nil {
+		return err
+	}
+
+	jumpPos := c.emit(code.OpJump, 9999)
+	c.changeOperand(jumpNotTruthyPos, len(c.currentInstructions()))
+
+	if node.Alternative == nil {
+		c.emit(code.OpNull)
+	} else if err := c.compileBlockValue(node.Alternative); err != nil {
+		return err
+	}
+
+	c.changeOperand(jumpPos, len(c.currentInstructions()))
+
+	return nil
+}
+
+// Compiles a block whose last value stays on the stack, a block that doesn't end with an expression leaves NULL
+func (c *Compiler) compileBlockValue(block *ast.BlockStatement) error {
+	if err := c.Compile(block); err != nil {
+		return err
+	}
+
+	if c.lastInstructionIs(code.OpPop) {
+		c.removeLastPop()
+	} else {
+		c.emit(code.OpNull)
+	}
+
+	return nil
+}
+
+func (c *Compiler) compileFunctionLiteral(node *ast.FunctionLiteral, name string) error {
+	c.enterScope()
+
+	if name != "" {
+		c.symbolTable.DefineFunctionName(name)
+	}
+
+	for _, p := range node.Params {
+		c.symbolTable.Define(p.Value())
+	}
+
+	if err := c.Compile(node.Body); err != nil {
+		return err
+	}
+
+	// the value of the last expression is the implicit return value
+	if c.lastInstructionIs(code.OpPop) {
+		c.replaceLastPopWithReturn()
+	}
+
+	if !c.lastInstructionIs(code.OpReturnValue) {
+		c.emit(code.OpReturn)
+	}
+
+	freeSymbols := c.symbolTable.FreeSymbols
+	numLocals := c.symbolTable.numDefinitions
+	locals := c.symbolTable.slotNames()
+	instructions, positions := c.leaveScope()
+
+	// the closure captures the cells of the free variables, not their values
+	for _, s := range freeSymbols {
+		c.loadCell(s)
+	}
+
+	fn := &object.CompiledFunction{
+		Instructions:  instructions,
+		NumLocals:     numLocals,
+		NumParameters: len(node.Params),
+		Positions:     positions,
+		Locals:        locals,
+	}
+
+	c.emit(code.OpClosure, c.addConstant(fn), len(freeSymbols))
+
+	return nil
+}
+
+// Resolves a name, a name that isn't defined anywhere becomes a global so it can be defined later, as the evaluator does
+func (c *Compiler) resolve(name string) Symbol {
+	if sym, ok := c.symbolTable.Resolve(name); ok {
+		return sym
+	}
+
+	return c.symbolTable.global().Define(name)
+}
+
+func (c *Compiler) loadSymbol(s Symbol) {
+	switch s.Scope {
+	case GlobalScope:
+		c.emit(code.OpGetGlobal, s.Index)
+	case LocalScope:
+		c.emit(code.OpGetLocal, s.Index)
+	case BuiltinScope:
+		c.emit(code.OpGetBuiltin, s.Index)
+	case FreeScope:
+		c.emit(code.OpGetFree, s.Index)
+	case FunctionScope:
+		c.emit(code.OpCurrentClosure)
+	}
+}
+
+// Loads the cell that holds a variable to capture it in a closure
+func (c *Compiler) loadCell(s Symbol) {
+	switch s.Scope {
+	case LocalScope:
+		c.emit(code.OpGetLocalCell, s.Index)
+	case FreeScope:
+		c.emit(code.OpGetFreeCell, s.Index)
+	default:
+		c.loadSymbol(s)
+	}
+}
+
+func (c *Compiler) addConstant(obj object.Object) int {
+	c.constants = append(c.constants, obj)
+	return len(c.constants) - 1
+}
+
+// Emits an instruction and returns its position
+func (c *Compiler) emit(op code.Opcode, operands ...int) int {
+	ins := code.Make(op, operands...)
+	pos := c.addInstruction(ins)
+
+	c.setLastInstruction(op, pos)
+
+	return pos
+}
+
+func (c *Compiler) addInstruction(ins []byte) int {
+	scope := &c.scopes[c.scopeIndex]
+
+	pos := len(scope.instructions)
+	scope.instructions = append(scope.instructions, ins...)
+	scope.positions = scope.positions.Add(pos, c.pos)
+
+	return pos
+}
+
+// Emits the call, its position keeps the name the function is called with for the stack traces
+func (c *Compiler) emitCall(node *ast.CallExpression) {
+	pos := c.addInstruction(code.Make(code.OpCall, len(node.Arguments)))
+	c.setLastInstruction(code.OpCall, pos)
+
+	scope := &c.scopes[c.scopeIndex]
+	scope.positions = scope.positions.Truncate(pos).AddCall(pos, node.Pos(), callName(node))
+}
+
+// Returns the name a function is called with, "fn" when it isn't called by its name
+func callName(node *ast.CallExpression) string {
+	if ident, ok := node.Function.(*ast.Identifier); ok {
+		return ident.Value()
+	}
+
+	return "fn"
+}
+
+func (c *Compiler) setLastInstruction(op code.Opcode, pos int) {
+	scope := &c.scopes[c.scopeIndex]
+
+	scope.previousInstruction = scope.lastInstruction
+	scope.lastInstruction = EmittedInstruction{Opcode: op, Position: pos}
+}
+
+func (c *Compiler) currentInstructions() code.Instructions {
+	return c.scopes[c.scopeIndex].instructions
+}
+
+func (c *Compiler) lastInstructionIs(op code.Opcode) bool {
+	if len(c.currentInstructions()) == 0 {
+		return false
+	}
+
+	return c.scopes[c.scopeIndex].lastInstruction.Opcode == op
+}
+
+func (c *Compiler) removeLastPop() {
+	scope := &c.scopes[c.scopeIndex]
+
+	scope.instructions = scope.instructions[:scope.lastInstruction.Position]
+	scope.positions = scope.positions.Truncate(scope.lastInstruction.Position)
+	scope.lastInstruction = scope.previousInstruction
+}
+
+func (c *Compiler) replaceLastPopWithReturn() {
+	lastPos := c.scopes[c.scopeIndex].lastInstruction.Position
+	c.replaceInstruction(lastPos, code.Make(code.OpReturnValue))
+
+	c.scopes[c.scopeIndex].lastInstruction.Opcode = code.OpReturnValue
+}
+
+func (c *Compiler) replaceInstruction(pos int, ins []byte) {
+	copy(c.currentInstructions()[pos:], ins)
+}
+
+func (c *Compiler) changeOperand(opPos int, operand int) {
+	op := code.Opcode(c.currentInstructions()[opPos])
+	c.replaceInstruction(opPos, code.Make(op, operand))
+}
+
+func (c *Compiler) enterScope() {
+	c.scopes = append(c.scopes, CompilationScope{instructions: code.Instructions{}})
+	c.scopeIndex++
+
+	c.symbolTable = NewEnclosedSymbolTable(c.symbolTable)
+}
+
+func (c *Compiler) leaveScope() (code.Instructions, code.SourceMap) {
+	scope := c.scopes[c.scopeIndex]
+
+	c.scopes = c.scopes[:len(c.scopes)-1]
+	c.scopeIndex--
+
+	c.symbolTable = c.symbolTable.Outer
+
+	return scope.instructions, scope.positions
+}
+
+// Error is a compilation error placed in the source
+type Error struct {
+	Node ast.Node
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Node.Pos(), e.Msg)
+}
+
+func newError(node ast.Node, format string, a ...any) *Error {
+	return &Error{Node: node, Msg: fmt.Sprintf(format, a...)}
+}
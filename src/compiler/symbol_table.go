@@ -0,0 +1,129 @@
+package compiler
+
+type SymbolScope string
+
+const (
+	GlobalScope   SymbolScope = "GLOBAL"
+	LocalScope    SymbolScope = "LOCAL"
+	BuiltinScope  SymbolScope = "BUILTIN"
+	FreeScope     SymbolScope = "FREE"
+	FunctionScope SymbolScope = "FUNCTION"
+)
+
+type Symbol struct {
+	Name  string
+	Scope SymbolScope
+	Index int
+}
+
+type SymbolTable struct {
+	Outer *SymbolTable
+
+	store          map[string]Symbol
+	numDefinitions int
+
+	FreeSymbols []Symbol
+}
+
+func NewSymbolTable() *SymbolTable {
+	return &SymbolTable{
+		store:       make(map[string]Symbol),
+		FreeSymbols: make([]Symbol, 0),
+	}
+}
+
+func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
+	s := NewSymbolTable()
+	s.Outer = outer
+
+	return s
+}
+
+// Defines a new binding, a name already defined in the same scope keeps its slot so it can be
+// redefined, as the evaluator updates the binding of its enviroment
+func (s *SymbolTable) Define(name string) Symbol {
+	if sym, ok := s.store[name]; ok && (sym.Scope == GlobalScope || sym.Scope == LocalScope) {
+		return sym
+	}
+
+	sym := Symbol{Name: name, Index: s.numDefinitions}
+	if s.Outer == nil {
+		sym.Scope = GlobalScope
+	} else {
+		sym.Scope = LocalScope
+	}
+
+	s.store[name] = sym
+	s.numDefinitions++
+
+	return sym
+}
+
+func (s *SymbolTable) DefineBuiltin(index int, name string) Symbol {
+	sym := Symbol{Name: name, Scope: BuiltinScope, Index: index}
+	s.store[name] = sym
+
+	return sym
+}
+
+// Defines the name of the function being compiled so it can call itself
+func (s *SymbolTable) DefineFunctionName(name string) Symbol {
+	sym := Symbol{Name: name, Scope: FunctionScope, Index: 0}
+	s.store[name] = sym
+
+	return sym
+}
+
+func (s *SymbolTable) defineFree(original Symbol) Symbol {
+	s.FreeSymbols = append(s.FreeSymbols, original)
+
+	sym := Symbol{Name: original.Name, Scope: FreeScope, Index: len(s.FreeSymbols) - 1}
+	s.store[original.Name] = sym
+
+	return sym
+}
+
+func (s *SymbolTable) Resolve(name string) (Symbol, bool) {
+	sym, ok := s.store[name]
+	if ok || s.Outer == nil {
+		return sym, ok
+	}
+
+	sym, ok = s.Outer.Resolve(name)
+	if !ok {
+		return sym, ok
+	}
+
+	if sym.Scope == GlobalScope || sym.Scope == BuiltinScope {
+		return sym, ok
+	}
+
+	return s.defineFree(sym), true
+}
+
+// Returns the global table that encloses this one
+func (s *SymbolTable) global() *SymbolTable {
+	for s.Outer != nil {
+		s = s.Outer
+	}
+
+	return s
+}
+
+// Returns the names of the global slots indexed by their slot
+func (s *SymbolTable) GlobalNames() []string {
+	return s.global().slotNames()
+}
+
+// Returns the names of the slots defined by the table indexed by their slot
+func (s *SymbolTable) slotNames() []string {
+	names := make([]string, s.numDefinitions)
+
+	for name, sym := range s.store {
+		if sym.Scope == GlobalScope || sym.Scope == LocalScope {
+			names[sym.Index] = name
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,310 @@
+package evaluator
+
+import (
+	"context"
+	"slices"
+
+	"github.com/dyxgou/parser/src/ast"
+	"github.com/dyxgou/parser/src/object"
+)
+
+// DefaultMaxCallDepth is the number of nested calls allowed by New
+const DefaultMaxCallDepth = object.DefaultMaxCallDepth
+
+// the context is checked once every contextCheckSteps steps
+const contextCheckSteps = 1024
+
+// Evaluator walks the tree of a program keeping the state of the calls being run
+type Evaluator struct {
+	// MaxCallDepth is the maximum number of nested function calls, tail calls don't add to it.
+	// Zero means no limit
+	MaxCallDepth int
+
+	// MaxSteps is the maximum number of nodes evaluated, counting every Eval of the evaluator.
+	// Zero means no limit
+	MaxSteps int
+
+	// Modules loads the files imported by the program, a nil one is created on the first import
+	Modules *Modules
+
+	// streams of print and input
+	object.IO
+
+	ctx     context.Context
+	steps   int
+	frames  []object.StackFrame // functions being called, the innermost is the last
+	builtin object.StackFrame   // call of the builtin being run
+}
+
+func New() *Evaluator {
+	return &Evaluator{MaxCallDepth: DefaultMaxCallDepth}
+}
+
+// Evaluates the node with a new Evaluator
+func Eval(node ast.Node, env *object.Enviroment) object.Object {
+	return New().Eval(node, env)
+}
+
+// Evaluates the node until it's done or ctx is, a cancelled evaluation returns an error whose Cause is object.ErrCancelled
+func (e *Evaluator) EvalContext(ctx context.Context, node ast.Node, env *object.Enviroment) object.Object {
+	if err := object.ContextError(ctx); err != nil {
+		return err
+	}
+
+	prev := e.ctx
+	e.ctx = ctx
+	defer func() { e.ctx = prev }()
+
+	return e.Eval(node, env)
+}
+
+func (e *Evaluator) Eval(node ast.Node, env *object.Enviroment) object.Object {
+	var obj object.Object
+	if err := e.step(); err != nil {
+		obj = err
+	} else {
+		obj = e.eval(node, env)
+	}
+
+	// the innermost node that produces an error is the one that places it
+	if err, ok := obj.(*object.Error); ok && !err.Pos.IsValid() {
+		err.Pos = node.Pos()
+		err.Stack = e.stackTrace()
+	}
+
+	return obj
+}
+
+// Calls a function or a builtin with a new Evaluator
+func Call(fn object.Object, args ...object.Object) (object.Object, error) {
+	return New().Call(fn, args...)
+}
+
+// Calls a function or a builtin from Go, an error of the call is returned as an *object.Error
+func (e *Evaluator) Call(fn object.Object, args ...object.Object) (object.Object, error) {
+	return e.CallContext(context.Background(), fn, args...)
+}
+
+// Calls a function or a builtin until it returns or ctx is done
+func (e *Evaluator) CallContext(ctx context.Context, fn object.Object, args ...object.Object) (object.Object, error) {
+	if err := object.ContextError(ctx); err != nil {
+		return nil, err
+	}
+
+	prev := e.ctx
+	e.ctx = ctx
+	defer func() { e.ctx = prev }()
+
+	if fn == nil {
+		fn = NULL
+	}
+
+	args = slices.Clone(args)
+	for i, arg := range args {
+		if arg == nil {
+			args[i] = NULL
+		}
+	}
+
+	result := e.applyFunction(fn, args, object.StackFrame{Function: "fn"})
+	if err, ok := result.(*object.Error); ok {
+		return nil, err
+	}
+
+	if result == nil {
+		return NULL, nil
+	}
+
+	return result, nil
+}
+
+// Context is done once the evaluation must stop
+func (e *Evaluator) Context() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+
+	return e.ctx
+}
+
+// Counts an evaluation step, returns the error that stops the program once it runs out of budget or its context is done
+func (e *Evaluator) step() *object.Error {
+	e.steps++
+
+	if e.MaxSteps > 0 && e.steps > e.MaxSteps {
+		return object.BudgetError(e.MaxSteps)
+	}
+
+	if e.ctx != nil && e.steps%contextCheckSteps == 0 {
+		return object.ContextError(e.ctx)
+	}
+
+	return nil
+}
+
+func (e *Evaluator) insideFunction() bool {
+	return len(e.frames) > 0
+}
+
+// Evaluates the function and the arguments of a call
+func (e *Evaluator) evalCall(node *ast.CallExpression, env *object.Enviroment) (object.Object, []object.Object, object.Object) {
+	function := e.Eval(node.Function, env)
+	if isError(function) {
+		return nil, nil, function
+	}
+
+	args := e.evalExpressions(node.Arguments, env)
+	if len(args) == 1 && isError(args[0]) {
+		return nil, nil, args[0]
+	}
+
+	return function, args, nil
+}
+
+// Evaluates a node whose value is the value of the function being run, a call there
+// becomes a TailCall that applyFunction runs in place of the current call
+func (e *Evaluator) evalTail(node ast.Node, env *object.Enviroment) object.Object {
+	switch node := node.(type) {
+	case *ast.BlockStatement:
+		var result object.Object
+
+		for i, stmt := range node.Statements {
+			if i == len(node.Statements)-1 {
+				return e.evalTail(stmt, env)
+			}
+
+			result = e.Eval(stmt, env)
+			if result != nil {
+				switch result.Type() {
+				case object.ReturnType, object.ErrorType, object.BreakType, object.ContinueType:
+					return result
+				}
+			}
+		}
+
+		return result
+	case *ast.ExpressionStatement:
+		return e.evalTail(node.Expression, env)
+	case *ast.IfExpression:
+		condition := e.Eval(node.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+
+		if isTruthy(condition) {
+			return e.evalTail(node.Consequence, env)
+		} else if node.Alternative != nil {
+			return e.evalTail(node.Alternative, env)
+		}
+
+		return NULL
+	case *ast.CallExpression:
+		function, args, errObj := e.evalCall(node, env)
+		if errObj != nil {
+			return errObj
+		}
+
+		return &object.TailCall{Fn: function, Args: args, Caller: callFrame(node)}
+	}
+
+	return e.Eval(node, env)
+}
+
+// Apply calls a function or a builtin from a builtin, the call is placed where the builtin was called
+func (e *Evaluator) Apply(fn object.Object, args ...object.Object) object.Object {
+	result := e.applyFunction(fn, args, e.builtin)
+	if result == nil {
+		return NULL
+	}
+
+	return result
+}
+
+// Calls a function, the tail calls it returns run in its frame
+func (e *Evaluator) applyFunction(fn object.Object, args []object.Object, caller object.StackFrame) object.Object {
+	depth := len(e.frames)
+	defer func() { e.frames = e.frames[:depth] }()
+
+	for {
+		var result object.Object
+
+		switch function := fn.(type) {
+		case *object.Function:
+			if len(args) != len(function.Parameters) {
+				return e.callError(caller, "wrong number of arguments: want=%d, got=%d", len(function.Parameters), len(args))
+			}
+
+			if len(e.frames) == depth {
+				if e.MaxCallDepth > 0 && depth >= e.MaxCallDepth {
+					return e.placeCallError(caller, object.CallDepthError(e.MaxCallDepth))
+				}
+
+				e.frames = append(e.frames, caller)
+			} else {
+				e.frames[depth] = caller
+			}
+
+			result = unwrapReturnerValue(e.evalTail(function.Body, extendFunctionEnv(function, args)))
+		case *object.BuiltIn:
+			result = e.applyBuiltIn(function, args, caller)
+		default:
+			return e.callError(caller, "not a function. got=%q", fn.String())
+		}
+
+		tail, ok := result.(*object.TailCall)
+		if !ok {
+			return result
+		}
+
+		fn, args, caller = tail.Fn, tail.Args, tail.Caller
+	}
+}
+
+func (e *Evaluator) applyBuiltIn(builtin *object.BuiltIn, args []object.Object, caller object.StackFrame) object.Object {
+	prev := e.builtin
+	e.builtin = caller
+	defer func() { e.builtin = prev }()
+
+	return builtin.Fn(e, args...)
+}
+
+// Creates an error of a call, a tail call is no longer inside the node that made it so it places the error itself
+func (e *Evaluator) callError(caller object.StackFrame, format string, a ...any) *object.Error {
+	return e.placeCallError(caller, newError(format, a...))
+}
+
+func (e *Evaluator) placeCallError(caller object.StackFrame, err *object.Error) *object.Error {
+	err.Pos = caller.Pos
+	err.Stack = e.stackTrace()
+
+	return err
+}
+
+// Returns the functions being called, the innermost first
+func (e *Evaluator) stackTrace() []object.StackFrame {
+	if len(e.frames) == 0 {
+		return nil
+	}
+
+	stack := make([]object.StackFrame, len(e.frames))
+	for i, frame := range e.frames {
+		stack[len(e.frames)-1-i] = frame
+	}
+
+	return stack
+}
+
+func callFrame(node *ast.CallExpression) object.StackFrame {
+	name := "fn"
+	switch fn := node.Function.(type) {
+	case *ast.Identifier:
+		name = fn.Value()
+	case *ast.MemberExpression:
+		name = fn.Property.Value()
+		if ident, ok := fn.Object.(*ast.Identifier); ok {
+			name = ident.Value() + "." + name
+		}
+	}
+
+	return object.StackFrame{Function: name, Pos: node.Pos()}
+}
@@ -2,15 +2,20 @@ package evaluator
 
 import (
 	"fmt"
+	"math"
+	"strings"
 
 	"github.com/dyxgou/parser/src/ast"
 	"github.com/dyxgou/parser/src/object"
 )
 
 var (
-	NULL  = &object.Null{}
-	TRUE  = &object.Boolean{Value: true}
-	FALSE = &object.Boolean{Value: false}
+	NULL  = object.NULL
+	TRUE  = object.TRUE
+	FALSE = object.FALSE
+
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
 )
 
 const (
@@ -19,26 +24,37 @@ const (
 	minusOperator        string = "-"
 	productoOperator     string = "*"
 	divitionOperator     string = "/"
+	moduloOperator       string = "%"
 	greaterOperator      string = ">"
 	lessOperator         string = "<"
 	greaterEqualOperator string = ">="
 	lessEqualOperator    string = "<="
 	equalOperator        string = "=="
 	notEqualOperator     string = "!="
+	andOperator          string = "&&"
+	orOperator           string = "||"
 )
 
-func Eval(node ast.Node, env *object.Enviroment) object.Object {
+func (e *Evaluator) eval(node ast.Node, env *object.Enviroment) object.Object {
 	switch node := node.(type) {
 	case *ast.Program:
-		return evalProgram(node.Statements, env)
+		return e.evalProgram(node.Statements, env)
 	case *ast.ExpressionStatement:
-		return Eval(node.Expression, env)
+		return e.Eval(node.Expression, env)
 	case *ast.BlockStatement:
-		return evalStatements(node.Statements, env)
+		return e.evalStatements(node.Statements, env)
 	case *ast.IfExpression:
-		return evalIfExpression(node, env)
+		return e.evalIfExpression(node, env)
+	case *ast.WhileStatement:
+		return e.evalWhileStatement(node, env)
+	case *ast.ForStatement:
+		return e.evalForStatement(node, env)
+	case *ast.BreakStatement:
+		return BREAK
+	case *ast.ContinueStatement:
+		return CONTINUE
 	case *ast.LetStatement:
-		val := Eval(node.Value, env)
+		val := e.Eval(node.Value, env)
 
 		if isError(val) {
 			return val
@@ -52,47 +68,55 @@ func Eval(node ast.Node, env *object.Enviroment) object.Object {
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
 	case *ast.ReturnStatement:
-		value := Eval(node.Value, env)
+		var value object.Object
+
+		// a return leaves the function, so the call it returns is a tail call
+		if e.insideFunction() {
+			value = e.evalTail(node.Value, env)
+		} else {
+			value = e.Eval(node.Value, env)
+		}
+
 		if isError(value) {
 			return value
 		}
 		return &object.ReturnValue{Value: value}
 	case *ast.CallExpression:
-		// node.Function can be an identifier
-		function := Eval(node.Function, env)
-
-		if isError(function) {
-			return function
-		}
-
-		args := evalExpressions(node.Arguments, env)
-
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
+		function, args, errObj := e.evalCall(node, env)
+		if errObj != nil {
+			return errObj
 		}
 
-		return applyFunction(function, args)
+		return e.applyFunction(function, args, callFrame(node))
 	case *ast.PrefixExpression:
-		right := Eval(node.Right, env)
+		right := e.Eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
 		return evalPrefixExpression(node.Operator(), right)
 	case *ast.InfixExpression:
-		left := Eval(node.Left, env)
+		left := e.Eval(node.Left, env)
 		if isError(left) {
 			return left
 		}
 
-		right := Eval(node.Right, env)
+		if op := node.Operator(); op == andOperator || op == orOperator {
+			return e.evalLogicalExpression(op, left, node.Right, env)
+		}
+
+		right := e.Eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
 		return evalInfixExpression(node.Operator(), right, left)
+	case *ast.AssignExpression:
+		return e.evalAssignExpression(node, env)
 	case *ast.IntegerLiteral:
 		return &object.Integer{
 			Value: node.Value,
 		}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
 	case *ast.StringLiteral:
 		return &object.String{
 			Value: node.Value(),
@@ -100,36 +124,49 @@ func Eval(node ast.Node, env *object.Enviroment) object.Object {
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
 	case *ast.ArrayLiteral:
-		elems := evalExpressions(node.Elements, env)
+		elems := e.evalExpressions(node.Elements, env)
 
 		if len(elems) == 1 && isError(elems[0]) {
 			return elems[0]
 		}
 
 		return &object.Array{Elements: elems}
+	case *ast.HashLiteral:
+		return e.evalHashLiteral(node, env)
 	case *ast.IndexExpression:
-		left := Eval(node.Left, env)
+		left := e.Eval(node.Left, env)
 
 		if isError(left) {
 			return left
 		}
 
-		index := Eval(node.Index, env)
+		index := e.Eval(node.Index, env)
 		if isError(index) {
 			return index
 		}
 
 		return evalIndexExpression(left, index)
+	case *ast.SliceExpression:
+		return e.evalSliceExpression(node, env)
+	case *ast.MemberExpression:
+		obj := e.Eval(node.Object, env)
+		if isError(obj) {
+			return obj
+		}
+
+		return evalMemberExpression(obj, node.Property.Value())
+	case *ast.ImportExpression:
+		return e.evalImportExpression(node)
 	}
 
 	return nil
 }
 
-func evalProgram(stmts []ast.Statement, env *object.Enviroment) object.Object {
+func (e *Evaluator) evalProgram(stmts []ast.Statement, env *object.Enviroment) object.Object {
 	var result object.Object
 
 	for _, stmt := range stmts {
-		result = Eval(stmt, env)
+		result = e.Eval(stmt, env)
 
 		switch result := result.(type) {
 		case *object.ReturnValue:
@@ -142,14 +179,15 @@ func evalProgram(stmts []ast.Statement, env *object.Enviroment) object.Object {
 	return result
 }
 
-func evalStatements(stmts []ast.Statement, env *object.Enviroment) object.Object {
+func (e *Evaluator) evalStatements(stmts []ast.Statement, env *object.Enviroment) object.Object {
 	var result object.Object
 
 	for _, stmt := range stmts {
-		result = Eval(stmt, env)
+		result = e.Eval(stmt, env)
 
 		if result != nil {
-			if result.Type() == object.ReturnType || result.Type() == object.ErrorType {
+			switch result.Type() {
+			case object.ReturnType, object.ErrorType, object.BreakType, object.ContinueType:
 				return result
 			}
 		}
@@ -189,6 +227,10 @@ func evalNotOperator(right object.Object) object.Object {
 }
 
 func evalMinusOperatorExpression(right object.Object) object.Object {
+	if f, ok := right.(*object.Float); ok {
+		return &object.Float{Value: -f.Value}
+	}
+
 	if right.Type() != object.IntegerType {
 		return newError(
 			fmt.Sprintf("unknown operator: %s%s", "-", right.Inspect()),
@@ -205,6 +247,11 @@ func evalInfixExpression(operator string, right, left object.Object) object.Obje
 		return NULL
 	}
 
+	// an Integer with a Float becomes a Float
+	if object.IsFloatOperation(left, right) {
+		return evalFloatInfixExpression(operator, right, left)
+	}
+
 	if right.Type() != left.Type() {
 		return newError("type mismatch: %s %s %s", left.Inspect(), operator, right.Inspect())
 	}
@@ -214,6 +261,10 @@ func evalInfixExpression(operator string, right, left object.Object) object.Obje
 		return evalIntegerInfixExpression(operator, right, left)
 	case right.Type() == object.StringType && operator == plusOperator:
 		return evalStringInfixExpression(right, left)
+	case right.Type() == object.StringType && (operator == equalOperator || operator == notEqualOperator):
+		equal := left.(*object.String).Value == right.(*object.String).Value
+
+		return nativeBoolToBooleanObject(equal == (operator == equalOperator))
 	case operator == equalOperator:
 		return nativeBoolToBooleanObject(left == right)
 	case operator == notEqualOperator:
@@ -223,12 +274,26 @@ func evalInfixExpression(operator string, right, left object.Object) object.Obje
 	return newError("unknown operator: %s %s %s", right.Inspect(), operator, left.Inspect())
 }
 
-func nativeBoolToBooleanObject(input bool) object.Object {
-	if input {
+// Evaluates && and ||, the right side is only evaluated when the left one doesn't decide the result
+func (e *Evaluator) evalLogicalExpression(operator string, left object.Object, right ast.Expression, env *object.Enviroment) object.Object {
+	if operator == andOperator && !isTruthy(left) {
+		return FALSE
+	}
+
+	if operator == orOperator && isTruthy(left) {
 		return TRUE
 	}
 
-	return FALSE
+	val := e.Eval(right, env)
+	if isError(val) {
+		return val
+	}
+
+	return nativeBoolToBooleanObject(isTruthy(val))
+}
+
+func nativeBoolToBooleanObject(input bool) object.Object {
+	return object.NativeBoolToBooleanObject(input)
 }
 
 func evalIntegerInfixExpression(operator string, right, left object.Object) object.Object {
@@ -243,7 +308,47 @@ func evalIntegerInfixExpression(operator string, right, left object.Object) obje
 	case productoOperator:
 		return &object.Integer{Value: leftVal * rightVal}
 	case divitionOperator:
+		if rightVal == 0 {
+			return newError("division by zero: %d / 0", leftVal)
+		}
 		return &object.Integer{Value: leftVal / rightVal}
+	case moduloOperator:
+		if rightVal == 0 {
+			return newError("division by zero: %d %% 0", leftVal)
+		}
+		return &object.Integer{Value: leftVal % rightVal}
+	case equalOperator:
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case greaterOperator:
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case greaterEqualOperator:
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+	case lessOperator:
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case lessEqualOperator:
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case notEqualOperator:
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	}
+
+	return NULL
+}
+
+func evalFloatInfixExpression(operator string, right, left object.Object) object.Object {
+	leftVal, _ := object.ToFloat(left)
+	rightVal, _ := object.ToFloat(right)
+
+	switch operator {
+	case plusOperator:
+		return &object.Float{Value: leftVal + rightVal}
+	case minusOperator:
+		return &object.Float{Value: leftVal - rightVal}
+	case productoOperator:
+		return &object.Float{Value: leftVal * rightVal}
+	case divitionOperator:
+		return &object.Float{Value: leftVal / rightVal}
+	case moduloOperator:
+		return &object.Float{Value: math.Mod(leftVal, rightVal)}
 	case equalOperator:
 		return nativeBoolToBooleanObject(leftVal == rightVal)
 	case greaterOperator:
@@ -281,42 +386,206 @@ func isTruthy(obj object.Object) bool {
 	return true
 }
 
-func evalIfExpression(ie *ast.IfExpression, env *object.Enviroment) object.Object {
-	condition := Eval(ie.Condition, env)
+func (e *Evaluator) evalIfExpression(ie *ast.IfExpression, env *object.Enviroment) object.Object {
+	condition := e.Eval(ie.Condition, env)
+	if isError(condition) {
+		return condition
+	}
 
 	if isTruthy(condition) {
-		return Eval(ie.Consequence, env)
+		return e.Eval(ie.Consequence, env)
 	} else if ie.Alternative != nil {
-		return Eval(ie.Alternative, env)
+		return e.Eval(ie.Alternative, env)
 	}
 
 	return NULL
 }
 
+func (e *Evaluator) evalWhileStatement(node *ast.WhileStatement, env *object.Enviroment) object.Object {
+	for {
+		condition := e.Eval(node.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+
+		if !isTruthy(condition) {
+			return nil
+		}
+
+		if result, done := e.evalLoopBody(node.Body, env); done {
+			return result
+		}
+	}
+}
+
+func (e *Evaluator) evalForStatement(node *ast.ForStatement, env *object.Enviroment) object.Object {
+	iterable := e.Eval(node.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	it, err := object.NewIterator(iterable)
+	if err != nil {
+		return err
+	}
+
+	for elem, ok := it.Next(); ok; elem, ok = it.Next() {
+		env.Set(node.Variable.Value(), elem)
+
+		if result, done := e.evalLoopBody(node.Body, env); done {
+			return result
+		}
+	}
+
+	return nil
+}
+
+// Evaluates one iteration of a loop, done reports whether the loop must stop with the returned result
+func (e *Evaluator) evalLoopBody(body *ast.BlockStatement, env *object.Enviroment) (object.Object, bool) {
+	result := e.Eval(body, env)
+
+	switch result {
+	case BREAK:
+		return nil, true
+	case CONTINUE, nil:
+		return nil, false
+	}
+
+	switch result.Type() {
+	case object.ReturnType, object.ErrorType:
+		return result, true
+	}
+
+	return nil, false
+}
+
 func evalIdentifier(node *ast.Identifier, env *object.Enviroment) object.Object {
-	if bi, ok := getBuiltins(node.Value()); ok {
+	// the bindings of the script shadow the builtins
+	if val, ok := env.Get(node.Value()); ok {
+		return val
+	}
+
+	if bi, ok := object.GetBuiltinByName(node.Value()); ok {
 		return bi
 	}
 
-	val, ok := env.Get(node.Value())
+	return newError("identifier not found: %s", node.Value())
+}
 
-	if !ok {
-		return newError("identifier not found: %s", node.Value())
+func (e *Evaluator) evalAssignExpression(node *ast.AssignExpression, env *object.Enviroment) object.Object {
+	// a compound assignment like += applies the operator before storing
+	operator := strings.TrimSuffix(node.Operator(), "=")
+	compound := operator != ""
+
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		name := target.Value()
+
+		if _, ok := env.Get(name); !ok {
+			if _, ok := object.GetBuiltinByName(name); ok {
+				return newError("cannot assign to builtin function: %s", name)
+			}
+		}
+
+		var current object.Object
+		if compound {
+			current = evalIdentifier(target, env)
+			if isError(current) {
+				return current
+			}
+		}
+
+		val := e.Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+
+		if compound {
+			val = evalInfixExpression(operator, val, current)
+			if isError(val) {
+				return val
+			}
+		}
+
+		if !env.Assign(name, val) {
+			return newError("cannot assign to undefined identifier: %s", name)
+		}
+
+		return val
+	case *ast.IndexExpression:
+		left := e.Eval(target.Left, env)
+		if isError(left) {
+			return left
+		}
+
+		index := e.Eval(target.Index, env)
+		if isError(index) {
+			return index
+		}
+
+		val := e.Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+
+		if compound {
+			current := evalIndexExpression(left, index)
+			if isError(current) {
+				return current
+			}
+
+			val = evalInfixExpression(operator, val, current)
+			if isError(val) {
+				return val
+			}
+		}
+
+		return evalIndexAssignment(left, index, val)
 	}
 
-	return val
+	return newError("cannot assign to %s", node.Target)
+}
+
+func evalIndexAssignment(left, index, val object.Object) object.Object {
+	switch {
+	case left.Type() == object.ArrayType && index.Type() == object.IntegerType:
+		array := left.(*object.Array)
+		i := index.(*object.Integer).Value
+
+		if i < 0 || i >= int64(len(array.Elements)) {
+			return newError("index out of bounds. got=%d", i)
+		}
+
+		array.Elements[i] = val
+		return val
+	case left.Type() == object.HashType:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", index.Inspect())
+		}
+
+		left.(*object.Hash).Set(key, val)
+		return val
+	}
+
+	return newError("index assignment not supported: %s", left.Inspect())
 }
 
-func evalExpressions(exps []ast.Expression, env *object.Enviroment) []object.Object {
+func (e *Evaluator) evalExpressions(exps []ast.Expression, env *object.Enviroment) []object.Object {
 	result := make([]object.Object, 0, len(exps))
 
 	for _, exp := range exps {
-		evaluated := Eval(exp, env)
+		evaluated := e.Eval(exp, env)
 
 		if isError(evaluated) {
 			return []object.Object{evaluated}
 		}
 
+		// an expression without value, like the call of an empty function, is NULL as a value
+		if evaluated == nil {
+			evaluated = NULL
+		}
+
 		result = append(result, evaluated)
 	}
 
@@ -324,13 +593,88 @@ func evalExpressions(exps []ast.Expression, env *object.Enviroment) []object.Obj
 }
 
 func evalIndexExpression(left, index object.Object) object.Object {
-	if left.Type() == object.ArrayType && index.Type() == object.IntegerType {
+	switch {
+	case left.Type() == object.ArrayType && index.Type() == object.IntegerType:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.StringType && index.Type() == object.IntegerType:
+		return object.StringIndex(left.(*object.String), index.(*object.Integer).Value)
+	case left.Type() == object.HashType:
+		return evalHashIndexExpression(left, index)
+	case left.Type() == object.ModuleType && index.Type() == object.StringType:
+		return evalMemberExpression(left, index.(*object.String).Value)
 	}
 
 	return newError("index operator not supported: %s", left.Inspect())
 }
 
+func (e *Evaluator) evalSliceExpression(node *ast.SliceExpression, env *object.Enviroment) object.Object {
+	left := e.Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	if left == nil {
+		left = NULL
+	}
+
+	bounds := [2]object.Object{NULL, NULL}
+	for i, bound := range []ast.Expression{node.Low, node.High} {
+		if bound == nil {
+			continue
+		}
+
+		val := e.Eval(bound, env)
+		if isError(val) {
+			return val
+		}
+
+		if val != nil {
+			bounds[i] = val
+		}
+	}
+
+	return object.Slice(left, bounds[0], bounds[1])
+}
+
+func evalHashIndexExpression(hash, idx object.Object) object.Object {
+	key, ok := idx.(object.Hashable)
+	if !ok {
+		return newError("unusable as hash key: %s", idx.Inspect())
+	}
+
+	val, ok := hash.(*object.Hash).Get(key)
+	if !ok {
+		return NULL
+	}
+
+	return val
+}
+
+func (e *Evaluator) evalHashLiteral(node *ast.HashLiteral, env *object.Enviroment) object.Object {
+	hash := object.NewHash()
+
+	for _, pair := range node.Pairs {
+		k := e.Eval(pair.Key, env)
+		if isError(k) {
+			return k
+		}
+
+		key, ok := k.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", k.Inspect())
+		}
+
+		val := e.Eval(pair.Value, env)
+		if isError(val) {
+			return val
+		}
+
+		hash.Set(key, val)
+	}
+
+	return hash
+}
+
 func evalArrayIndexExpression(arr, idx object.Object) object.Object {
 	array := arr.(*object.Array)
 	index := idx.(*object.Integer).Value
@@ -343,20 +687,6 @@ func evalArrayIndexExpression(arr, idx object.Object) object.Object {
 	return array.Elements[index]
 }
 
-func applyFunction(fn object.Object, args []object.Object) object.Object {
-	switch fn := fn.(type) {
-	case *object.Function:
-		env := extendFunctionEnv(fn, args)
-		evaluated := Eval(fn.Body, env)
-
-		return unwrapReturnerValue(evaluated)
-	case *object.BuiltIn:
-		return fn.Fn(args...)
-	}
-
-	return newError("not a function. got=%q", fn.String())
-}
-
 func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Enviroment {
 	env := object.NewOuterEnviroment(fn.Env)
 
@@ -378,9 +708,7 @@ func unwrapReturnerValue(obj object.Object) object.Object {
 }
 
 func newError(message string, a ...any) *object.Error {
-	return &object.Error{
-		Message: fmt.Sprintf(message, a...),
-	}
+	return object.NewError(message, a...)
 }
 
 func isError(obj object.Object) bool {
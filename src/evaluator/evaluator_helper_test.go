@@ -1,26 +1,164 @@
 package evaluator
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"log/slog"
+	"strings"
 	"testing"
 
+	"github.com/dyxgou/parser/src/ast"
+	"github.com/dyxgou/parser/src/compiler"
 	"github.com/dyxgou/parser/src/lexer"
 	"github.com/dyxgou/parser/src/object"
 	"github.com/dyxgou/parser/src/parser"
+	"github.com/dyxgou/parser/src/vm"
 )
 
-func testEval(input string) object.Object {
+func testEval(t *testing.T, input string) object.Object {
+	t.Helper()
+
 	l := lexer.New(input)
 	p := parser.New(l)
 
+	program := p.ParseProgram()
+
 	if p.ErrorsLen() != 0 {
 		slog.Error("parser had errors")
 	}
 
-	program := p.ParseProgram()
+	// what the tests print isn't checked here, testEvalIO does it
+	e := New()
+	e.IO.Out = io.Discard
+
 	env := object.NewEnviroment()
+	evaluated := e.Eval(program, env)
+
+	testVMResult(t, program, evaluated)
+
+	return evaluated
+}
+
+// Evaluates the input reading stdin and returns what it printed, the vm must print the same
+func testEvalIO(t *testing.T, input, stdin string) (object.Object, string) {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+
+	if p.ErrorsLen() != 0 {
+		t.Fatalf("parser had errors: %v", p.Errors())
+	}
+
+	var out bytes.Buffer
+	e := New()
+	e.IO = object.IO{Out: &out, In: strings.NewReader(stdin)}
+
+	evaluated := e.Eval(program, object.NewEnviroment())
+
+	comp := compiler.New()
+	if err := comp.Compile(program); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+
+	var vmOut bytes.Buffer
+	machine := vm.New(comp.Bytecode())
+	machine.IO = object.IO{Out: &vmOut, In: strings.NewReader(stdin)}
+
+	var result object.Object
+	if err := machine.Run(); err != nil {
+		objErr, ok := err.(*object.Error)
+		if !ok {
+			t.Fatalf("vm error is not *object.Error. got=%T (%s)", err, err)
+		}
+
+		result = objErr
+	} else {
+		result = machine.Result()
+	}
+
+	if !sameResult(evaluated, result) {
+		t.Errorf("vm result differs from Eval. expected=%s. got=%s", describe(evaluated), describe(result))
+	}
+
+	if out.String() != vmOut.String() {
+		t.Errorf("vm output differs from Eval. expected=%q. got=%q", out.String(), vmOut.String())
+	}
+
+	return evaluated, out.String()
+}
+
+// Runs the program in the vm and checks that it gives the same result as Eval
+func testVMResult(t *testing.T, program *ast.Program, expected object.Object) {
+	t.Helper()
+
+	result, ok := runVM(t, program)
+	if !ok {
+		return
+	}
+
+	if !sameResult(expected, result) {
+		t.Errorf("vm result differs from Eval. expected=%s. got=%s", describe(expected), describe(result))
+	}
+}
+
+// Returns the result of the program in the vm, the errors of the compiler and the vm are results too
+func runVM(t *testing.T, program *ast.Program) (object.Object, bool) {
+	t.Helper()
 
-	return Eval(program, env)
+	comp := compiler.New()
+	if err := comp.Compile(program); err != nil {
+		// some errors are found by the compiler before running the program
+		compErr, ok := err.(*compiler.Error)
+		if !ok {
+			t.Errorf("compiler error: %s", err)
+			return nil, false
+		}
+
+		return object.NewError("%s", compErr.Msg), true
+	}
+
+	machine := vm.New(comp.Bytecode())
+	machine.IO.Out = io.Discard
+
+	if err := machine.Run(); err != nil {
+		objErr, ok := err.(*object.Error)
+		if !ok {
+			t.Errorf("vm error is not *object.Error. got=%T (%s)", err, err)
+			return nil, false
+		}
+
+		return objErr, true
+	}
+
+	return machine.Result(), true
+}
+
+func sameResult(expected, got object.Object) bool {
+	// a function without value gives nil in the evaluator and NULL in the vm
+	if expected == nil || got == nil {
+		return (expected == nil || expected == NULL) && (got == nil || got == NULL)
+	}
+
+	switch expected := expected.(type) {
+	case *object.Function:
+		_, ok := got.(*object.Closure)
+		return ok
+	case *object.Error:
+		err, ok := got.(*object.Error)
+		return ok && err.Message == expected.Message
+	}
+
+	return expected.Type() == got.Type() && expected.String() == got.String()
+}
+
+func describe(obj object.Object) string {
+	if obj == nil {
+		return "nil"
+	}
+
+	return fmt.Sprintf("%s(%s)", obj.Inspect(), obj.String())
 }
 
 func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
@@ -84,3 +222,36 @@ func testNullObject(t *testing.T, obj object.Object) bool {
 
 	return true
 }
+
+// Returns the String of the object or "nil" when there is no object
+func describeResult(obj object.Object) string {
+	if obj == nil {
+		return "nil"
+	}
+
+	return obj.String()
+}
+
+func parseProgram(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+
+	if p.ErrorsLen() != 0 {
+		t.Fatalf("parser had errors: %v", p.Errors())
+	}
+
+	return program
+}
+
+func mustGet(t *testing.T, env *object.Enviroment, name string) object.Object {
+	t.Helper()
+
+	obj, ok := env.Get(name)
+	if !ok {
+		t.Fatalf("%s is not defined", name)
+	}
+
+	return obj
+}
@@ -1,9 +1,18 @@
 package evaluator
 
 import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/dyxgou/parser/src/compiler"
+	"github.com/dyxgou/parser/src/lexer"
 	"github.com/dyxgou/parser/src/object"
+	"github.com/dyxgou/parser/src/parser"
+	"github.com/dyxgou/parser/src/vm"
 )
 
 func TestEvalIntegerLiteral(t *testing.T) {
@@ -25,10 +34,14 @@ func TestEvalIntegerLiteral(t *testing.T) {
 		{"3 * 3 * 3 + 10", 37},
 		{"3 * (3 * 3) + 10", 37},
 		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+		{"7 % 3", 1},
+		{"-7 % 3", -1},
+		{"2 + 10 % 4 * 3", 8},
+		{"let x = 10; x %= 4; x", 2},
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		testIntegerObject(t, evaluated, tt.value)
 	}
 }
@@ -57,10 +70,26 @@ func TestEvalBooleanLiteral(t *testing.T) {
 		{"(1 < 2) == false", false},
 		{"(1 > 2) == true", false},
 		{"(1 > 2) == false", true},
+		{"1 <= 2", true},
+		{"2 <= 2", true},
+		{"3 <= 2", false},
+		{"1 >= 2", false},
+		{"2 >= 2", true},
+		{"true && true", true},
+		{"true && false", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 && 0", true},
+		{"if (false) { 1 } || 2", true},
+		{"let x = 5; x >= 1 && x <= 10", true},
+		{"let x = 11; x >= 1 && x <= 10", false},
+		{"1 == 2 || 3 > 2 && 2 > 1", true},
+		{"false && undefined", false},
+		{"true || undefined", true},
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		testBooleanObject(t, evaluated, tt.expected)
 	}
 }
@@ -79,7 +108,7 @@ func TestNotOperator(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		testBooleanObject(t, evaluated, tt.expected)
 	}
 }
@@ -99,7 +128,7 @@ func TestIfElseExpression(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		integer, ok := tt.expected.(int)
 
 		if ok {
@@ -129,7 +158,7 @@ func TestReturnStatement(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		testIntegerObject(t, evaluated, 10)
 	}
 }
@@ -182,7 +211,7 @@ func TestErrorHandeling(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 
 		objErr, ok := evaluated.(*object.Error)
 
@@ -212,14 +241,14 @@ func TestLetStatement(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		testIntegerObject(t, testEval(tt.input), tt.expected)
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
 	}
 }
 
 func TestFunctionLiteral(t *testing.T) {
 	input := "fn(x) { x + 2; };"
 
-	evaluated := testEval(input)
+	evaluated := testEval(t, input)
 
 	fn, ok := evaluated.(*object.Function)
 
@@ -256,7 +285,7 @@ func TestCallExpression(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		testIntegerObject(t, evaluated, tt.expected)
 	}
 }
@@ -271,20 +300,20 @@ func TestClousure(t *testing.T) {
   addTwo(2);
   `
 
-	testIntegerObject(t, testEval(input), 4)
+	testIntegerObject(t, testEval(t, input), 4)
 }
 
 func TestStringLiteral(t *testing.T) {
 	input := `"hello world";`
 
-	evaluated := testEval(input)
+	evaluated := testEval(t, input)
 	testStringObject(t, evaluated, "hello world")
 }
 
 func TestStringConcatenation(t *testing.T) {
 	input := `"hello" + " " + "world";`
 
-	evaluated := testEval(input)
+	evaluated := testEval(t, input)
 
 	testStringObject(t, evaluated, "hello world")
 }
@@ -292,7 +321,7 @@ func TestStringConcatenation(t *testing.T) {
 func TestArrayLiteral(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 
-	evaluated := testEval(input)
+	evaluated := testEval(t, input)
 	array, ok := evaluated.(*object.Array)
 
 	if !ok {
@@ -348,7 +377,7 @@ func TestArrayIndexExpression(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		testIntegerObject(t, evaluated, tt.expected)
 	}
 }
@@ -369,7 +398,7 @@ func TestArrayIndexExpressionError(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		err, ok := evaluated.(*object.Error)
 		if !ok {
 			t.Fatalf("array index expression expected=*object.Error. got=%T", evaluated)
@@ -380,3 +409,851 @@ func TestArrayIndexExpressionError(t *testing.T) {
 		}
 	}
 }
+
+func TestErrorPosition(t *testing.T) {
+	input := `let f = fn(x) {
+  x + true;
+};
+f(1);`
+
+	evaluated := testEval(t, input)
+	err, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("evaluated expected=*object.Error. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if pos := err.Pos.String(); pos != "2:3" {
+		t.Errorf("err.Pos expected=%q. got=%q", "2:3", pos)
+	}
+}
+
+func TestHashLiteral(t *testing.T) {
+	input := `let two = "two";
+  {
+    "one": 10 - 9,
+    two: 1 + 1,
+    "thr" + "ee": 6 / 2,
+    4: 4,
+    true: 5,
+    false: 6
+  }`
+
+	evaluated := testEval(t, input)
+	hash, ok := evaluated.(*object.Hash)
+
+	if !ok {
+		t.Fatalf("eval didn't return *object.Hash. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	expected := []struct {
+		key   object.Hashable
+		value int64
+	}{
+		{&object.String{Value: "one"}, 1},
+		{&object.String{Value: "two"}, 2},
+		{&object.String{Value: "three"}, 3},
+		{&object.Integer{Value: 4}, 4},
+		{TRUE, 5},
+		{FALSE, 6},
+	}
+
+	if hash.Len() != len(expected) {
+		t.Fatalf("hash has wrong num of pairs. got=%d", hash.Len())
+	}
+
+	for i, pair := range hash.Pairs() {
+		if pair.Key.HashKey() != expected[i].key.HashKey() {
+			t.Errorf("pair[%d] key expected=%s. got=%s", i, expected[i].key, pair.Key)
+		}
+
+		testIntegerObject(t, pair.Value, expected[i].value)
+	}
+}
+
+func TestHashIndexExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`{"foo": 5}["foo"]`, 5},
+		{`{"foo": 5}["bar"]`, nil},
+		{`let key = "foo"; {"foo": 5}[key]`, 5},
+		{`{}["foo"]`, nil},
+		{`{5: 5}[5]`, 5},
+		{`{true: 5}[true]`, 5},
+		{`{false: 5}[false]`, 5},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		integer, ok := tt.expected.(int)
+
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestHashBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`keys({"a": 1, "b": 2})`, "[a, b]"},
+		{`values({"a": 1, "b": 2})`, "[1, 2]"},
+		{`has({"a": 1}, "a")`, "true"},
+		{`has({"a": 1}, "b")`, "false"},
+		{`let h = {"a": 1, "b": 2}; delete(h, "a"); h`, "{b: 2}"},
+		{`delete({"a": 1}, "b")`, "NULL"},
+		{`merge({"a": 1, "b": 2}, {"b": 3, "c": 4})`, "{a: 1, b: 3, c: 4}"},
+		{`len({"a": 1, "b": 2})`, "2"},
+		{`{[1]: 2}`, "ERROR : unusable as hash key: ARRAY"},
+		{`{"a": 1}[fn(x) { x }]`, "ERROR : unusable as hash key: FUNCTION"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if s := describeResult(evaluated); s != tt.expected {
+			t.Errorf("%s expected=%q. got=%q", tt.input, tt.expected, s)
+		}
+	}
+}
+
+func TestLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let out = []; for (x in [1, 2, 3]) { push(out, x * 2) }; out", "[2, 4, 6]"},
+		{
+			`let a = [1, 2, 3, 4];
+      let out = [];
+      while (len(a) > 0) {
+        let x = pop(a);
+        if (x == 2) { continue; }
+        push(out, x);
+      }
+      out`,
+			"[4, 3, 1]",
+		},
+		{"let out = []; for (x in [1, 2, 3, 4]) { if (x == 3) { break; } push(out, x) }; out", "[1, 2]"},
+		{
+			`let out = [];
+      for (x in [1, 2]) {
+        for (y in [10, 20, 30]) {
+          if (y == 20) { break; }
+          push(out, x + y);
+        }
+      }
+      out`,
+			"[11, 12]",
+		},
+		{"let f = fn(arr) { for (x in arr) { if (x > 1) { return x; } } 0 }; f([1, 5, 3])", "5"},
+		{"let f = fn(arr) { for (x in arr) { if (x > 1) { return x; } } 0 }; f([1])", "0"},
+		{`let out = []; for (k in {"a": 1, "b": 2}) { push(out, k) }; out`, "[a, b]"},
+		{"let a = [1, 2]; for (x in a) { push(a, x) }; a", "[1, 2, 1, 2]"},
+		{"for (x in 5) { x }", "ERROR : cannot iterate over INTEGER"},
+		{"while (5 + true) { 1 }", "ERROR : type mismatch: INTEGER + BOOLEAN"},
+		{"for (x in [1]) { x }", "nil"},
+		{"while (false) { 1 }", "nil"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if s := describeResult(evaluated); s != tt.expected {
+			t.Errorf("%s expected=%q. got=%q", tt.input, tt.expected, s)
+		}
+	}
+}
+
+func TestAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 1; x = 2; x", "2"},
+		{"let x = 1; x = x + 1", "2"},
+		{"let x = 10; x += 5; x -= 3; x *= 2; x /= 4; x", "6"},
+		{"let a = 1; let b = 2; a = b = 3; a + b", "6"},
+		{"let x = 1; let f = fn() { x = 5 }; f(); x", "5"},
+		{"let x = 1; let f = fn(x) { x = 5; x }; f(0) + x", "6"},
+		{
+			`let counter = fn() {
+        let c = 0;
+        fn() { c += 1 }
+      };
+      let next = counter();
+      next(); next();
+      next()`,
+			"3",
+		},
+		{
+			`let make = fn() {
+        let c = 0;
+        let get = fn() { c };
+        c = 10;
+        fn() { fn() { c += 1 } }()() + get()
+      };
+      make()`,
+			"22",
+		},
+		{"let i = 0; let sum = 0; while (i < 4) { i += 1; sum += i; }; sum", "10"},
+		{"let a = [1, 2, 3]; a[1] = 5; a", "[1, 5, 3]"},
+		{"let a = [1, 2, 3]; a[2] *= 10", "30"},
+		{`let h = {"a": 1}; h["b"] = 2; h["a"] += 1; h`, "{a: 2, b: 2}"},
+		{"x = 1", "ERROR : cannot assign to undefined identifier: x"},
+		{"let f = fn() { y = 1 }; f()", "ERROR : cannot assign to undefined identifier: y"},
+		{"x += 1", "ERROR : identifier not found: x"},
+		{"len = 1", "ERROR : cannot assign to builtin function: len"},
+		{"let x = 1; x += true", "ERROR : type mismatch: INTEGER + BOOLEAN"},
+		{"let a = [1]; a[3] = 1", "ERROR : index out of bounds. got=3"},
+		{"let a = 1; a[0] = 1", "ERROR : index assignment not supported: INTEGER"},
+		{`let h = {}; h[fn() { 1 }] = 1`, "ERROR : unusable as hash key: FUNCTION"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if s := describeResult(evaluated); s != tt.expected {
+			t.Errorf("%s expected=%q. got=%q", tt.input, tt.expected, s)
+		}
+	}
+}
+
+func TestLogicalOperatorErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"true && undefined", "ERROR : identifier not found: undefined"},
+		{"false || 1 + true", "ERROR : type mismatch: INTEGER + BOOLEAN"},
+		{`"a" <= "b"`, "ERROR : unknown operator: STRING <= STRING"},
+		{"let i = 0; let f = fn() { i += 1; true }; false && f(); true || f(); i", "0"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if s := describeResult(evaluated); s != tt.expected {
+			t.Errorf("%s expected=%q. got=%q", tt.input, tt.expected, s)
+		}
+	}
+}
+
+func TestFloats(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1.5", "1.5"},
+		{"2.0", "2.0"},
+		{"1 + 2.5", "3.5"},
+		{"2.5 * 2", "5.0"},
+		{"7 / 2.0", "3.5"},
+		{"7 / 2", "3"},
+		{"-1.5", "-1.5"},
+		{"7.5 % 2", "1.5"},
+		{"1e21", "1e+21"},
+		{"1 == 1.0", "true"},
+		{"0.1 + 0.2 > 0.3", "true"},
+		{"2 <= 1.5", "false"},
+		{"let total = 10; let avg = total / 4.0; avg", "2.5"},
+		{"let x = 1; x += 0.5; x", "1.5"},
+		{"1.5 + true", "ERROR : type mismatch: FLOAT + BOOLEAN"},
+		{"int(3.9)", "3"},
+		{"int(-3.9)", "-3"},
+		{`int("42")`, "42"},
+		{`int("4.2")`, `ERROR : could not parse "4.2" as an integer`},
+		{"float(3)", "3.0"},
+		{`float("2.25")`, "2.25"},
+		{"floor(2.7)", "2"},
+		{"floor(-2.5)", "-3"},
+		{"ceil(2.1)", "3"},
+		{"round(2.5)", "3"},
+		{"round(7)", "7"},
+		{`floor("a")`, "ERROR : argument to `floor` must be a number. got=STRING"},
+		{"int(1e300)", "ERROR : argument to `int` out of the integer range. got=1e+300"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if s := describeResult(evaluated); s != tt.expected {
+			t.Errorf("%s expected=%q. got=%q", tt.input, tt.expected, s)
+		}
+	}
+}
+
+func TestRuntimeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"10 / 0", "ERROR : division by zero: 10 / 0"},
+		{"10 % 0", "ERROR : division by zero: 10 % 0"},
+		{"let x = 0; 5 / x + 1", "ERROR : division by zero: 5 / 0"},
+		{"1.0 / 0 > 1", "true"},
+		{"pop([])", "ERROR : function `pop` called on an empty array"},
+		{"let a = [1]; pop(a); pop(a)", "ERROR : function `pop` called on an empty array"},
+		{"fn(a, b) { a + b }(1)", "ERROR : wrong number of arguments: want=2, got=1"},
+		{"fn(a) { a }(1, 2)", "ERROR : wrong number of arguments: want=1, got=2"},
+		{"let f = fn() {}; print(f())", "NULL"},
+		{"let f = fn() {}; [f()]", "[NULL]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if s := describeResult(evaluated); s != tt.expected {
+			t.Errorf("%s expected=%q. got=%q", tt.input, tt.expected, s)
+		}
+	}
+}
+
+func TestTailCalls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`let count = fn(n, acc) { if (n == 0) { acc } else { count(n - 1, acc + 1) } };
+      count(100000, 0)`,
+			"100000",
+		},
+		{
+			`let count = fn(n) { if (n == 0) { return "done"; } return count(n - 1); };
+      count(100000)`,
+			"done",
+		},
+		{
+			`let even = fn(n) { if (n == 0) { true } else { odd(n - 1) } };
+      let odd = fn(n) { if (n == 0) { false } else { even(n - 1) } };
+      even(50001)`,
+			"false",
+		},
+		{
+			`let reduce = fn(arr, initial, f) {
+        let iter = fn(arr, result) {
+          if (len(arr) == 0) { result } else { iter(rest(arr), f(result, first(arr))); }
+        };
+        iter(arr, initial);
+      };
+      let a = []; let i = 0;
+      while (i < 5000) { push(a, i); i += 1; }
+      reduce(a, 0, fn(acc, x) { acc + x })`,
+			"12497500",
+		},
+		{"let f = fn(n) { len(n) }; f([1, 2])", "2"},
+		{"let f = fn(n) { g(n) }; let g = fn(a, b) { a }; f(1)", "ERROR : wrong number of arguments: want=2, got=1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if s := describeResult(evaluated); s != tt.expected {
+			t.Errorf("%s expected=%q. got=%q", tt.input, tt.expected, s)
+		}
+	}
+}
+
+func TestMaxCallDepth(t *testing.T) {
+	input := `let f = fn(n) { if (n == 0) { 0 } else { 1 + f(n - 1) } };
+f(100)`
+
+	e := New()
+	e.MaxCallDepth = 50
+
+	evaluated := e.Eval(parseProgram(t, input), object.NewEnviroment())
+
+	err, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("expected *object.Error. got=%T (%s)", evaluated, describeResult(evaluated))
+	}
+
+	if err.Message != "maximum call depth of 50 exceeded" {
+		t.Errorf("wrong message. got=%q", err.Message)
+	}
+
+	if len(err.Stack) != 50 {
+		t.Fatalf("expected 50 frames. got=%d", len(err.Stack))
+	}
+
+	if s := err.Stack[0].String(); s != "f (1:46)" {
+		t.Errorf("wrong innermost frame. got=%q", s)
+	}
+
+	if s := err.Stack[49].String(); s != "f (2:1)" {
+		t.Errorf("wrong outermost frame. got=%q", s)
+	}
+
+	if len(e.frames) != 0 {
+		t.Errorf("the frames weren't released. got=%d", len(e.frames))
+	}
+
+	// a deep recursion fits in the default limit
+	evaluated = New().Eval(parseProgram(t, "let f = fn(n) { if (n == 0) { 0 } else { 1 + f(n - 1) } }; f(5000)"), object.NewEnviroment())
+	testIntegerObject(t, evaluated, 5000)
+}
+
+func TestMaxCallDepthEngines(t *testing.T) {
+	input := `let f = fn(n) { if (n == 0) { 0 } else { 1 + f(n - 1) } };
+f(100)`
+	program := parseProgram(t, input)
+
+	e := New()
+	e.MaxCallDepth = 50
+	evaluated := e.Eval(program, object.NewEnviroment())
+
+	comp := compiler.New()
+	if err := comp.Compile(program); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+
+	machine := vm.New(comp.Bytecode())
+	machine.MaxCallDepth = 50
+
+	results := []struct {
+		engine string
+		result any
+	}{
+		{"eval", evaluated},
+		{"vm", machine.Run()},
+	}
+
+	for _, r := range results {
+		engine := r.engine
+
+		err, ok := r.result.(*object.Error)
+		if !ok {
+			t.Fatalf("%s: expected *object.Error. got=%T (%v)", engine, r.result, r.result)
+		}
+
+		if err.Message != "maximum call depth of 50 exceeded" || err.Pos.String() != "1:46" {
+			t.Errorf("%s: wrong error. got=%s %q", engine, err.Pos, err.Message)
+		}
+
+		if len(err.Stack) != 50 {
+			t.Fatalf("%s: expected 50 frames. got=%d", engine, len(err.Stack))
+		}
+
+		if s := err.Stack[0].String(); s != "f (1:46)" {
+			t.Errorf("%s: wrong innermost frame. got=%q", engine, s)
+		}
+
+		if s := err.Stack[49].String(); s != "f (2:1)" {
+			t.Errorf("%s: wrong outermost frame. got=%q", engine, s)
+		}
+	}
+
+	// the default limit is the same on both engines
+	testEval(t, "let f = fn(n) { if (n == 0) { 0 } else { 1 + f(n - 1) } }; f(5000)")
+	testEval(t, "let f = fn(n) { 1 + f(n + 1) }; f(0)")
+}
+
+func TestErrorStackTrace(t *testing.T) {
+	input := `let inner = fn(x) { x / 0 };
+let outer = fn(x) { inner(x) + 1 };
+outer(1)`
+
+	evaluated := testEval(t, input)
+
+	err, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("expected *object.Error. got=%T", evaluated)
+	}
+
+	expected := []string{"inner (2:21)", "outer (3:1)"}
+	if len(err.Stack) != len(expected) {
+		t.Fatalf("wrong stack. got=%v", err.Stack)
+	}
+
+	for i, frame := range err.Stack {
+		if frame.String() != expected[i] {
+			t.Errorf("frame %d expected=%q. got=%q", i, expected[i], frame.String())
+		}
+	}
+}
+
+func TestMaxSteps(t *testing.T) {
+	e := New()
+	e.MaxSteps = 1000
+
+	evaluated := e.Eval(parseProgram(t, "let i = 0; while (true) { i += 1 }"), object.NewEnviroment())
+
+	err, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("expected *object.Error. got=%T", evaluated)
+	}
+
+	if !errors.Is(err, object.ErrBudgetExceeded) {
+		t.Errorf("expected ErrBudgetExceeded. got=%v", err.Cause)
+	}
+
+	if err.Message != "execution budget exceeded: more than 1000 steps" {
+		t.Errorf("wrong message. got=%q", err.Message)
+	}
+}
+
+func TestEvalContext(t *testing.T) {
+	tests := []string{
+		"while (true) { 1 }",
+		"let f = fn() { f() }; f()",
+	}
+
+	for _, input := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+
+		evaluated := New().EvalContext(ctx, parseProgram(t, input), object.NewEnviroment())
+		cancel()
+
+		err, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Fatalf("%s: expected *object.Error. got=%T", input, evaluated)
+		}
+
+		if !errors.Is(err, object.ErrCancelled) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: expected a cancelled error. got=%q", input, err.Message)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	evaluated := New().EvalContext(ctx, parseProgram(t, "1"), object.NewEnviroment())
+	if s := describeResult(evaluated); s != "ERROR : execution cancelled: context canceled" {
+		t.Errorf("wrong result. got=%q", s)
+	}
+}
+
+func TestCall(t *testing.T) {
+	env := object.NewEnviroment()
+	Eval(parseProgram(t, `
+let add = fn(a, b) { a + b };
+let fail = fn(x) { x / 0 };
+let nothing = fn() { let y = 1; };
+`), env)
+
+	tests := []struct {
+		name     string
+		args     []object.Object
+		expected string
+	}{
+		{"add", []object.Object{&object.Integer{Value: 2}, &object.Integer{Value: 3}}, "5"},
+		{"nothing", nil, "NULL"},
+		{"len", []object.Object{&object.String{Value: "abc"}}, "3"},
+	}
+
+	for _, tt := range tests {
+		fn, ok := env.Get(tt.name)
+		if !ok {
+			fn, _ = object.GetBuiltinByName(tt.name)
+		}
+
+		result, err := Call(fn, tt.args...)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.name, err)
+			continue
+		}
+
+		if s := describeResult(result); s != tt.expected {
+			t.Errorf("%s: expected=%q. got=%q", tt.name, tt.expected, s)
+		}
+	}
+
+	errorTests := []struct {
+		fn       object.Object
+		args     []object.Object
+		expected string
+	}{
+		{mustGet(t, env, "fail"), []object.Object{&object.Integer{Value: 1}}, "division by zero: 1 / 0"},
+		{mustGet(t, env, "add"), []object.Object{&object.Integer{Value: 1}}, "wrong number of arguments: want=2, got=1"},
+		{&object.Integer{Value: 1}, nil, `not a function. got="1"`},
+		{nil, nil, `not a function. got="NULL"`},
+	}
+
+	for _, tt := range errorTests {
+		_, err := Call(tt.fn, tt.args...)
+
+		var objErr *object.Error
+		if !errors.As(err, &objErr) {
+			t.Errorf("expected an *object.Error. got=%T (%v)", err, err)
+			continue
+		}
+
+		if objErr.Message != tt.expected {
+			t.Errorf("expected=%q. got=%q", tt.expected, objErr.Message)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	files := map[string]string{
+		"lib/list.lang": `
+let _iter = fn(arr, acc, f) { if (len(arr) == 0) { acc } else { _iter(rest(arr), f(acc, first(arr)), f) } };
+let reduce = fn(arr, initial, f) { _iter(arr, initial, f) };
+let sum = fn(arr) { reduce(arr, 0, fn(a, b) { a + b }) };
+`,
+		"lib/math.lang": `
+let list = import("list.lang");
+let loads = 0;
+let total = fn(arr) { list.sum(arr) };
+`,
+		"lib/broken.lang": "let x 1;",
+		"lib/fails.lang":  "let x = 1 / 0;",
+		"lib/a.lang":      `let b = import("b.lang");`,
+		"lib/b.lang":      `let a = import("a.lang");`,
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let m = import("lib/math.lang"); m.total([1, 2, 3])`, "6"},
+		{`let m = import("lib/math.lang"); m.list.reduce([1, 2], 10, fn(a, b) { a * b })`, "20"},
+		{`let m = import("lib/math.lang"); m["total"]([4])`, "4"},
+		{`import("lib/math.lang") == import("./lib/../lib/math.lang")`, "true"},
+		{`let l = import("lib/list.lang"); l._iter`, "ERROR : _iter is not exported by module(lib/list.lang)"},
+		{`let l = import("lib/list.lang"); l.missing`, "ERROR : missing is not exported by module(lib/list.lang)"},
+		{`let x = 1; x.y`, "ERROR : member access not supported: INTEGER"},
+		{`import("lib/none.lang")`, `ERROR : cannot import "lib/none.lang": file does not exist`},
+		{`import("lib/broken.lang")`, `ERROR : cannot import "lib/broken.lang": expected next token to be "=" got="1"`},
+		{`import("lib/fails.lang")`, "ERROR : division by zero: 1 / 0"},
+		{`import("lib/a.lang")`, "ERROR : import cycle: lib/a.lang -> lib/b.lang -> lib/a.lang"},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.NewFile("main.lang", tt.input))
+		program := p.ParseProgram()
+		if p.ErrorsLen() != 0 {
+			t.Fatalf("parser had errors: %v", p.Errors())
+		}
+
+		e := New()
+		e.Modules = NewModules()
+		e.Modules.ReadFile = func(path string) ([]byte, error) {
+			src, ok := files[filepath.ToSlash(path)]
+			if !ok {
+				return nil, fs.ErrNotExist
+			}
+
+			return []byte(src), nil
+		}
+
+		evaluated := e.Eval(program, object.NewEnviroment())
+		if s := describeResult(evaluated); s != tt.expected {
+			t.Errorf("%s: expected=%q. got=%q", tt.input, tt.expected, s)
+		}
+	}
+}
+
+func TestImportErrorPosition(t *testing.T) {
+	e := New()
+	e.Modules = NewModules()
+	e.Modules.ReadFile = func(path string) ([]byte, error) {
+		return []byte("let f = fn(x) {\n  x / 0\n};"), nil
+	}
+
+	program := parser.New(lexer.NewFile("main.lang", `let m = import("m.lang"); m.f(1)`)).ParseProgram()
+
+	err, ok := e.Eval(program, object.NewEnviroment()).(*object.Error)
+	if !ok {
+		t.Fatalf("expected an error")
+	}
+
+	if err.Pos.String() != "m.lang:2:3" {
+		t.Errorf("wrong position. got=%s", err.Pos)
+	}
+
+	if len(err.Stack) != 1 || err.Stack[0].String() != "m.f (main.lang:1:27)" {
+		t.Errorf("wrong stack. got=%v", err.Stack)
+	}
+}
+
+func TestStringBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`len("héllo, 世界")`, "9"},
+		{`split("a,b,,c", ",")`, "[a, b, , c]"},
+		{`split("añb", "")`, "[a, ñ, b]"},
+		{`join(["a", "b", "c"], "-")`, "a-b-c"},
+		{`join([], ", ")`, ""},
+		{`trim("  hi\t\n")`, "hi"},
+		{`trim("--hi--", "-")`, "hi"},
+		{`upper("ñandú")`, "ÑANDÚ"},
+		{`lower("ÀÉÎ")`, "àéî"},
+		{`contains("hello", "ell")`, "true"},
+		{`contains("hello", "xyz")`, "false"},
+		{`replace("a-b-c", "-", "+")`, "a+b+c"},
+		{`starts_with("función", "fun")`, "true"},
+		{`ends_with("función", "ción")`, "true"},
+		{`index_of("日本語", "語")`, "2"},
+		{`index_of("abc", "z")`, "-1"},
+		{`substr("日本語です", 1, 2)`, "本語"},
+		{`substr("日本語です", 3)`, "です"},
+		{`repeat("ab", 3)`, "ababab"},
+		{`repeat("ab", 0)`, ""},
+		{`upper("a") == "A"`, "true"},
+		{`"a" + "b" != "ab"`, "false"},
+		{`split(1, ",")`, "ERROR : argument 1 to `split` must be STRING. got=INTEGER"},
+		{`upper()`, "ERROR : function `upper` expected 1 arguments. got=0"},
+		{`trim("a", "b", "c")`, "ERROR : function `trim` expected 1 to 2 arguments. got=3"},
+		{`join(["a", 1], "")`, "ERROR : function `join` expected an array of strings. got=INTEGER at index 1"},
+		{`substr("abc", 2, 5)`, "ERROR : slice bounds out of range [2:7] with length 3"},
+		{`substr("abc", 1, -1)`, "ERROR : function `substr` expected a length that isn't negative. got=-1"},
+		{`repeat("a", -1)`, "ERROR : function `repeat` expected a count that isn't negative. got=-1"},
+		{`repeat("ab", 100000000)`, "ERROR : function `repeat` would build a string longer than 67108864 bytes"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if s := describeResult(evaluated); s != tt.expected {
+			t.Errorf("%s: expected=%q. got=%q", tt.input, tt.expected, s)
+		}
+	}
+}
+
+func TestStringIndexAndSlice(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"héllo"[1]`, "é"},
+		{`"日本語"[2]`, "語"},
+		{`"abc"[3]`, "ERROR : index out of bounds. got=3"},
+		{`"abc"[-1]`, "ERROR : index out of bounds. got=-1"},
+		{`"héllo"[1:3]`, "él"},
+		{`"héllo"[:2]`, "hé"},
+		{`"héllo"[3:]`, "lo"},
+		{`"héllo"[:]`, "héllo"},
+		{`"abc"[2:1]`, "ERROR : slice bounds out of range [2:1] with length 3"},
+		{`"abc"[0:4]`, "ERROR : slice bounds out of range [0:4] with length 3"},
+		{`"abc"["a":]`, "ERROR : slice index must be an integer. got=STRING"},
+		{`[1, 2, 3, 4][1:3]`, "[2, 3]"},
+		{`let a = [1, 2, 3]; let b = a[1:]; b[0] = 9; a`, "[1, 2, 3]"},
+		{`let s = "abc"; s[len(s) - 1:]`, "c"},
+		{`5[1:2]`, "ERROR : slice operator not supported: INTEGER"},
+		{`{"a": 1}[0:1]`, "ERROR : slice operator not supported: HASH"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if s := describeResult(evaluated); s != tt.expected {
+			t.Errorf("%s: expected=%q. got=%q", tt.input, tt.expected, s)
+		}
+	}
+}
+
+func TestArrayBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`map([1, 2, 3], fn(x) { x * 2 })`, "[2, 4, 6]"},
+		{`let k = 10; map([1, 2], fn(x) { x + k })`, "[11, 12]"},
+		{`map([[1, 2], [3]], fn(row) { map(row, fn(x) { -x }) })`, "[[-1, -2], [-3]]"},
+		{`map(["a", "b"], upper)`, "[A, B]"},
+		{`map([], fn(x) { x })`, "[]"},
+		{`let count = 0; map([1, 2, 3], fn(x) { count += x }); count`, "6"},
+		{`let depth = fn(x) { if (len(x) == 0) { 1 } else { 1 + reduce(map(x, depth), 0, fn(a, b) { if (a > b) { a } else { b } }) } }; depth([[], [[[]]]])`, "4"},
+		{`filter(range(10), fn(x) { x % 3 == 0 })`, "[0, 3, 6, 9]"},
+		{`reduce([1, 2, 3, 4], 0, fn(acc, x) { acc + x })`, "10"},
+		{`reduce([], "empty", fn(acc, x) { acc + x })`, "empty"},
+		{`reduce(range(1, 5001), 0, fn(acc, x) { acc + x })`, "12502500"},
+		{`sort([3, 1.5, 2, -1])`, "[-1, 1.5, 2, 3]"},
+		{`sort(["pear", "apple", "fig"])`, "[apple, fig, pear]"},
+		{`sort([3, 1, 2], fn(a, b) { a > b })`, "[3, 2, 1]"},
+		{`sort([[2, "b"], [1, "a"], [2, "a"]], fn(a, b) { a[0] < b[0] })`, "[[1, a], [2, b], [2, a]]"},
+		{`let a = [2, 1]; sort(a); a`, "[2, 1]"},
+		{`reverse([1, 2, 3])`, "[3, 2, 1]"},
+		{`reverse("añb")`, "bña"},
+		{`range(5)`, "[0, 1, 2, 3, 4]"},
+		{`range(2, 5)`, "[2, 3, 4]"},
+		{`range(10, 0, -3)`, "[10, 7, 4, 1]"},
+		{`range(0, 10, 4)`, "[0, 4, 8]"},
+		{`range(5, 0)`, "[]"},
+		{`zip([1, 2, 3], ["a", "b"])`, "[[1, a], [2, b]]"},
+		{`any([1, 2, 3], fn(x) { x > 2 })`, "true"},
+		{`any([1, 2, 3], fn(x) { x > 3 })`, "false"},
+		{`any([false, null_value], fn(x) { x })`, "ERROR : identifier not found: null_value"},
+		{`any([false, 0])`, "true"},
+		{`all([1, 2, 3], fn(x) { x > 0 })`, "true"},
+		{`all([true, false])`, "false"},
+		{`all([])`, "true"},
+		{`find([1, 4, 9], fn(x) { x > 3 })`, "4"},
+		{`find([1, 4, 9], fn(x) { x > 10 })`, "NULL"},
+		{`flatten([1, [2, 3], [[4]], []])`, "[1, 2, 3, [4]]"},
+		{`unique([1, 2, 1, "a", "a", true, 2])`, "[1, 2, a, true]"},
+		{`map([1, 2], fn(x) { x / 0 })`, "ERROR : division by zero: 1 / 0"},
+		{`sort([1, 0], fn(a, b) { a / 0 })`, "ERROR : division by zero: 0 / 0"},
+		{`map([1], fn(a, b) { a })`, "ERROR : wrong number of arguments: want=2, got=1"},
+		{`map([1], 2)`, "ERROR : argument 2 to `map` must be FUNCTION. got=INTEGER"},
+		{`filter(1, fn(x) { x })`, "ERROR : argument 1 to `filter` must be ARRAY. got=INTEGER"},
+		{`reduce([1], fn(a, b) { a })`, "ERROR : function `reduce` expected 3 arguments. got=2"},
+		{`reduce([1], 0, 0)`, "ERROR : argument 3 to `reduce` must be FUNCTION. got=INTEGER"},
+		{`sort([1, "a"])`, "ERROR : function `sort` can't compare STRING with INTEGER"},
+		{`range(1, 2, 0)`, "ERROR : function `range` expected a step that isn't zero"},
+		{`range(100000000)`, "ERROR : function `range` would build an array longer than 16777216 elements"},
+		{`zip([1], 2)`, "ERROR : argument 2 to `zip` must be ARRAY. got=INTEGER"},
+		{`find([1])`, "ERROR : function `find` expected 2 arguments. got=1"},
+		{`unique([[1]])`, "ERROR : unusable as hash key: ARRAY"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		if s := describeResult(evaluated); s != tt.expected {
+			t.Errorf("%s: expected=%q. got=%q", tt.input, tt.expected, s)
+		}
+	}
+}
+
+func TestCallbackStackTrace(t *testing.T) {
+	input := `let f = fn(x) { x / 0 };
+map([1], f)`
+
+	err, ok := Eval(parseProgram(t, input), object.NewEnviroment()).(*object.Error)
+	if !ok {
+		t.Fatalf("expected an error")
+	}
+
+	if len(err.Stack) != 1 || err.Stack[0].String() != "map (2:1)" {
+		t.Errorf("wrong stack. got=%v", err.Stack)
+	}
+}
+
+func TestPrintAndInput(t *testing.T) {
+	tests := []struct {
+		input    string
+		stdin    string
+		expected string // printed output
+		result   string
+	}{
+		{`print("a", 1, [2])`, "", "a1[2]", "NULL"},
+		{`println("a"); println(); println(1, true)`, "", "a\n\n1true\n", "NULL"},
+		{`let name = input("name? "); println("hi ", name)`, "ana\nbob\n", "name? hi ana\n", "NULL"},
+		{`[readline(), readline(), readline()]`, "a\r\nb", "", "[a, b, NULL]"},
+		{`let l = input(); len(l)`, "ñandú\n", "", "5"},
+		{`input(1)`, "", "", "ERROR : argument 1 to `input` must be STRING. got=INTEGER"},
+		{`readline("a")`, "", "", "ERROR : function `readline` expected 0 arguments. got=1"},
+	}
+
+	for _, tt := range tests {
+		evaluated, out := testEvalIO(t, tt.input, tt.stdin)
+
+		if out != tt.expected {
+			t.Errorf("%s: expected output=%q. got=%q", tt.input, tt.expected, out)
+		}
+
+		if s := describeResult(evaluated); s != tt.result {
+			t.Errorf("%s: expected=%q. got=%q", tt.input, tt.result, s)
+		}
+	}
+}
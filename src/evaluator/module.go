@@ -0,0 +1,134 @@
+package evaluator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/dyxgou/parser/src/ast"
+	"github.com/dyxgou/parser/src/lexer"
+	"github.com/dyxgou/parser/src/object"
+	"github.com/dyxgou/parser/src/parser"
+)
+
+// Modules loads the files imported by the programs, every file is evaluated once and shared by the ones importing it
+type Modules struct {
+	// ReadFile reads an imported file, defaults to os.ReadFile
+	ReadFile func(path string) ([]byte, error)
+
+	loaded  map[string]*object.Module
+	loading []string // files being evaluated, the innermost is the last
+}
+
+func NewModules() *Modules {
+	return &Modules{
+		ReadFile: os.ReadFile,
+		loaded:   make(map[string]*object.Module),
+	}
+}
+
+// Returns the path of an import, a relative path is resolved from the directory of the importing file
+func resolveImport(importer, path string) string {
+	if filepath.IsAbs(path) || importer == "" {
+		return filepath.Clean(path)
+	}
+
+	return filepath.Join(filepath.Dir(importer), path)
+}
+
+func (e *Evaluator) evalImportExpression(node *ast.ImportExpression) object.Object {
+	if e.Modules == nil {
+		e.Modules = NewModules()
+	}
+	m := e.Modules
+
+	path := resolveImport(node.Pos().Filename, node.Path.Value())
+
+	// the same file imported with different relative paths is loaded once
+	key, err := filepath.Abs(path)
+	if err != nil {
+		key = path
+	}
+
+	if module, ok := m.loaded[key]; ok {
+		return module
+	}
+
+	for i, loading := range m.loading {
+		if loading == key {
+			cycle := append(slices.Clone(m.loading[i:]), key)
+			for j := range cycle {
+				cycle[j] = relativePath(cycle[j])
+			}
+
+			return newError("import cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	src, err := m.ReadFile(path)
+	if err != nil {
+		return newError("cannot import %q: %s", node.Path.Value(), err)
+	}
+
+	p := parser.New(lexer.NewFile(path, string(src)))
+	program := p.ParseProgram()
+
+	if p.ErrorsLen() != 0 {
+		var parseErr *parser.Error
+		if errors.As(p.Errors()[0], &parseErr) {
+			err := newError("cannot import %q: %s", node.Path.Value(), parseErr.Msg)
+			err.Pos = parseErr.Pos
+
+			return err
+		}
+
+		return newError("cannot import %q: %s", node.Path.Value(), p.Errors()[0])
+	}
+
+	m.loading = append(m.loading, key)
+	defer func() { m.loading = m.loading[:len(m.loading)-1] }()
+
+	// the module is evaluated on its own, outside of the function that imports it
+	frames := e.frames
+	e.frames = nil
+	defer func() { e.frames = frames }()
+
+	module := &object.Module{Path: path, Env: object.NewEnviroment()}
+	if result := e.Eval(program, module.Env); isError(result) {
+		return result
+	}
+
+	m.loaded[key] = module
+	return module
+}
+
+// Returns the path relative to the working directory when it's inside of it
+func relativePath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+
+	rel, err := filepath.Rel(wd, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+
+	return rel
+}
+
+func evalMemberExpression(obj object.Object, name string) object.Object {
+	module, ok := obj.(*object.Module)
+	if !ok {
+		return newError("member access not supported: %s", obj.Inspect())
+	}
+
+	val, ok := module.Export(name)
+	if !ok {
+		return newError("%s is not exported by %s", name, module)
+	}
+
+	return val
+}
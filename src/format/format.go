@@ -0,0 +1,59 @@
+// Package format prints programs in the canonical style of the language
+package format
+
+import (
+	"errors"
+
+	"github.com/dyxgou/parser/src/ast"
+	"github.com/dyxgou/parser/src/lexer"
+	"github.com/dyxgou/parser/src/parser"
+	"github.com/dyxgou/parser/src/token"
+)
+
+// Formats the source of a file keeping its comments, the errors of the parser are joined in the returned error.
+// Formatting the result again gives the same result
+func Source(filename, src string) (string, error) {
+	p := parser.New(lexer.NewFile(filename, src))
+	program := p.ParseProgram()
+
+	if p.ErrorsLen() != 0 {
+		return "", errors.Join(p.Errors()...)
+	}
+
+	pr := &printer{comments: comments(filename, src)}
+	pr.program(program)
+
+	return pr.sb.String(), nil
+}
+
+// Formats a node without comments, a program ends with a new line like a formatted file
+func Node(node ast.Node) string {
+	pr := &printer{}
+
+	switch node := node.(type) {
+	case *ast.Program:
+		pr.program(node)
+	case ast.Statement:
+		pr.statement(node, true)
+	case ast.Expression:
+		pr.expr(node, parser.LOWEST)
+	}
+
+	return pr.sb.String()
+}
+
+// Returns the comments of the source in the order they were written
+func comments(filename, src string) []token.Comment {
+	l := lexer.NewFile(filename, src)
+	l.SetErrorHandler(func(token.Position, string) {})
+
+	var comments []token.Comment
+	for {
+		tok := l.NextToken()
+		comments = append(comments, tok.Comments...)
+
+		if tok.Kind == token.EOF {
+			return comments
+		}
+	}
+}
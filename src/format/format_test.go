@@ -0,0 +1,166 @@
+package format
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var sourceTests = []struct {
+	input    string
+	expected string
+}{
+	{"let x=1", "let x = 1;\n"},
+	{"let y = x+2*3  ;  let z = (x+2)*3; x-(y-z); (x-y)-z", "let y = x + 2 * 3;\nlet z = (x + 2) * 3;\nx - (y - z);\nx - y - z;\n"},
+	{"-x[1]; (-x)[1]; !(a && b) || c; a = b = c; (a = b) + 1", "-x[1];\n(-x)[1];\n!(a && b) || c;\na = b = c;\n(a = b) + 1;\n"},
+	{"f(1)(2)[0].k; s[1:]; s[:2]; s[:]; import(\"a.lang\").b", "f(1)(2)[0].k;\ns[1:];\ns[:2];\ns[:];\nimport(\"a.lang\").b;\n"},
+	{`let s = "q\"\\` + "\n" + `	"`, "let s = \"q\\\"\\\\\\n\\t\";\n"},
+	{"let f = fn ( a,b ) {a+b}", "let f = fn(a, b) { a + b };\n"},
+	{"let f = fn() {\nreturn 1\n}", "let f = fn() {\n  return 1;\n};\n"},
+	{"let f = fn() {\n}; fn() { x; y }", "let f = fn() {};\nfn() {\n  x;\n  y\n};\n"},
+	// a block in one line keeps it only when its statement prints in one line
+	{"while (a) { while (b) { 1; 2 } }", "while (a) {\n  while (b) {\n    1;\n    2\n  }\n}\n"},
+	{"let f = fn() { [\n1] }", "let f = fn() {\n  [\n    1\n  ]\n};\n"},
+	{"if (x) { 1 } else { 2 }; let a = 1", "if (x) { 1 } else { 2 }\nlet a = 1;\n"},
+	// without the semicolon the next statement would call the if expression, the parens it loses don't count
+	{"if (x) { 1 }; (1 + 2)", "if (x) { 1 }\n1 + 2;\n"},
+	{"if (x) { 1 }; (2)", "if (x) { 1 }\n2;\n"},
+	{"if (x) { 1 }; (a + b)(1); if (y) { 2 }; -1", "if (x) { 1 };\n(a + b)(1);\nif (y) { 2 };\n-1;\n"},
+	{"if (x) { 1 }; ([1])[0]", "if (x) { 1 };\n[1][0];\n"},
+	{"while (x < 3) { x += 1; }\nfor (i in [1]) {\nbreak;\n}", "while (x < 3) { x += 1 }\nfor (i in [1]) {\n  break;\n}\n"},
+	{"let h = {\"a\":1, \"b\": [1,2,\n3]}", "let h = {\"a\": 1, \"b\": [1, 2, 3]};\n"},
+	{"print(\n1,2)", "print(\n  1,\n  2\n);\n"},
+	// a comment between the elements of a one line list takes it to one line per element
+	{"let h = {\"a\": 1, // one\n\"b\": 2};\nlet n = 1", "let h = {\n  \"a\": 1, // one\n  \"b\": 2\n};\nlet n = 1;\n"},
+	{"f(fn() {\n// inside\nx\n}, 2)", "f(fn() {\n  // inside\n  x\n}, 2);\n"},
+	{"let a = 1;\n\n\n\nlet b = 2;\nlet c = 3;", "let a = 1;\n\nlet b = 2;\nlet c = 3;\n"},
+	{"", ""},
+}
+
+func TestSource(t *testing.T) {
+	for _, tt := range sourceTests {
+		formatted, err := Source("", tt.input)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", tt.input, err)
+			continue
+		}
+
+		if formatted != tt.expected {
+			t.Errorf("%q: expected=%q. got=%q", tt.input, tt.expected, formatted)
+		}
+	}
+}
+
+func TestSourceComments(t *testing.T) {
+	input := `// header
+
+/* block */ let x = 1 // trailing
+let f = fn(a) { // after the brace
+  // inside
+
+
+  a
+
+  // last
+}
+let h = {
+  "k": 1, // one
+  // before two
+  "t": 2
+}
+// final`
+
+	expected := `// header
+
+/* block */
+let x = 1; // trailing
+let f = fn(a) { // after the brace
+  // inside
+
+  a
+
+  // last
+};
+let h = {
+  "k": 1, // one
+  // before two
+  "t": 2
+};
+// final
+`
+
+	formatted, err := Source("", input)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if formatted != expected {
+		t.Errorf("expected=\n%s\ngot=\n%s", expected, formatted)
+	}
+}
+
+// Formatting twice gives the same result and the same program
+func TestSourceIdempotent(t *testing.T) {
+	files, err := filepath.Glob("../../example/*.lang")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no examples found: %v", err)
+	}
+
+	inputs := []string{
+		"let g = {\n  \"k\": [\n    1, // one\n    2\n  ]\n};\nif (x) {\n  f(fn() { 1 })\n} else { y }\n(z)",
+		"/* a */ /* b */ let a = [ // open\n1]; // c\n\n\n// d",
+		"let h = {\"a\": 1, /* one */ \"b\": [2, // two\n3]};\nf(/* none */)",
+		"while (a) { while (b) { 1; 2 } }\nif (x) { [\n1] } else { f(fn() { 1; 2 }) }",
+	}
+
+	for _, tt := range sourceTests {
+		inputs = append(inputs, tt.input)
+	}
+
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		inputs = append(inputs, string(src))
+	}
+
+	for _, input := range inputs {
+		once, err := Source("", input)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", input, err)
+		}
+
+		twice, err := Source("", once)
+		if err != nil {
+			t.Fatalf("%q: unexpected error formatting again: %s", once, err)
+		}
+
+		if once != twice {
+			t.Errorf("%q: formatting again changed it.\nonce=%q\ntwice=%q", input, once, twice)
+		}
+
+		if a, b := parse(t, input).String(), parse(t, once).String(); a != b {
+			t.Errorf("%q: the formatted program is different.\nexpected=%q\ngot=%q", input, a, b)
+		}
+	}
+}
+
+func TestSourceErrors(t *testing.T) {
+	if _, err := Source("main.lang", "let x 1"); err == nil || err.Error()[:14] != "main.lang:1:7:" {
+		t.Errorf("expected the error of the parser. got=%v", err)
+	}
+}
+
+func TestNode(t *testing.T) {
+	program := parse(t, "let add = fn(a, b) { a + b };\nadd(1, 2 * (3 + 4))")
+
+	if s := Node(program); s != "let add = fn(a, b) { a + b };\nadd(1, 2 * (3 + 4));\n" {
+		t.Errorf("wrong program. got=%q", s)
+	}
+
+	if s := Node(program.Statements[1]); s != "add(1, 2 * (3 + 4));" {
+		t.Errorf("wrong statement. got=%q", s)
+	}
+}
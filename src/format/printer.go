@@ -0,0 +1,455 @@
+package format
+
+import (
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/dyxgou/parser/src/ast"
+	"github.com/dyxgou/parser/src/parser"
+	"github.com/dyxgou/parser/src/token"
+)
+
+const indentation = "  "
+
+// precedence of the expressions that never need parens, like literals or identifiers
+const atom = parser.INDEX + 1
+
+type printer struct {
+	sb        strings.Builder
+	indent    int
+	lineStart bool // nothing was written in the current line, so it still needs its indentation
+
+	comments []token.Comment // the comments not printed yet
+	lastLine int             // source line of the last thing printed, zero at the start of a block
+}
+
+func (p *printer) write(s string) {
+	if p.lineStart && s != "" {
+		p.sb.WriteString(strings.Repeat(indentation, p.indent))
+		p.lineStart = false
+	}
+
+	p.sb.WriteString(s)
+}
+
+func (p *printer) newline() {
+	p.sb.WriteByte('\n')
+	p.lineStart = true
+}
+
+// Keeps one blank line where the source had at least one before the line
+func (p *printer) blankLine(line int) {
+	if p.lastLine > 0 && line-p.lastLine > 1 {
+		p.newline()
+	}
+}
+
+// Prints the comments placed before the offset, every one in its own line
+func (p *printer) commentsBefore(offset int) {
+	for p.hasCommentBefore(offset) {
+		c := p.comments[0]
+		p.comments = p.comments[1:]
+
+		p.blankLine(c.Pos.Line)
+		p.write(c.Text)
+		p.newline()
+		p.lastLine = max(p.lastLine, c.End.Line)
+	}
+}
+
+// Prints the comments written in the source line before the offset at the end of the current line
+func (p *printer) trailingComments(line, offset int) {
+	for p.hasCommentBefore(offset) && p.comments[0].Pos.Line == line {
+		c := p.comments[0]
+		p.comments = p.comments[1:]
+
+		p.write(" ")
+		p.write(c.Text)
+		p.lastLine = max(p.lastLine, c.End.Line)
+	}
+}
+
+func (p *printer) hasCommentBefore(offset int) bool {
+	return len(p.comments) > 0 && p.comments[0].Pos.Offset < offset
+}
+
+func (p *printer) program(program *ast.Program) {
+	p.statements(program.Statements, math.MaxInt, false)
+}
+
+// Prints every statement in its own line followed by the comments before end
+func (p *printer) statements(stmts []ast.Statement, end int, inBlock bool) {
+	for i, stmt := range stmts {
+		p.commentsBefore(stmt.Pos().Offset)
+		p.blankLine(stmt.Pos().Line)
+
+		next := end
+		var nextStmt ast.Statement
+		if i+1 < len(stmts) {
+			nextStmt = stmts[i+1]
+			next = nextStmt.Pos().Offset
+		}
+
+		// the value of a block is its last expression, which is written without a semicolon
+		_, isExp := stmt.(*ast.ExpressionStatement)
+		value := inBlock && isExp && nextStmt == nil
+		p.statement(stmt, !value && needsSemicolon(stmt, nextStmt))
+
+		p.lastLine = stmt.End().Line
+		p.trailingComments(stmt.End().Line, next)
+		p.newline()
+	}
+
+	p.commentsBefore(end)
+}
+
+// The statements end with a semicolon except the loops and the if expressions, which end with a brace.
+// An if expression still needs it when the next statement would continue it, like one starting with "("
+func needsSemicolon(stmt, next ast.Statement) bool {
+	switch stmt := stmt.(type) {
+	case *ast.WhileStatement, *ast.ForStatement, *ast.BlockStatement:
+		return false
+	case *ast.ExpressionStatement:
+		if _, ok := stmt.Expression.(*ast.IfExpression); !ok {
+			return true
+		}
+
+		nextExp, ok := next.(*ast.ExpressionStatement)
+		return ok && parser.InfixPrecedence(firstToken(nextExp.Expression, parser.LOWEST)) != parser.LOWEST
+	}
+
+	return true
+}
+
+// Returns the kind of the first token printed for an expression written where the precedence is pr, which isn't
+// the first one of the source when the printer drops its parens. The identifiers and literals give token.IDENT
+func firstToken(exp ast.Expression, pr parser.Precendence) token.TokenKind {
+	if precedence(exp) < pr {
+		return token.LPAREN
+	}
+
+	switch exp := exp.(type) {
+	case *ast.InfixExpression:
+		return firstToken(exp.Left, parser.InfixPrecedence(exp.Token.Kind))
+	case *ast.AssignExpression:
+		return firstToken(exp.Target, parser.ASSIGN+1)
+	case *ast.CallExpression:
+		return firstToken(exp.Function, parser.CALL)
+	case *ast.IndexExpression:
+		return firstToken(exp.Left, parser.CALL)
+	case *ast.SliceExpression:
+		return firstToken(exp.Left, parser.CALL)
+	case *ast.MemberExpression:
+		return firstToken(exp.Object, parser.CALL)
+	case *ast.PrefixExpression:
+		return exp.Token.Kind
+	case *ast.ArrayLiteral:
+		return exp.Token.Kind
+	case *ast.HashLiteral:
+		return exp.Token.Kind
+	case *ast.FunctionLiteral:
+		return exp.Token.Kind
+	case *ast.IfExpression:
+		return exp.Token.Kind
+	case *ast.ImportExpression:
+		return exp.Token.Kind
+	}
+
+	return token.IDENT
+}
+
+func (p *printer) statement(stmt ast.Statement, semicolon bool) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		p.write("let ")
+		p.write(stmt.Name.String())
+		p.write(" = ")
+		p.expr(stmt.Value, parser.LOWEST)
+	case *ast.ReturnStatement:
+		p.write("return ")
+		p.expr(stmt.Value, parser.LOWEST)
+	case *ast.ExpressionStatement:
+		p.expr(stmt.Expression, parser.LOWEST)
+	case *ast.BreakStatement, *ast.ContinueStatement:
+		p.write(stmt.TokenLiteral())
+	case *ast.WhileStatement:
+		p.write("while (")
+		p.expr(stmt.Condition, parser.LOWEST)
+		p.write(") ")
+		p.block(stmt.Body)
+	case *ast.ForStatement:
+		p.write("for (")
+		p.write(stmt.Variable.String())
+		p.write(" in ")
+		p.expr(stmt.Iterable, parser.LOWEST)
+		p.write(") ")
+		p.block(stmt.Body)
+	case *ast.BlockStatement:
+		p.block(stmt)
+	}
+
+	if semicolon {
+		p.write(";")
+	}
+}
+
+// A block written in one line with just one statement that prints in one line stays in one line, the others have
+// a line for every statement
+func (p *printer) block(block *ast.BlockStatement) {
+	end := block.Rbrace.Offset
+	stmts := block.Statements
+
+	if p.hasCommentBefore(end) {
+		// the comments before the block go in its first line
+		p.write("{")
+		p.trailingComments(block.Pos().Line, end)
+	} else if len(stmts) == 0 {
+		p.write("{}")
+		return
+	} else if line, ok := oneLineStatement(block); ok {
+		p.write("{ " + line + " }")
+		return
+	} else {
+		p.write("{")
+	}
+
+	p.newline()
+	p.indent++
+	p.lastLine = 0
+
+	p.statements(stmts, end, true)
+
+	p.indent--
+	p.write("}")
+	p.lastLine = block.Rbrace.Line
+}
+
+// Prints the statement of a block written in one line with just one statement, ok is false when it doesn't fit in
+// one line. The block has no comments, so the statement is printed without them
+func oneLineStatement(block *ast.BlockStatement) (line string, ok bool) {
+	if len(block.Statements) != 1 || block.Pos().Line != block.Rbrace.Line {
+		return "", false
+	}
+
+	pr := &printer{}
+	pr.statement(block.Statements[0], false)
+	line = pr.sb.String()
+
+	return line, !strings.Contains(line, "\n")
+}
+
+// Returns the precedence of an expression, the parens around an expression are needed when it's lower than
+// the one of the place where it's written
+func precedence(exp ast.Expression) parser.Precendence {
+	switch exp := exp.(type) {
+	case *ast.InfixExpression:
+		return parser.InfixPrecedence(exp.Token.Kind)
+	case *ast.AssignExpression:
+		return parser.ASSIGN
+	case *ast.PrefixExpression:
+		return parser.PREFIX
+	case *ast.CallExpression:
+		return parser.CALL
+	case *ast.IndexExpression, *ast.SliceExpression, *ast.MemberExpression:
+		return parser.INDEX
+	}
+
+	return atom
+}
+
+func (p *printer) expr(exp ast.Expression, pr parser.Precendence) {
+	if precedence(exp) < pr {
+		p.write("(")
+		defer p.write(")")
+	}
+
+	switch exp := exp.(type) {
+	case *ast.Identifier, *ast.IntegerLiteral, *ast.FloatLiteral, *ast.Boolean:
+		p.write(exp.TokenLiteral())
+	case *ast.StringLiteral:
+		p.write(quote(exp.Token.Literal))
+	case *ast.PrefixExpression:
+		p.write(exp.Token.Literal)
+		p.expr(exp.Right, parser.PREFIX)
+	case *ast.InfixExpression:
+		// the operators are left associative, so the right side needs parens for the same precedence
+		opPr := parser.InfixPrecedence(exp.Token.Kind)
+		p.expr(exp.Left, opPr)
+		p.write(" " + exp.Token.Literal + " ")
+		p.expr(exp.Right, opPr+1)
+	case *ast.AssignExpression:
+		// while the assignments are right associative
+		p.expr(exp.Target, parser.ASSIGN+1)
+		p.write(" " + exp.Token.Literal + " ")
+		p.expr(exp.Value, parser.ASSIGN)
+	case *ast.IfExpression:
+		p.write("if (")
+		p.expr(exp.Condition, parser.LOWEST)
+		p.write(") ")
+		p.block(exp.Consequence)
+
+		if exp.Alternative != nil {
+			p.write(" else ")
+			p.block(exp.Alternative)
+		}
+	case *ast.FunctionLiteral:
+		p.write("fn(")
+		for i, param := range exp.Params {
+			if i > 0 {
+				p.write(", ")
+			}
+
+			p.write(param.String())
+		}
+		p.write(") ")
+		p.block(exp.Body)
+	case *ast.CallExpression:
+		p.expr(exp.Function, parser.CALL)
+		p.list("(", ")", exp.Token.Pos, exp.Rparen, spans(exp.Arguments), func(i int) {
+			p.expr(exp.Arguments[i], parser.LOWEST)
+		})
+	case *ast.ArrayLiteral:
+		p.list("[", "]", exp.Token.Pos, exp.Rbracket, spans(exp.Elements), func(i int) {
+			p.expr(exp.Elements[i], parser.LOWEST)
+		})
+	case *ast.HashLiteral:
+		pairs := make([]span, len(exp.Pairs))
+		for i, pair := range exp.Pairs {
+			pairs[i] = span{pair.Key.Pos(), pair.Value.End()}
+		}
+
+		p.list("{", "}", exp.Token.Pos, exp.Rbrace, pairs, func(i int) {
+			p.expr(exp.Pairs[i].Key, parser.LOWEST)
+			p.write(": ")
+			p.expr(exp.Pairs[i].Value, parser.LOWEST)
+		})
+	case *ast.IndexExpression:
+		p.expr(exp.Left, parser.CALL)
+		p.write("[")
+		p.expr(exp.Index, parser.LOWEST)
+		p.write("]")
+	case *ast.SliceExpression:
+		p.expr(exp.Left, parser.CALL)
+		p.write("[")
+		if exp.Low != nil {
+			p.expr(exp.Low, parser.LOWEST)
+		}
+		p.write(":")
+		if exp.High != nil {
+			p.expr(exp.High, parser.LOWEST)
+		}
+		p.write("]")
+	case *ast.MemberExpression:
+		p.expr(exp.Object, parser.CALL)
+		p.write(".")
+		p.write(exp.Property.String())
+	case *ast.ImportExpression:
+		p.write("import(")
+		p.write(quote(exp.Path.Token.Literal))
+		p.write(")")
+	}
+}
+
+// span is the place of an element of a list in the source
+type span struct {
+	pos, end token.Position
+}
+
+func spans(exps []ast.Expression) []span {
+	spans := make([]span, len(exps))
+	for i, exp := range exps {
+		spans[i] = span{exp.Pos(), exp.End()}
+	}
+
+	return spans
+}
+
+// Prints the elements of a list between its delimiters, elem prints the element i. The list is written in one line
+// unless its first element was in a line after the opening delimiter or it has comments between its elements,
+// then every element has its own line
+func (p *printer) list(open, close string, openPos, closePos token.Position, elems []span, elem func(i int)) {
+	p.write(open)
+
+	oneLine := len(elems) == 0 || elems[0].pos.Line == openPos.Line
+	if oneLine && !p.listHasComments(openPos.Offset, closePos.Offset, elems) {
+		for i := range elems {
+			if i > 0 {
+				p.write(", ")
+			}
+
+			elem(i)
+		}
+
+		p.write(close)
+		return
+	}
+
+	p.newline()
+	p.indent++
+	lastLine := p.lastLine
+	p.lastLine = 0
+
+	for i, e := range elems {
+		p.commentsBefore(e.pos.Offset)
+		elem(i)
+
+		next := closePos.Offset
+		if i+1 < len(elems) {
+			p.write(",")
+			next = elems[i+1].pos.Offset
+		}
+
+		p.lastLine = e.end.Line
+		p.trailingComments(e.end.Line, next)
+		p.newline()
+	}
+
+	p.commentsBefore(closePos.Offset)
+	p.indent--
+	p.write(close)
+	p.lastLine = lastLine
+}
+
+// Tells if there are comments inside the delimiters of a list that aren't inside its elements
+func (p *printer) listHasComments(open, close int, elems []span) bool {
+	for _, c := range p.comments {
+		if c.Pos.Offset >= close {
+			return false
+		}
+
+		inside := slices.ContainsFunc(elems, func(e span) bool {
+			return e.pos.Offset <= c.Pos.Offset && c.Pos.Offset < e.end.Offset
+		})
+
+		if c.Pos.Offset > open && !inside {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Writes the value of a string back as a literal, with the escapes the lexer knows
+func quote(s string) string {
+	var sb strings.Builder
+
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteByte('"')
+
+	return sb.String()
+}
@@ -0,0 +1,441 @@
+package interpreter
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+
+	"github.com/dyxgou/parser/src/object"
+)
+
+var (
+	objectType  = reflect.TypeFor[object.Object]()
+	runtimeType = reflect.TypeFor[object.Runtime]()
+	contextType = reflect.TypeFor[context.Context]()
+	errorType   = reflect.TypeFor[error]()
+)
+
+// Converts a Go value to an object.
+//
+// Ints, uints and floats become integers and floats, strings and bools keep their kind, slices and arrays become
+// arrays, maps become hashes with the keys sorted and funcs become builtin functions. Pointers and interfaces are
+// converted to the value they hold, nil becomes NULL and an object.Object is returned as it is
+func ToObject(v any) (object.Object, error) {
+	if v == nil {
+		return object.NULL, nil
+	}
+
+	return toObject("fn", reflect.ValueOf(v), make(map[goRef]object.Object))
+}
+
+// goRef identifies a slice or a map of Go by the memory it points to
+type goRef struct {
+	typ reflect.Type
+	ptr uintptr
+	len int
+}
+
+// Converts a Go value, seen holds the arrays and hashes made for the slices and maps being converted, so a slice or
+// a map holding itself becomes an array or a hash holding itself
+func toObject(name string, v reflect.Value, seen map[goRef]object.Object) (object.Object, error) {
+	if v.Type().Implements(objectType) {
+		if isNil(v) {
+			return object.NULL, nil
+		}
+
+		return v.Interface().(object.Object), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return object.NativeBoolToBooleanObject(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &object.Integer{Value: v.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := v.Uint()
+		if u > math.MaxInt64 {
+			return nil, fmt.Errorf("%d overflows an integer", u)
+		}
+
+		return &object.Integer{Value: int64(u)}, nil
+	case reflect.Float32, reflect.Float64:
+		return &object.Float{Value: v.Float()}, nil
+	case reflect.String:
+		return &object.String{Value: v.String()}, nil
+	case reflect.Slice, reflect.Array:
+		arr := &object.Array{Elements: make([]object.Object, v.Len())}
+
+		if v.Kind() == reflect.Slice && v.Len() > 0 {
+			ref := goRef{v.Type(), v.Pointer(), v.Len()}
+			if obj, ok := seen[ref]; ok {
+				return obj, nil
+			}
+
+			seen[ref] = arr
+		}
+
+		for i := range arr.Elements {
+			elem, err := toObject(name, v.Index(i), seen)
+			if err != nil {
+				return nil, err
+			}
+
+			arr.Elements[i] = elem
+		}
+
+		return arr, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return object.NewHash(), nil
+		}
+
+		ref := goRef{v.Type(), v.Pointer(), 0}
+		if obj, ok := seen[ref]; ok {
+			return obj, nil
+		}
+
+		hash := object.NewHash()
+		seen[ref] = hash
+
+		return hash, fillHash(hash, name, v, seen)
+	case reflect.Func:
+		if v.IsNil() {
+			return object.NULL, nil
+		}
+
+		return wrapFunc(name, v)
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return object.NULL, nil
+		}
+
+		return toObject(name, v.Elem(), seen)
+	}
+
+	return nil, fmt.Errorf("cannot convert %s to an object", v.Type())
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	}
+
+	return false
+}
+
+// Sets the pairs of the map in the hash, the keys are sorted so the hash doesn't depend on the iteration order of the map
+func fillHash(hash *object.Hash, name string, v reflect.Value, seen map[goRef]object.Object) error {
+	keys := v.MapKeys()
+	slices.SortFunc(keys, compareKeys)
+
+	for _, k := range keys {
+		key, err := toObject(name, k, seen)
+		if err != nil {
+			return err
+		}
+
+		hashable, ok := key.(object.Hashable)
+		if !ok {
+			return fmt.Errorf("unusable as hash key: %s", k.Type())
+		}
+
+		val, err := toObject(name, v.MapIndex(k), seen)
+		if err != nil {
+			return err
+		}
+
+		hash.Set(hashable, val)
+	}
+
+	return nil
+}
+
+func compareKeys(a, b reflect.Value) int {
+	if a.Kind() == reflect.Interface {
+		a, b = a.Elem(), b.Elem()
+	}
+
+	if c := cmp.Compare(a.Kind(), b.Kind()); c != 0 || !a.IsValid() {
+		return c
+	}
+
+	switch a.Kind() {
+	case reflect.String:
+		return cmp.Compare(a.String(), b.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Bool:
+		if a.Bool() == b.Bool() {
+			return 0
+		} else if a.Bool() {
+			return 1
+		}
+
+		return -1
+	}
+
+	return 0
+}
+
+// Converts an object to a Go value.
+//
+// Integers, floats, strings and booleans become int64, float64, string and bool, NULL becomes nil, arrays become
+// []any and hashes become map[string]any when all their keys are strings or map[any]any when they are not. The
+// objects without a Go counterpart, like functions, are returned as they are. An array or a hash holding itself
+// becomes a slice or a map holding itself
+func ToGo(obj object.Object) any {
+	return toGo(obj, make(map[object.Object]any))
+}
+
+// Converts an object, seen holds the slices and maps made for the arrays and hashes being converted
+func toGo(obj object.Object, seen map[object.Object]any) any {
+	if v, ok := seen[obj]; ok {
+		return v
+	}
+
+	switch obj := obj.(type) {
+	case nil, *object.Null:
+		return nil
+	case *object.Integer:
+		return obj.Value
+	case *object.Float:
+		return obj.Value
+	case *object.String:
+		return obj.Value
+	case *object.Boolean:
+		return obj.Value
+	case *object.Array:
+		elems := make([]any, len(obj.Elements))
+		seen[obj] = elems
+
+		for i, elem := range obj.Elements {
+			elems[i] = toGo(elem, seen)
+		}
+
+		return elems
+	case *object.Hash:
+		return hashToGo(obj, seen)
+	}
+
+	return obj
+}
+
+func hashToGo(hash *object.Hash, seen map[object.Object]any) any {
+	pairs := hash.Pairs()
+
+	stringKeys := true
+	for _, pair := range pairs {
+		if _, ok := pair.Key.(*object.String); !ok {
+			stringKeys = false
+			break
+		}
+	}
+
+	if stringKeys {
+		m := make(map[string]any, len(pairs))
+		seen[hash] = m
+
+		for _, pair := range pairs {
+			m[pair.Key.(*object.String).Value] = toGo(pair.Value, seen)
+		}
+
+		return m
+	}
+
+	m := make(map[any]any, len(pairs))
+	seen[hash] = m
+
+	for _, pair := range pairs {
+		m[toGo(pair.Key, seen)] = toGo(pair.Value, seen)
+	}
+
+	return m
+}
+
+// Converts an object to a Go value of type t
+func fromObject(obj object.Object, t reflect.Type) (reflect.Value, error) {
+	if t.Kind() == reflect.Interface {
+		if t.NumMethod() == 0 {
+			v := ToGo(obj)
+			if v == nil {
+				return reflect.Zero(t), nil
+			}
+
+			return reflect.ValueOf(v), nil
+		}
+
+		if reflect.TypeOf(obj).Implements(t) {
+			return reflect.ValueOf(obj), nil
+		}
+	}
+
+	v := reflect.New(t).Elem()
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, ok := obj.(*object.Boolean); ok {
+			v.SetBool(b.Value)
+			return v, nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, ok := obj.(*object.Integer); ok {
+			if v.OverflowInt(i.Value) {
+				return v, fmt.Errorf("%d overflows %s", i.Value, t)
+			}
+
+			v.SetInt(i.Value)
+			return v, nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if i, ok := obj.(*object.Integer); ok {
+			if i.Value < 0 || v.OverflowUint(uint64(i.Value)) {
+				return v, fmt.Errorf("%d overflows %s", i.Value, t)
+			}
+
+			v.SetUint(uint64(i.Value))
+			return v, nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, ok := object.ToFloat(obj); ok {
+			v.SetFloat(f)
+			return v, nil
+		}
+	case reflect.String:
+		if s, ok := obj.(*object.String); ok {
+			v.SetString(s.Value)
+			return v, nil
+		}
+	case reflect.Slice:
+		if obj == object.NULL {
+			return v, nil
+		}
+
+		if arr, ok := obj.(*object.Array); ok {
+			v = reflect.MakeSlice(t, len(arr.Elements), len(arr.Elements))
+
+			for i, elem := range arr.Elements {
+				ev, err := fromObject(elem, t.Elem())
+				if err != nil {
+					return v, err
+				}
+
+				v.Index(i).Set(ev)
+			}
+
+			return v, nil
+		}
+	case reflect.Map:
+		if obj == object.NULL {
+			return v, nil
+		}
+
+		if hash, ok := obj.(*object.Hash); ok {
+			v = reflect.MakeMapWithSize(t, hash.Len())
+
+			for _, pair := range hash.Pairs() {
+				kv, err := fromObject(pair.Key, t.Key())
+				if err != nil {
+					return v, err
+				}
+
+				ev, err := fromObject(pair.Value, t.Elem())
+				if err != nil {
+					return v, err
+				}
+
+				v.SetMapIndex(kv, ev)
+			}
+
+			return v, nil
+		}
+	}
+
+	return v, fmt.Errorf("expected %s. got=%s", t, obj.Inspect())
+}
+
+// Wraps a Go function into a builtin, the arguments are converted to the types of its parameters and its result back
+// to an object. The function may take a context.Context or an object.Runtime as its first parameter, which is filled
+// with the ones of the engine calling it, and may return an error as its last result, which becomes an error of the
+// program
+func wrapFunc(name string, fn reflect.Value) (*object.BuiltIn, error) {
+	t := fn.Type()
+
+	switch n := t.NumOut(); {
+	case n > 2:
+		return nil, fmt.Errorf("function %s returns %d results, at most 2 are supported", name, n)
+	case n == 2 && t.Out(1) != errorType:
+		return nil, fmt.Errorf("second result of function %s must be an error. got=%s", name, t.Out(1))
+	}
+
+	injectRuntime := t.NumIn() > 0 && (t.In(0) == runtimeType || t.In(0) == contextType)
+
+	params := make([]reflect.Type, 0, t.NumIn())
+	for i := range t.NumIn() {
+		if i == 0 && injectRuntime {
+			continue
+		}
+
+		params = append(params, t.In(i))
+	}
+
+	return &object.BuiltIn{Fn: func(rt object.Runtime, args ...object.Object) object.Object {
+		in := make([]reflect.Value, 0, t.NumIn())
+
+		if injectRuntime {
+			if t.In(0) == contextType {
+				in = append(in, reflect.ValueOf(rt.Context()))
+			} else {
+				in = append(in, reflect.ValueOf(&rt).Elem())
+			}
+		}
+
+		if t.IsVariadic() {
+			if least := len(params) - 1; len(args) < least {
+				return object.NewError("function `%s` expected at least %d arguments. got=%d", name, least, len(args))
+			}
+		} else if len(args) != len(params) {
+			return object.NewError("function `%s` expected %d arguments. got=%d", name, len(params), len(args))
+		}
+
+		for i, arg := range args {
+			pt := params[min(i, len(params)-1)]
+			if t.IsVariadic() && i >= len(params)-1 {
+				pt = pt.Elem()
+			}
+
+			v, err := fromObject(arg, pt)
+			if err != nil {
+				return object.NewError("argument %d to `%s`: %s", i+1, name, err)
+			}
+
+			in = append(in, v)
+		}
+
+		out := fn.Call(in)
+
+		if n := len(out); n > 0 && t.Out(n-1) == errorType {
+			if err, _ := out[n-1].Interface().(error); err != nil {
+				return &object.Error{Message: err.Error(), Cause: err}
+			}
+
+			out = out[:n-1]
+		}
+
+		if len(out) == 0 {
+			return object.NULL
+		}
+
+		result, err := toObject(name, out[0], make(map[goRef]object.Object))
+		if err != nil {
+			return object.NewError("result of `%s`: %s", name, err)
+		}
+
+		return result
+	}}, nil
+}
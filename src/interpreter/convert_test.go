@@ -0,0 +1,184 @@
+package interpreter
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/dyxgou/parser/src/object"
+)
+
+func TestToObject(t *testing.T) {
+	n := 7
+
+	tests := []struct {
+		input    any
+		expected string
+	}{
+		{nil, "NULL"},
+		{int8(-3), "-3"},
+		{uint32(5), "5"},
+		{float32(1.5), "1.5"},
+		{"hi", "hi"},
+		{false, "false"},
+		{&n, "7"},
+		{[2]bool{true, false}, "[true, false]"},
+		{[]any{1, "a", nil}, "[1, a, NULL]"},
+		{map[string]int{"b": 2, "a": 1, "c": 3}, "{a: 1, b: 2, c: 3}"},
+		{map[int]string{3: "c", -1: "a"}, "{-1: a, 3: c}"},
+		{map[any]int{"x": 1, 2: 2, true: 3}, "{true: 3, 2: 2, x: 1}"},
+		{&object.Integer{Value: 9}, "9"},
+		{[]int(nil), "[]"},
+	}
+
+	for _, tt := range tests {
+		obj, err := ToObject(tt.input)
+		if err != nil {
+			t.Errorf("ToObject(%#v) error: %s", tt.input, err)
+			continue
+		}
+
+		if obj.String() != tt.expected {
+			t.Errorf("ToObject(%#v) expected=%q. got=%q", tt.input, tt.expected, obj.String())
+		}
+	}
+}
+
+func TestToObjectErrors(t *testing.T) {
+	tests := []struct {
+		input    any
+		expected string
+	}{
+		{uint64(math.MaxUint64), "18446744073709551615 overflows an integer"},
+		{struct{}{}, "cannot convert struct {} to an object"},
+		{map[float64]int{1.5: 1}, "unusable as hash key: float64"},
+		{[]chan int{nil}, "cannot convert chan int to an object"},
+	}
+
+	for _, tt := range tests {
+		_, err := ToObject(tt.input)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("ToObject(%#v) expected error %q. got=%v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestToGo(t *testing.T) {
+	fn := &object.BuiltIn{}
+
+	tests := []struct {
+		input    object.Object
+		expected any
+	}{
+		{nil, nil},
+		{object.NULL, nil},
+		{&object.Integer{Value: 1}, int64(1)},
+		{&object.Float{Value: 0.25}, 0.25},
+		{&object.String{Value: "s"}, "s"},
+		{object.TRUE, true},
+		{&object.Array{Elements: []object.Object{object.NULL, fn}}, []any{nil, fn}},
+		{fn, fn},
+	}
+
+	for _, tt := range tests {
+		got := ToGo(tt.input)
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("ToGo(%v) expected=%#v. got=%#v", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestToGoCycles(t *testing.T) {
+	in := New()
+
+	if _, err := in.Run(`let a = [1]; push(a, a); let h = {"n": 1}; h["self"] = h; 1`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	a, _ := in.Get("a")
+	arr, ok := a.([]any)
+	if !ok || len(arr) != 2 {
+		t.Fatalf("expected a slice of 2 elements. got=%T", a)
+	}
+
+	if inner, ok := arr[1].([]any); !ok || &inner[0] != &arr[0] {
+		t.Errorf("expected the slice to hold itself. got=%T", arr[1])
+	}
+
+	h, err := in.Run("h")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	m, ok := h.(map[string]any)
+	if !ok {
+		t.Fatalf("expected a map. got=%T", h)
+	}
+
+	if inner, ok := m["self"].(map[string]any); !ok || reflect.ValueOf(inner).Pointer() != reflect.ValueOf(m).Pointer() {
+		t.Errorf("expected the map to hold itself. got=%T", m["self"])
+	}
+}
+
+func TestToObjectCycles(t *testing.T) {
+	s := []any{1, nil}
+	s[1] = s
+
+	obj, err := ToObject(s)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if arr, ok := obj.(*object.Array); !ok || arr.Elements[1] != obj {
+		t.Errorf("expected the array to hold itself. got=%T", obj)
+	}
+
+	m := map[string]any{"n": 1}
+	m["self"] = m
+
+	obj, err = ToObject(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	hash, ok := obj.(*object.Hash)
+	if !ok {
+		t.Fatalf("expected a hash. got=%T", obj)
+	}
+
+	if self, ok := hash.Get(&object.String{Value: "self"}); !ok || self != obj {
+		t.Errorf("expected the hash to hold itself. got=%T", self)
+	}
+}
+
+func TestFromObject(t *testing.T) {
+	hash := object.NewHash()
+	hash.Set(&object.String{Value: "a"}, &object.Integer{Value: 1})
+
+	tests := []struct {
+		input    object.Object
+		expected any
+	}{
+		{&object.Integer{Value: 2}, 2.0},
+		{&object.Integer{Value: 255}, uint8(255)},
+		{&object.Array{Elements: []object.Object{&object.String{Value: "x"}}}, []string{"x"}},
+		{hash, map[string]int64{"a": 1}},
+		{object.NULL, []int(nil)},
+	}
+
+	for _, tt := range tests {
+		v, err := fromObject(tt.input, reflect.TypeOf(tt.expected))
+		if err != nil {
+			t.Errorf("fromObject(%s) error: %s", tt.input, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(v.Interface(), tt.expected) {
+			t.Errorf("fromObject(%s) expected=%#v. got=%#v", tt.input, tt.expected, v.Interface())
+		}
+	}
+
+	if _, err := fromObject(&object.Integer{Value: 256}, reflect.TypeFor[uint8]()); err == nil || err.Error() != "256 overflows uint8" {
+		t.Errorf("expected an overflow error. got=%v", err)
+	}
+}
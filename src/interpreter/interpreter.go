@@ -0,0 +1,214 @@
+// Package interpreter embeds the language in Go programs
+package interpreter
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/dyxgou/parser/src/evaluator"
+	"github.com/dyxgou/parser/src/lexer"
+	"github.com/dyxgou/parser/src/object"
+	"github.com/dyxgou/parser/src/parser"
+)
+
+type Config struct {
+	Filename string // errors are reported inside this file
+
+	// limits of every Run, zero means no limit
+	MaxSteps int           // evaluated nodes
+	Timeout  time.Duration // wall clock time
+
+	// MaxCallDepth is the maximum number of nested function calls, zero means evaluator.DefaultMaxCallDepth
+	MaxCallDepth int
+
+	// streams of print and input, nil means the ones of the process
+	Stdout io.Writer
+	Stdin  io.Reader
+}
+
+// Interpreter runs programs on the tree walking evaluator, the globals defined by a Run are seen by the next ones
+type Interpreter struct {
+	cfg     Config
+	env     *object.Enviroment
+	modules *evaluator.Modules // imported files, loaded once for all the runs
+	stdin   *bufio.Reader      // shared by the runs so a run doesn't lose what the previous one buffered
+}
+
+func New() *Interpreter {
+	return NewWithConfig(Config{})
+}
+
+func NewWithConfig(cfg Config) *Interpreter {
+	stdin := cfg.Stdin
+	if stdin == nil {
+		stdin = os.Stdin
+	}
+
+	return &Interpreter{
+		cfg:     cfg,
+		env:     object.NewEnviroment(),
+		modules: evaluator.NewModules(),
+		stdin:   bufio.NewReader(stdin),
+	}
+}
+
+// Runs the source and returns the value of its last statement converted with ToGo.
+// Parsing errors are joined in the returned error and a runtime error is returned as an *object.Error
+func (in *Interpreter) Run(src string) (any, error) {
+	return in.RunContext(context.Background(), src)
+}
+
+// Runs the source until it's done, ctx is done or it runs out of the limits of the config
+func (in *Interpreter) RunContext(ctx context.Context, src string) (_ any, err error) {
+	defer recoverPanic(&err)
+
+	ctx, cancel := in.context(ctx)
+	defer cancel()
+
+	p := parser.New(lexer.NewFile(in.cfg.Filename, src))
+	program := p.ParseProgram()
+
+	if p.ErrorsLen() != 0 {
+		return nil, errors.Join(p.Errors()...)
+	}
+
+	evaluated := in.evaluator().EvalContext(ctx, program, in.env)
+	if objErr, ok := evaluated.(*object.Error); ok {
+		return nil, objErr
+	}
+
+	return ToGo(evaluated), nil
+}
+
+// Calls the function defined as the global name with the arguments converted with ToObject
+func (in *Interpreter) Call(name string, args ...any) (any, error) {
+	return in.CallContext(context.Background(), name, args...)
+}
+
+func (in *Interpreter) CallContext(ctx context.Context, name string, args ...any) (any, error) {
+	fn, ok := in.env.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("identifier not found: %s", name)
+	}
+
+	return in.CallFunctionContext(ctx, fn, args...)
+}
+
+// Calls a function or a builtin, like the ones returned by Get, with the arguments converted with ToObject.
+// The result is converted with ToGo and an error of the call is returned as an *object.Error
+func (in *Interpreter) CallFunction(fn object.Object, args ...any) (any, error) {
+	return in.CallFunctionContext(context.Background(), fn, args...)
+}
+
+func (in *Interpreter) CallFunctionContext(ctx context.Context, fn object.Object, args ...any) (_ any, err error) {
+	defer recoverPanic(&err)
+
+	objs := make([]object.Object, len(args))
+	for i, arg := range args {
+		obj, err := ToObject(arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", i+1, err)
+		}
+
+		objs[i] = obj
+	}
+
+	ctx, cancel := in.context(ctx)
+	defer cancel()
+
+	result, err := in.evaluator().CallContext(ctx, fn, objs...)
+	if err != nil {
+		return nil, err
+	}
+
+	return ToGo(result), nil
+}
+
+// Returns an evaluator with the limits of the config
+func (in *Interpreter) evaluator() *evaluator.Evaluator {
+	e := evaluator.New()
+	e.MaxSteps = in.cfg.MaxSteps
+	e.Modules = in.modules
+	e.IO = object.IO{Out: in.cfg.Stdout, In: in.stdin}
+	if in.cfg.MaxCallDepth != 0 {
+		e.MaxCallDepth = in.cfg.MaxCallDepth
+	}
+
+	return e
+}
+
+// Returns ctx with the timeout of the config
+func (in *Interpreter) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if in.cfg.Timeout > 0 {
+		return context.WithTimeout(ctx, in.cfg.Timeout)
+	}
+
+	return ctx, func() {}
+}
+
+// Reports a panic of the interpreter as an error so it doesn't take down the host process
+func recoverPanic(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("internal interpreter error: %v", r)
+	}
+}
+
+// Defines a global with the value converted with ToObject, a func becomes a builtin like in RegisterBuiltin
+func (in *Interpreter) Set(name string, value any) error {
+	if reflect.ValueOf(value).Kind() == reflect.Func {
+		return in.RegisterBuiltin(name, value)
+	}
+
+	obj, err := ToObject(value)
+	if err != nil {
+		return fmt.Errorf("cannot set %s: %w", name, err)
+	}
+
+	in.env.Set(name, obj)
+	return nil
+}
+
+// Returns the value of a global converted with ToGo
+func (in *Interpreter) Get(name string) (any, bool) {
+	obj, ok := in.env.Get(name)
+	if !ok {
+		return nil, false
+	}
+
+	return ToGo(obj), true
+}
+
+// Defines a global builtin function called name.
+//
+// fn is either an object.BuiltInFunction, which gets the objects as they are, or any Go func whose arguments and result
+// are converted. A Go func may take a context.Context or an object.Runtime as its first parameter and may return an error
+// as its last result, which stops the program like any other error
+func (in *Interpreter) RegisterBuiltin(name string, fn any) error {
+	switch fn := fn.(type) {
+	case object.BuiltInFunction:
+		in.env.Set(name, &object.BuiltIn{Fn: fn})
+		return nil
+	case func(object.Runtime, ...object.Object) object.Object:
+		in.env.Set(name, &object.BuiltIn{Fn: fn})
+		return nil
+	}
+
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return fmt.Errorf("cannot register %s: %T is not a function", name, fn)
+	}
+
+	builtin, err := wrapFunc(name, v)
+	if err != nil {
+		return fmt.Errorf("cannot register %s: %w", name, err)
+	}
+
+	in.env.Set(name, builtin)
+	return nil
+}
@@ -0,0 +1,365 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dyxgou/parser/src/object"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"1 + 2", int64(3)},
+		{"1.5 * 2", 3.0},
+		{`"a" + "b"`, "ab"},
+		{"1 < 2", true},
+		{"[1, \"two\", [3]]", []any{int64(1), "two", []any{int64(3)}}},
+		{`{"a": 1, "b": [true]}`, map[string]any{"a": int64(1), "b": []any{true}}},
+		{`{1: "one", true: "yes"}`, map[any]any{int64(1): "one", true: "yes"}},
+		{"let x = 1;", nil},
+	}
+
+	for _, tt := range tests {
+		in := New()
+
+		result, err := in.Run(tt.input)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", tt.input, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("%q: expected=%#v. got=%#v", tt.input, tt.expected, result)
+		}
+	}
+}
+
+func TestRunKeepsGlobals(t *testing.T) {
+	in := New()
+
+	if _, err := in.Run("let add = fn(a, b) { a + b }; let x = 2;"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	result, err := in.Run("add(x, 3)")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if result != int64(5) {
+		t.Errorf("expected=5. got=%#v", result)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	in := NewWithConfig(Config{Filename: "script.dy"})
+
+	_, err := in.Run("let = 1;")
+	if err == nil || !strings.Contains(err.Error(), "script.dy:1:5") {
+		t.Errorf("expected a parser error at script.dy:1:5. got=%v", err)
+	}
+
+	_, err = in.Run("1 / 0")
+	var objErr *object.Error
+	if !errors.As(err, &objErr) {
+		t.Fatalf("expected an *object.Error. got=%T (%v)", err, err)
+	}
+
+	if objErr.Message != "division by zero: 1 / 0" {
+		t.Errorf("wrong message. got=%q", objErr.Message)
+	}
+
+	if objErr.Pos.String() != "script.dy:1:1" {
+		t.Errorf("wrong position. got=%s", objErr.Pos)
+	}
+}
+
+func TestRunLimits(t *testing.T) {
+	in := NewWithConfig(Config{MaxSteps: 100})
+
+	_, err := in.Run("let loop = fn() { loop() }; loop()")
+	if !errors.Is(err, object.ErrBudgetExceeded) {
+		t.Errorf("expected ErrBudgetExceeded. got=%v", err)
+	}
+
+	// the budget is for every run
+	if _, err := in.Run("1 + 1"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = New().RunContext(ctx, "1")
+	if !errors.Is(err, object.ErrCancelled) {
+		t.Errorf("expected ErrCancelled. got=%v", err)
+	}
+
+	_, err = NewWithConfig(Config{MaxCallDepth: 5}).Run("let f = fn(n) { 1 + f(n + 1) }; f(0)")
+	if err == nil || err.Error() != "maximum call depth of 5 exceeded" {
+		t.Errorf("expected a call depth error. got=%v", err)
+	}
+}
+
+func TestSetGet(t *testing.T) {
+	in := New()
+
+	values := map[string]any{
+		"count":  42,
+		"ratio":  0.5,
+		"name":   "dyxgou",
+		"ok":     true,
+		"nums":   []int{1, 2, 3},
+		"config": map[string]any{"debug": false, "level": uint8(3)},
+	}
+
+	for name, value := range values {
+		if err := in.Set(name, value); err != nil {
+			t.Fatalf("Set(%q) error: %s", name, err)
+		}
+	}
+
+	result, err := in.Run(`let total = count + len(nums) + config["level"]; name + "!"`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if result != "dyxgou!" {
+		t.Errorf("expected=%q. got=%#v", "dyxgou!", result)
+	}
+
+	tests := []struct {
+		name     string
+		expected any
+	}{
+		{"total", int64(48)},
+		{"ratio", 0.5},
+		{"ok", true},
+		{"nums", []any{int64(1), int64(2), int64(3)}},
+		{"config", map[string]any{"debug": false, "level": int64(3)}},
+	}
+
+	for _, tt := range tests {
+		got, ok := in.Get(tt.name)
+		if !ok {
+			t.Errorf("Get(%q) not found", tt.name)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Get(%q) expected=%#v. got=%#v", tt.name, tt.expected, got)
+		}
+	}
+
+	if _, ok := in.Get("missing"); ok {
+		t.Errorf("Get(\"missing\") expected to not be found")
+	}
+
+	if err := in.Set("ch", make(chan int)); err == nil {
+		t.Errorf("Set of a channel expected an error")
+	}
+}
+
+func TestRegisterBuiltin(t *testing.T) {
+	in := New()
+
+	register := map[string]any{
+		"double": func(n int) int { return n * 2 },
+		"join":   strings.Join,
+		"sum": func(nums ...float64) float64 {
+			var total float64
+			for _, n := range nums {
+				total += n
+			}
+
+			return total
+		},
+		"fail": func(msg string) (string, error) {
+			if msg != "" {
+				return "", errors.New(msg)
+			}
+
+			return "ok", nil
+		},
+		"keys": func(m map[string]int) int { return len(m) },
+		"deadline": func(ctx context.Context) bool {
+			_, ok := ctx.Deadline()
+			return ok
+		},
+		"raw": object.BuiltInFunction(func(_ object.Runtime, args ...object.Object) object.Object {
+			return &object.Integer{Value: int64(len(args))}
+		}),
+		"noop": func() {},
+		"twice": func(rt object.Runtime, f object.Object, x int) object.Object {
+			return rt.Apply(f, rt.Apply(f, &object.Integer{Value: int64(x)}))
+		},
+	}
+
+	for name, fn := range register {
+		if err := in.RegisterBuiltin(name, fn); err != nil {
+			t.Fatalf("RegisterBuiltin(%q) error: %s", name, err)
+		}
+	}
+
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"double(21)", int64(42)},
+		{`join(["a", "b"], "-")`, "a-b"},
+		{"sum(1, 2.5, 3)", 6.5},
+		{"sum()", 0.0},
+		{`fail("")`, "ok"},
+		{`keys({"a": 1, "b": 2})`, int64(2)},
+		{"deadline()", false},
+		{`raw(1, "a", [])`, int64(3)},
+		{"noop()", nil},
+		{"twice(fn(x) { x * 3 }, 2)", int64(18)},
+	}
+
+	for _, tt := range tests {
+		result, err := in.Run(tt.input)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", tt.input, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("%q: expected=%#v. got=%#v", tt.input, tt.expected, result)
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{`fail("boom")`, "boom"},
+		{"double(1, 2)", "function `double` expected 1 arguments. got=2"},
+		{`double("a")`, "argument 1 to `double`: expected int. got=STRING"},
+		{`sum(1, "a")`, "argument 2 to `sum`: expected float64. got=STRING"},
+		{`keys({"a": "b"})`, "argument 1 to `keys`: expected int. got=STRING"},
+	}
+
+	for _, tt := range errorTests {
+		_, err := in.Run(tt.input)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%q: expected error %q. got=%v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestRegisterBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		fn       any
+		expected string
+	}{
+		{42, "cannot register f: int is not a function"},
+		{func() (int, int) { return 0, 0 }, "cannot register f: second result of function f must be an error. got=int"},
+		{func() (int, int, error) { return 0, 0, nil }, "cannot register f: function f returns 3 results, at most 2 are supported"},
+	}
+
+	for _, tt := range tests {
+		err := New().RegisterBuiltin("f", tt.fn)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("expected error %q. got=%v", tt.expected, err)
+		}
+	}
+}
+
+func TestGoErrorCause(t *testing.T) {
+	errNotFound := errors.New("not found")
+
+	in := New()
+	in.Set("find", func(key string) (int, error) {
+		return 0, fmt.Errorf("%s: %w", key, errNotFound)
+	})
+
+	_, err := in.Run(`find("user")`)
+	if !errors.Is(err, errNotFound) {
+		t.Errorf("expected the error of the Go function. got=%v", err)
+	}
+}
+
+func TestCall(t *testing.T) {
+	in := New()
+
+	_, err := in.Run(`
+let handler = fn(req) { req["method"] + " " + req["path"] };
+let scale = fn(nums, k) { [nums[0] * k, nums[1] * k] };
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	result, err := in.Call("handler", map[string]string{"method": "GET", "path": "/"})
+	if err != nil || result != "GET /" {
+		t.Errorf("expected=%q. got=%#v (%v)", "GET /", result, err)
+	}
+
+	result, err = in.Call("scale", []float64{1, 2.5}, 2)
+	if err != nil || !reflect.DeepEqual(result, []any{2.0, 5.0}) {
+		t.Errorf("expected=[2 5]. got=%#v (%v)", result, err)
+	}
+
+	fn, _ := in.Get("handler")
+	if _, err := in.CallFunction(fn.(object.Object), 1); err == nil || err.Error() != "index operator not supported: INTEGER" {
+		t.Errorf("expected an index error. got=%v", err)
+	}
+
+	if _, err := in.Call("missing"); err == nil || err.Error() != "identifier not found: missing" {
+		t.Errorf("expected identifier not found. got=%v", err)
+	}
+
+	if _, err := in.Call("handler", make(chan int)); err == nil || err.Error() != "argument 1: cannot convert chan int to an object" {
+		t.Errorf("expected a conversion error. got=%v", err)
+	}
+
+	in.RegisterBuiltin("upper", strings.ToUpper)
+	if result, err := in.Call("upper", "abc"); err != nil || result != "ABC" {
+		t.Errorf("expected=%q. got=%#v (%v)", "ABC", result, err)
+	}
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "lib.lang"), []byte("let double = fn(x) { x * 2 };"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	in := NewWithConfig(Config{Filename: filepath.Join(dir, "main.lang")})
+
+	if _, err := in.Run(`let lib = import("lib.lang");`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the module is loaded once for all the runs
+	result, err := in.Run(`lib == import("lib.lang") && lib.double(4) == 8`)
+	if err != nil || result != true {
+		t.Errorf("expected=true. got=%#v (%v)", result, err)
+	}
+}
+
+func TestRunIO(t *testing.T) {
+	var out strings.Builder
+	in := NewWithConfig(Config{Stdout: &out, Stdin: strings.NewReader("1\n2\n")})
+
+	// the runs share the input, the second one reads what the first one left
+	for range 2 {
+		if _, err := in.Run(`println("got ", readline())`); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if s := out.String(); s != "got 1\ngot 2\n" {
+		t.Errorf("wrong output. got=%q", s)
+	}
+}
@@ -9,25 +9,84 @@ import (
 
 const backSlash = '\\'
 
+// ErrorHandler receives the errors found while reading the input
+type ErrorHandler func(pos token.Position, msg string)
+
+// The messages of the errors given to the ErrorHandler, both mean the input ended too soon
+const (
+	ErrUnterminatedString  = "unterminated string"
+	ErrUnterminatedComment = "unterminated block comment"
+)
+
 type Lexer struct {
+	filename     string
 	input        string
 	position     int // Points to the position of the last read
 	readPosition int // Points to the reading position
 	ch           byte
+
+	line   int // line of the last read
+	column int // column of the last read
+
+	onError ErrorHandler
 }
 
 func New(input string) *Lexer {
+	return NewFile("", input)
+}
+
+// Creates a lexer whose token positions are reported inside the given file
+func NewFile(filename, input string) *Lexer {
 	l := &Lexer{
+		filename:     filename,
 		input:        input,
 		position:     0,
 		readPosition: 0,
+		line:         1,
 	}
 
 	l.readChar()
 	return l
 }
 
+func (l *Lexer) Filename() string {
+	return l.filename
+}
+
+func (l *Lexer) Input() string {
+	return l.input
+}
+
+// Sets the function that receives the errors, without one they are logged
+func (l *Lexer) SetErrorHandler(h ErrorHandler) {
+	l.onError = h
+}
+
+func (l *Lexer) error(pos token.Position, msg string) {
+	if l.onError == nil {
+		slog.Warn("tokenizing", "pos", pos.String(), "warn", msg)
+		return
+	}
+
+	l.onError(pos, msg)
+}
+
+func (l *Lexer) pos() token.Position {
+	return token.Position{
+		Filename: l.filename,
+		Offset:   l.position,
+		Line:     l.line,
+		Column:   l.column,
+	}
+}
+
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+	l.column++
+
 	if l.readPosition >= len(l.input) {
 		l.ch = byte(token.EOF)
 	} else {
@@ -53,10 +112,78 @@ func (l *Lexer) skipWhitespace() {
 
 }
 
+// Skips the whitespace and the comments before the next token, returning the comments
+func (l *Lexer) skipTrivia() []token.Comment {
+	var comments []token.Comment
+
+	for {
+		l.skipWhitespace()
+
+		if l.ch != '/' || (l.peekChar() != '/' && l.peekChar() != '*') {
+			return comments
+		}
+
+		pos := l.pos()
+		if l.peekChar() == '/' {
+			l.skipLineComment()
+		} else {
+			l.skipBlockComment(pos)
+		}
+
+		comments = append(comments, token.Comment{
+			Text: l.input[pos.Offset:l.position],
+			Pos:  pos,
+			End:  l.pos(),
+		})
+	}
+}
+
+// Skips a // comment up to the end of the line, the new line isn't part of it
+func (l *Lexer) skipLineComment() {
+	for l.ch != '\n' && l.ch != byte(token.EOF) {
+		l.readChar()
+	}
+}
+
+// Skips a /* */ comment, the block comments inside it must be closed too
+func (l *Lexer) skipBlockComment(pos token.Position) {
+	depth := 0
+
+	for {
+		switch {
+		case l.ch == byte(token.EOF):
+			l.error(pos, ErrUnterminatedComment)
+			return
+		case l.ch == '/' && l.peekChar() == '*':
+			depth++
+			l.readChar()
+		case l.ch == '*' && l.peekChar() == '/':
+			depth--
+			l.readChar()
+		}
+
+		l.readChar()
+
+		if depth == 0 {
+			return
+		}
+	}
+}
+
 func (l *Lexer) NextToken() token.Token {
-	var t token.Token
+	comments := l.skipTrivia()
 
-	l.skipWhitespace()
+	pos := l.pos()
+	t := l.readToken()
+	t.Pos = pos
+	t.End = l.pos()
+	t.Comments = comments
+
+	return t
+}
+
+func (l *Lexer) readToken() token.Token {
+	var t token.Token
 
 	switch l.ch {
 	default:
@@ -65,8 +192,7 @@ func (l *Lexer) NextToken() token.Token {
 			t.Kind = token.LookupIdent(t.Literal)
 			return t
 		} else if isDigit(l.ch) {
-			t.Literal = l.readNumber()
-			t.Kind = token.INT
+			t.Literal, t.Kind = l.readNumber()
 			return t
 		} else {
 			t = token.New(token.ILLEGAL, string(l.ch))
@@ -96,18 +222,30 @@ func (l *Lexer) NextToken() token.Token {
 		t = token.New(token.SEMI, string(l.ch))
 	case ':':
 		t = token.New(token.COLON, string(l.ch))
+	case '.':
+		if isDigit(l.peekChar()) {
+			t.Literal, t.Kind = l.readNumber()
+			return t
+		}
+		t = token.New(token.DOT, string(l.ch))
 	case '+':
-		t = token.New(token.PLUS, string(l.ch))
+		t = l.readOperatorAssign(token.PLUS, token.PLUS_ASSIGN)
 	case '-':
-		t = token.New(token.MINUS, string(l.ch))
+		t = l.readOperatorAssign(token.MINUS, token.MINUS_ASSIGN)
 	case '*':
-		t = token.New(token.MULTIPLICATION, string(l.ch))
+		t = l.readOperatorAssign(token.MULTIPLICATION, token.MULTIPLICATION_ASSIGN)
 	case '/':
-		t = token.New(token.DIVISION, string(l.ch))
+		t = l.readOperatorAssign(token.DIVISION, token.DIVISION_ASSIGN)
+	case '%':
+		t = l.readOperatorAssign(token.MODULO, token.MODULO_ASSIGN)
 	case '<':
-		t = token.New(token.LESS, string(l.ch))
+		t = l.readOperatorAssign(token.LESS, token.LESS_EQUAL)
 	case '>':
-		t = token.New(token.GREATER, string(l.ch))
+		t = l.readOperatorAssign(token.GREATER, token.GREATER_EQUAL)
+	case '&':
+		t = l.readDoubleOperator(token.AND)
+	case '|':
+		t = l.readDoubleOperator(token.OR)
 	case '"':
 		s, k := l.readString()
 		t = token.New(k, s)
@@ -119,13 +257,38 @@ func (l *Lexer) NextToken() token.Token {
 		}
 		t = token.New(token.NOT, string(l.ch))
 	case byte(token.EOF):
-		t = token.New(token.EOF, "")
+		// don't move past the end so the EOF token has an empty span
+		return token.New(token.EOF, "")
 	}
 
 	l.readChar()
 	return t
 }
 
+// Reads an operator that becomes another one when it is followed by "=", like "+=" or "<="
+func (l *Lexer) readOperatorAssign(op, assign token.TokenKind) token.Token {
+	if ch := l.peekChar(); ch == '=' {
+		t := token.New(assign, getCompositeString(l.ch, ch))
+		l.readChar()
+
+		return t
+	}
+
+	return token.New(op, string(l.ch))
+}
+
+// Reads an operator made of the current character twice like "&&", a single one is illegal
+func (l *Lexer) readDoubleOperator(k token.TokenKind) token.Token {
+	if ch := l.peekChar(); ch == l.ch {
+		t := token.New(k, getCompositeString(l.ch, ch))
+		l.readChar()
+
+		return t
+	}
+
+	return token.New(token.ILLEGAL, string(l.ch))
+}
+
 func (l *Lexer) readIdentifier() string {
 	pos := l.position
 	for isLetter(l.ch) {
@@ -135,14 +298,44 @@ func (l *Lexer) readIdentifier() string {
 	return l.input[pos:l.position]
 }
 
-func (l *Lexer) readNumber() string {
+// Reads an integer or a float like 1.5, .5 or 1e10
+func (l *Lexer) readNumber() (string, token.TokenKind) {
 	pos := l.position
+	kind := token.INT
 
-	for isDigit(l.ch) {
+	l.readDigits()
+
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		kind = token.FLOAT
 		l.readChar()
+		l.readDigits()
 	}
 
-	return l.input[pos:l.position]
+	if l.ch == 'e' || l.ch == 'E' {
+		// the exponent needs digits, otherwise the e starts the next token
+		exp := l.readPosition
+		if exp < len(l.input) && (l.input[exp] == '+' || l.input[exp] == '-') {
+			exp++
+		}
+
+		if exp < len(l.input) && isDigit(l.input[exp]) {
+			kind = token.FLOAT
+			for l.readPosition < exp {
+				l.readChar()
+			}
+
+			l.readChar()
+			l.readDigits()
+		}
+	}
+
+	return l.input[pos:l.position], kind
+}
+
+func (l *Lexer) readDigits() {
+	for isDigit(l.ch) {
+		l.readChar()
+	}
 }
 
 func (l *Lexer) peekChar() byte {
@@ -154,6 +347,7 @@ func (l *Lexer) peekChar() byte {
 }
 
 func (l *Lexer) readString() (string, token.TokenKind) {
+	pos := l.pos()
 	var sb strings.Builder
 
 	for {
@@ -171,7 +365,12 @@ func (l *Lexer) readString() (string, token.TokenKind) {
 			continue
 		}
 
-		if l.ch == '"' || l.ch == byte(token.EOF) {
+		if l.ch == '"' {
+			break
+		}
+
+		if l.ch == byte(token.EOF) {
+			l.error(pos, ErrUnterminatedString)
 			break
 		}
 
@@ -23,7 +23,7 @@ let add = fn(x, y) {
 x + y;
 };
 let result = add(five, ten);
-!-/*+5;
+!-/ *+5;
 5 < 10 > 5;
 
 if (7 < 10) {
@@ -107,20 +107,12 @@ if (7 < 10) {
 		{token.SEMI, ";"},
 		{token.RBRACE, "}"},
 		{token.STRING, "foobar"},
-		{token.SEMI, ";"},
 		{token.STRING, "foo bar"},
-		{token.SEMI, ";"},
 		{token.LBRACKET, "["},
 		{token.INT, "1"},
 		{token.COMMA, ","},
 		{token.INT, "2"},
 		{token.RBRACKET, "]"},
-		{token.SEMI, ";"},
-		{token.LBRACE, "{"},
-		{token.STRING, "foo"},
-		{token.COLON, ":"},
-		{token.STRING, "bar"},
-		{token.RBRACE, "}"},
 		{token.EOF, ""},
 	}
 
@@ -143,7 +135,9 @@ if (7 < 10) {
 
 func TestDoubleToken(t *testing.T) {
 	input := `10 == 10; 
-  9 != 10;`
+  9 != 10;
+  x += 1; x -= 1; x *= 2; x /= 2;
+  a <= b >= c && d || e % f; x %= 2;`
 
 	tests := []struct {
 		expectedKind    token.TokenKind
@@ -157,6 +151,38 @@ func TestDoubleToken(t *testing.T) {
 		{expectedKind: token.NOT_EQUAL, expectedLiteral: "!="},
 		{expectedKind: token.INT, expectedLiteral: "10"},
 		{expectedKind: token.SEMI, expectedLiteral: ";"},
+		{expectedKind: token.IDENT, expectedLiteral: "x"},
+		{expectedKind: token.PLUS_ASSIGN, expectedLiteral: "+="},
+		{expectedKind: token.INT, expectedLiteral: "1"},
+		{expectedKind: token.SEMI, expectedLiteral: ";"},
+		{expectedKind: token.IDENT, expectedLiteral: "x"},
+		{expectedKind: token.MINUS_ASSIGN, expectedLiteral: "-="},
+		{expectedKind: token.INT, expectedLiteral: "1"},
+		{expectedKind: token.SEMI, expectedLiteral: ";"},
+		{expectedKind: token.IDENT, expectedLiteral: "x"},
+		{expectedKind: token.MULTIPLICATION_ASSIGN, expectedLiteral: "*="},
+		{expectedKind: token.INT, expectedLiteral: "2"},
+		{expectedKind: token.SEMI, expectedLiteral: ";"},
+		{expectedKind: token.IDENT, expectedLiteral: "x"},
+		{expectedKind: token.DIVISION_ASSIGN, expectedLiteral: "/="},
+		{expectedKind: token.INT, expectedLiteral: "2"},
+		{expectedKind: token.SEMI, expectedLiteral: ";"},
+		{expectedKind: token.IDENT, expectedLiteral: "a"},
+		{expectedKind: token.LESS_EQUAL, expectedLiteral: "<="},
+		{expectedKind: token.IDENT, expectedLiteral: "b"},
+		{expectedKind: token.GREATER_EQUAL, expectedLiteral: ">="},
+		{expectedKind: token.IDENT, expectedLiteral: "c"},
+		{expectedKind: token.AND, expectedLiteral: "&&"},
+		{expectedKind: token.IDENT, expectedLiteral: "d"},
+		{expectedKind: token.OR, expectedLiteral: "||"},
+		{expectedKind: token.IDENT, expectedLiteral: "e"},
+		{expectedKind: token.MODULO, expectedLiteral: "%"},
+		{expectedKind: token.IDENT, expectedLiteral: "f"},
+		{expectedKind: token.SEMI, expectedLiteral: ";"},
+		{expectedKind: token.IDENT, expectedLiteral: "x"},
+		{expectedKind: token.MODULO_ASSIGN, expectedLiteral: "%="},
+		{expectedKind: token.INT, expectedLiteral: "2"},
+		{expectedKind: token.SEMI, expectedLiteral: ";"},
 	}
 
 	l := New(input)
@@ -204,3 +230,204 @@ func TestTokenizeString(t *testing.T) {
 		}
 	}
 }
+
+func TestTokenPosition(t *testing.T) {
+	input := `let x = 5;
+  x + "a
+b";
+{"foo": "bar"}`
+
+	tests := []struct {
+		expectedLiteral string
+		line, column    int
+		endLine, endCol int
+	}{
+		{"let", 1, 1, 1, 4},
+		{"x", 1, 5, 1, 6},
+		{"=", 1, 7, 1, 8},
+		{"5", 1, 9, 1, 10},
+		{";", 1, 10, 1, 11},
+		{"x", 2, 3, 2, 4},
+		{"+", 2, 5, 2, 6},
+		{"a\nb", 2, 7, 3, 3},
+		{";", 3, 3, 3, 4},
+		{"{", 4, 1, 4, 2},
+		{"foo", 4, 2, 4, 7},
+		{":", 4, 7, 4, 8},
+		{"bar", 4, 9, 4, 14},
+		{"}", 4, 14, 4, 15},
+		{"", 4, 15, 4, 15},
+	}
+
+	l := NewFile("main.lang", input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Pos.Filename != "main.lang" {
+			t.Errorf("tests[%d] - filename wrong. got=%q", i, tok.Pos.Filename)
+		}
+
+		if tok.Pos.Line != tt.line || tok.Pos.Column != tt.column {
+			t.Errorf("tests[%d] - pos wrong. expected=%d:%d, got=%d:%d",
+				i, tt.line, tt.column, tok.Pos.Line, tok.Pos.Column)
+		}
+
+		if tok.End.Line != tt.endLine || tok.End.Column != tt.endCol {
+			t.Errorf("tests[%d] - end wrong. expected=%d:%d, got=%d:%d",
+				i, tt.endLine, tt.endCol, tok.End.Line, tok.End.Column)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// leading
+let x = 5; // trailing
+/* block /* nested */ still comment */ x / 2
+/* last */`
+
+	tests := []struct {
+		expectedKind     token.TokenKind
+		expectedLiteral  string
+		expectedComments []string
+	}{
+		{token.LET, "let", []string{"// leading"}},
+		{token.IDENT, "x", nil},
+		{token.ASSIGN, "=", nil},
+		{token.INT, "5", nil},
+		{token.SEMI, ";", nil},
+		{token.IDENT, "x", []string{"// trailing", "/* block /* nested */ still comment */"}},
+		{token.DIVISION, "/", nil},
+		{token.INT, "2", nil},
+		{token.EOF, "", []string{"/* last */"}},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Kind != tt.expectedKind || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - wrong token. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if len(tok.Comments) != len(tt.expectedComments) {
+			t.Fatalf("tests[%d] - wrong number of comments. expected=%d, got=%d", i, len(tt.expectedComments), len(tok.Comments))
+		}
+
+		for j, c := range tok.Comments {
+			if c.Text != tt.expectedComments[j] {
+				t.Errorf("tests[%d] - comment[%d] expected=%q, got=%q", i, j, tt.expectedComments[j], c.Text)
+			}
+		}
+	}
+}
+
+func TestUnterminatedBlockComment(t *testing.T) {
+	l := New("let x = 1;\n/* open /* nested */")
+
+	var errs []string
+	var errPos token.Position
+	l.SetErrorHandler(func(pos token.Position, msg string) {
+		errs = append(errs, msg)
+		errPos = pos
+	})
+
+	for tok := l.NextToken(); tok.Kind != token.EOF; tok = l.NextToken() {
+	}
+
+	if len(errs) != 1 || errs[0] != ErrUnterminatedComment {
+		t.Fatalf("expected one unterminated comment error. got=%v", errs)
+	}
+
+	if errPos.Line != 2 || errPos.Column != 1 {
+		t.Errorf("wrong error position. got=%s", errPos)
+	}
+}
+
+func TestUnterminatedString(t *testing.T) {
+	l := New("let s = \"open;")
+
+	var errs []string
+	var errPos token.Position
+	l.SetErrorHandler(func(pos token.Position, msg string) {
+		errs = append(errs, msg)
+		errPos = pos
+	})
+
+	for tok := l.NextToken(); tok.Kind != token.EOF; tok = l.NextToken() {
+	}
+
+	if len(errs) != 1 || errs[0] != ErrUnterminatedString {
+		t.Fatalf("expected one unterminated string error. got=%v", errs)
+	}
+
+	if errPos.Line != 1 || errPos.Column != 9 {
+		t.Errorf("wrong error position. got=%s", errPos)
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedKind    token.TokenKind
+		expectedLiteral string
+	}{
+		{"42", token.INT, "42"},
+		{"1.5", token.FLOAT, "1.5"},
+		{".5", token.FLOAT, ".5"},
+		{"1e10", token.FLOAT, "1e10"},
+		{"2.5E-3", token.FLOAT, "2.5E-3"},
+		{"3e+2", token.FLOAT, "3e+2"},
+		{"1.", token.INT, "1"},
+		{"2else", token.INT, "2"},
+	}
+
+	for _, tt := range tests {
+		tok := New(tt.input).NextToken()
+
+		if tok.Kind != tt.expectedKind || tok.Literal != tt.expectedLiteral {
+			t.Errorf("%q: expected=%d %q. got=%d %q", tt.input, tt.expectedKind, tt.expectedLiteral, tok.Kind, tok.Literal)
+		}
+	}
+}
+
+func TestImportAndMember(t *testing.T) {
+	input := `let m = import("lib.lang"); m.sum(.5)`
+
+	tests := []struct {
+		expectedKind    token.TokenKind
+		expectedLiteral string
+	}{
+		{token.LET, "let"},
+		{token.IDENT, "m"},
+		{token.ASSIGN, "="},
+		{token.IMPORT, "import"},
+		{token.LPAREN, "("},
+		{token.STRING, "lib.lang"},
+		{token.RPAREN, ")"},
+		{token.SEMI, ";"},
+		{token.IDENT, "m"},
+		{token.DOT, "."},
+		{token.IDENT, "sum"},
+		{token.LPAREN, "("},
+		{token.FLOAT, ".5"},
+		{token.RPAREN, ")"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Kind != tt.expectedKind || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d]: expected=%d %q. got=%d %q", i, tt.expectedKind, tt.expectedLiteral, tok.Kind, tok.Literal)
+		}
+	}
+}
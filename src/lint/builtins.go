@@ -0,0 +1,71 @@
+package lint
+
+import "fmt"
+
+// arity is the number of arguments a builtin takes, max is -1 when there is no limit
+type arity struct {
+	min, max int
+}
+
+// The builtins of object.Builtins by name
+var builtinArity = map[string]arity{
+	"len":         {1, 1},
+	"first":       {1, 1},
+	"last":        {1, 1},
+	"rest":        {1, 1},
+	"push":        {2, 2},
+	"pop":         {1, 1},
+	"print":       {0, -1},
+	"keys":        {1, 1},
+	"values":      {1, 1},
+	"has":         {2, 2},
+	"delete":      {2, 2},
+	"merge":       {2, -1},
+	"int":         {1, 1},
+	"float":       {1, 1},
+	"floor":       {1, 1},
+	"ceil":        {1, 1},
+	"round":       {1, 1},
+	"split":       {2, 2},
+	"join":        {2, 2},
+	"trim":        {1, 2},
+	"upper":       {1, 1},
+	"lower":       {1, 1},
+	"contains":    {2, 2},
+	"replace":     {3, 3},
+	"starts_with": {2, 2},
+	"ends_with":   {2, 2},
+	"index_of":    {2, 2},
+	"substr":      {2, 3},
+	"repeat":      {2, 2},
+	"map":         {2, 2},
+	"filter":      {2, 2},
+	"reduce":      {3, 3},
+	"sort":        {1, 2},
+	"reverse":     {1, 1},
+	"range":       {1, 3},
+	"zip":         {1, -1},
+	"any":         {1, 2},
+	"all":         {1, 2},
+	"find":        {2, 2},
+	"flatten":     {1, 1},
+	"unique":      {1, 1},
+	"println":     {0, -1},
+	"input":       {0, 1},
+	"readline":    {0, 0},
+}
+
+func (a arity) accepts(n int) bool {
+	return n >= a.min && (a.max < 0 || n <= a.max)
+}
+
+func (a arity) String() string {
+	switch {
+	case a.min == a.max:
+		return fmt.Sprintf("%d", a.min)
+	case a.max < 0:
+		return fmt.Sprintf("at least %d", a.min)
+	}
+
+	return fmt.Sprintf("%d to %d", a.min, a.max)
+}
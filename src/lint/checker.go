@@ -0,0 +1,289 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dyxgou/parser/src/ast"
+	"github.com/dyxgou/parser/src/token"
+)
+
+// binding is a name defined by a let, a parameter or the variable of a for loop
+type binding struct {
+	name  string
+	pos   token.Position
+	isLet bool
+
+	defined    bool // the checker went past its definition
+	used       bool
+	assigned   bool                 // an assignment may have replaced fn
+	redeclared bool                 // another let defines it again, so fn may not be its value
+	fn         *ast.FunctionLiteral // the function of a let, used to check the calls to it
+}
+
+// scope holds the bindings of a function or the ones of the program, the blocks don't have their own ones
+type scope struct {
+	outer    *scope
+	names    map[string]*binding
+	function bool // false for the scope of the program
+}
+
+func newScope(outer *scope, function bool) *scope {
+	return &scope{outer: outer, names: make(map[string]*binding), function: function}
+}
+
+// Returns the binding of the name and the scope defining it, nil for a builtin or an undefined name
+func (s *scope) lookup(name string) (*binding, *scope) {
+	for ; s != nil; s = s.outer {
+		if b, ok := s.names[name]; ok {
+			return b, s
+		}
+	}
+
+	return nil, nil
+}
+
+// call is a call to a function bound by a let, checked once every assignment of the program is known
+type call struct {
+	callee *binding
+	pos    token.Position
+	args   int
+}
+
+type checker struct {
+	scope *scope
+	diags []Diagnostic
+	calls []call
+}
+
+func (c *checker) report(pos token.Position, rule, format string, a ...any) {
+	c.diags = append(c.diags, Diagnostic{Pos: pos, Rule: rule, Message: fmt.Sprintf(format, a...)})
+}
+
+// Checks the body of a function, or of the program when fn is nil, in the current scope
+func (c *checker) function(fn *ast.FunctionLiteral, body []ast.Statement) {
+	if fn != nil {
+		for _, param := range fn.Params {
+			c.scope.names[param.Value()] = &binding{name: param.Value(), pos: param.Pos(), defined: true}
+		}
+	}
+
+	// the lets are seen by the whole function, a function can call another one defined after it
+	for _, stmt := range body {
+		c.declare(stmt)
+	}
+
+	c.statements(body)
+
+	if !c.scope.function {
+		return
+	}
+
+	for _, b := range c.scope.names {
+		if b.isLet && !b.used && !strings.HasPrefix(b.name, "_") {
+			c.report(b.pos, Unused, "%s is declared but never used", b.name)
+		}
+	}
+}
+
+// Adds the bindings defined by the node to the current scope, without going into the functions inside it
+func (c *checker) declare(node ast.Node) {
+	ast.Inspect(node, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.FunctionLiteral:
+			return false
+		case *ast.LetStatement:
+			fn, _ := node.Value.(*ast.FunctionLiteral)
+			c.define(node.Name, true, fn)
+		case *ast.ForStatement:
+			c.define(node.Variable, false, nil)
+		}
+
+		return true
+	})
+}
+
+func (c *checker) define(ident *ast.Identifier, isLet bool, fn *ast.FunctionLiteral) {
+	name := ident.Value()
+
+	if b, ok := c.scope.names[name]; ok {
+		// a loop can reuse the variable of another loop
+		if isLet || b.isLet {
+			c.report(ident.Pos(), Redeclared, "%s is already defined at line %d", name, b.pos.Line)
+			b.redeclared = true
+		}
+
+		return
+	}
+
+	if outer, _ := c.scope.lookup(name); outer != nil && isLet && !strings.HasPrefix(name, "_") {
+		c.report(ident.Pos(), Shadow, "%s shadows the %s defined at line %d", name, name, outer.pos.Line)
+	}
+
+	c.scope.names[name] = &binding{name: name, pos: ident.Pos(), isLet: isLet, fn: fn}
+}
+
+func (c *checker) statements(stmts []ast.Statement) {
+	var exit ast.Statement // the statement leaving the block, the ones after it never run
+	reported := false
+
+	for _, stmt := range stmts {
+		// the rest of the block is still checked, but it's reported just once
+		if exit != nil && !reported {
+			c.report(stmt.Pos(), Unreachable, "unreachable code after %s", exit.TokenLiteral())
+			reported = true
+		}
+
+		c.statement(stmt)
+
+		switch stmt.(type) {
+		case *ast.ReturnStatement, *ast.BreakStatement, *ast.ContinueStatement:
+			if exit == nil {
+				exit = stmt
+			}
+		}
+	}
+}
+
+func (c *checker) statement(stmt ast.Statement) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		c.expr(stmt.Value)
+		c.scope.names[stmt.Name.Value()].defined = true
+	case *ast.ReturnStatement:
+		c.expr(stmt.Value)
+	case *ast.ExpressionStatement:
+		c.expr(stmt.Expression)
+	case *ast.WhileStatement:
+		c.expr(stmt.Condition)
+		c.statements(stmt.Body.Statements)
+	case *ast.ForStatement:
+		c.expr(stmt.Iterable)
+		c.scope.names[stmt.Variable.Value()].defined = true
+		c.statements(stmt.Body.Statements)
+	case *ast.BlockStatement:
+		c.statements(stmt.Statements)
+	}
+}
+
+func (c *checker) expr(exp ast.Expression) {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		c.use(exp, true)
+	case *ast.PrefixExpression:
+		c.expr(exp.Right)
+	case *ast.InfixExpression:
+		c.expr(exp.Left)
+		c.expr(exp.Right)
+	case *ast.AssignExpression:
+		if ident, ok := exp.Target.(*ast.Identifier); ok {
+			// a compound assignment like += reads the variable too
+			if b := c.use(ident, exp.Token.Kind != token.ASSIGN); b != nil {
+				b.assigned = true
+			}
+		} else {
+			c.expr(exp.Target)
+		}
+
+		c.expr(exp.Value)
+	case *ast.IfExpression:
+		c.expr(exp.Condition)
+		c.statements(exp.Consequence.Statements)
+
+		if exp.Alternative != nil {
+			c.statements(exp.Alternative.Statements)
+		}
+	case *ast.FunctionLiteral:
+		c.scope = newScope(c.scope, true)
+		c.function(exp, exp.Body.Statements)
+		c.scope = c.scope.outer
+	case *ast.CallExpression:
+		c.expr(exp.Function)
+		for _, arg := range exp.Arguments {
+			c.expr(arg)
+		}
+
+		c.checkArity(exp)
+	case *ast.ArrayLiteral:
+		for _, elem := range exp.Elements {
+			c.expr(elem)
+		}
+	case *ast.HashLiteral:
+		for _, pair := range exp.Pairs {
+			c.expr(pair.Key)
+			c.expr(pair.Value)
+		}
+	case *ast.IndexExpression:
+		c.expr(exp.Left)
+		c.expr(exp.Index)
+	case *ast.SliceExpression:
+		c.expr(exp.Left)
+		if exp.Low != nil {
+			c.expr(exp.Low)
+		}
+		if exp.High != nil {
+			c.expr(exp.High)
+		}
+	case *ast.MemberExpression:
+		c.expr(exp.Object)
+	}
+}
+
+// Resolves an identifier, read tells if its value is used. Returns its binding, nil for a builtin or an undefined name
+func (c *checker) use(ident *ast.Identifier, read bool) *binding {
+	name := ident.Value()
+
+	b, s := c.scope.lookup(name)
+	if b == nil {
+		if _, ok := builtinArity[name]; !ok {
+			c.report(ident.Pos(), Undefined, "identifier not found: %s", name)
+		}
+
+		return nil
+	}
+
+	// the functions inside the scope may run after the definition, the code of the scope itself can't
+	if s == c.scope && !b.defined {
+		c.report(ident.Pos(), Undefined, "%s is used before it's defined", name)
+	}
+
+	if read {
+		b.used = true
+	}
+
+	return b
+}
+
+func (c *checker) checkArity(exp *ast.CallExpression) {
+	ident, ok := exp.Function.(*ast.Identifier)
+	if !ok {
+		return
+	}
+
+	b, _ := c.scope.lookup(ident.Value())
+	if b == nil {
+		if a, ok := builtinArity[ident.Value()]; ok && !a.accepts(len(exp.Arguments)) {
+			c.report(exp.Pos(), Arity, "wrong number of arguments for %s: want=%s, got=%d", ident.Value(), a, len(exp.Arguments))
+		}
+
+		return
+	}
+
+	if b.fn != nil {
+		c.calls = append(c.calls, call{callee: b, pos: exp.Pos(), args: len(exp.Arguments)})
+	}
+}
+
+// Checks the calls to the functions bound by a let that always hold them
+func (c *checker) checkCalls() {
+	for _, call := range c.calls {
+		b := call.callee
+		if b.assigned || b.redeclared {
+			continue
+		}
+
+		if want := len(b.fn.Params); want != call.args {
+			c.report(call.pos, Arity, "wrong number of arguments for %s: want=%d, got=%d", b.name, want, call.args)
+		}
+	}
+}
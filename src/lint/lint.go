@@ -0,0 +1,57 @@
+// Package lint finds the mistakes of a program before running it
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/dyxgou/parser/src/ast"
+	"github.com/dyxgou/parser/src/lexer"
+	"github.com/dyxgou/parser/src/parser"
+	"github.com/dyxgou/parser/src/token"
+)
+
+// The rules a diagnostic comes from
+const (
+	Undefined   = "undefined"   // a name that isn't defined where it's used
+	Arity       = "arity"       // a call with the wrong number of arguments
+	Shadow      = "shadow"      // a let hiding a binding of an enclosing function
+	Redeclared  = "redeclared"  // a let of a name already defined in the same function
+	Unused      = "unused"      // a let inside a function that is never read
+	Unreachable = "unreachable" // a statement after a return, break or continue
+)
+
+// Diagnostic is a mistake found in the program
+type Diagnostic struct {
+	Pos     token.Position
+	Rule    string
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s (%s)", d.Pos, d.Message, d.Rule)
+}
+
+// Checks the program and returns its diagnostics in the order they appear in the source
+func Program(program *ast.Program) []Diagnostic {
+	c := &checker{scope: newScope(nil, false)}
+	c.function(nil, program.Statements)
+	c.checkCalls()
+
+	slices.SortStableFunc(c.diags, func(a, b Diagnostic) int { return a.Pos.Offset - b.Pos.Offset })
+
+	return c.diags
+}
+
+// Parses and checks the source of a file, the errors of the parser are joined in the returned error
+func Source(filename, src string) ([]Diagnostic, error) {
+	p := parser.New(lexer.NewFile(filename, src))
+	program := p.ParseProgram()
+
+	if p.ErrorsLen() != 0 {
+		return nil, errors.Join(p.Errors()...)
+	}
+
+	return Program(program), nil
+}
@@ -0,0 +1,22 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/dyxgou/parser/src/ast"
+	"github.com/dyxgou/parser/src/lexer"
+	"github.com/dyxgou/parser/src/parser"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+
+	if p.ErrorsLen() != 0 {
+		t.Fatalf("%q: parser had errors: %v", input, p.Errors())
+	}
+
+	return program
+}
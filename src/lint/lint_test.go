@@ -0,0 +1,98 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/dyxgou/parser/src/object"
+)
+
+func TestProgram(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 1; let f = fn(a) { a + x }; f(x);", nil},
+		{"y + 1; let f = fn() { z };", []string{
+			"1:1: identifier not found: y (undefined)",
+			"1:23: identifier not found: z (undefined)",
+		}},
+		{"x; let x = 1;", []string{"1:1: x is used before it's defined (undefined)"}},
+		// the functions run after the lets that follow them
+		{"let f = fn() { g() }; let g = fn() { 1 }; f();", nil},
+		{"len(); len([1], 2); push([1]); merge({}); print(); range(1, 2, 3, 4);", []string{
+			"1:1: wrong number of arguments for len: want=1, got=0 (arity)",
+			"1:8: wrong number of arguments for len: want=1, got=2 (arity)",
+			"1:21: wrong number of arguments for push: want=2, got=1 (arity)",
+			"1:32: wrong number of arguments for merge: want=at least 2, got=1 (arity)",
+			"1:52: wrong number of arguments for range: want=1 to 3, got=4 (arity)",
+		}},
+		{"let f = fn(a, b) { a + b }; f(1); f(1, 2);", []string{
+			"1:29: wrong number of arguments for f: want=2, got=1 (arity)",
+		}},
+		// an assignment may change the function, and a let may replace a builtin
+		{"let f = fn(a) { a }; f = fn() { 1 }; f(); let len = fn() { 0 }; len();", nil},
+		{"let x = 1; let f = fn() { let x = 2; x }; f();", []string{
+			"1:31: x shadows the x defined at line 1 (shadow)",
+		}},
+		{"let x = 1;\nif (true) { let x = 2 }\nx;", []string{
+			"2:17: x is already defined at line 1 (redeclared)",
+		}},
+		{"for (i in [1]) { i }; for (i in [2]) { i };", nil},
+		{"let f = fn(a) { let b = 1; let _c = 2; let d = 3; d = 4; a };", []string{
+			"1:21: b is declared but never used (unused)",
+			"1:44: d is declared but never used (unused)",
+		}},
+		{"let f = fn() { let n = 0; n += 1; };", nil},
+		{"let f = fn() { return 1; print(2); print(3) };", []string{
+			"1:26: unreachable code after return (unreachable)",
+		}},
+		{"while (true) { break; y } for (i in [1]) { if (i) { continue; 1 } }", []string{
+			"1:23: unreachable code after break (unreachable)",
+			"1:23: identifier not found: y (undefined)",
+			"1:63: unreachable code after continue (unreachable)",
+		}},
+		{`let m = import("m.lang"); m.value; m.missing; {"a": 1}.a; [1, 2][0:1];`, nil},
+	}
+
+	for _, tt := range tests {
+		diags := Program(parse(t, tt.input))
+
+		if len(diags) != len(tt.expected) {
+			t.Errorf("%q: expected %d diagnostics. got=%d: %v", tt.input, len(tt.expected), len(diags), diags)
+			continue
+		}
+
+		for i, d := range diags {
+			if d.String() != tt.expected[i] {
+				t.Errorf("%q: diagnostic %d expected=%q. got=%q", tt.input, i, tt.expected[i], d.String())
+			}
+		}
+	}
+}
+
+func TestSource(t *testing.T) {
+	diags, err := Source("main.lang", "len(1, 2)")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(diags) != 1 || diags[0].Pos.Filename != "main.lang" || diags[0].Rule != Arity {
+		t.Errorf("expected an arity diagnostic in main.lang. got=%v", diags)
+	}
+
+	if _, err := Source("main.lang", "let = 1"); err == nil {
+		t.Errorf("expected the errors of the parser")
+	}
+}
+
+func TestBuiltinArity(t *testing.T) {
+	for _, b := range object.Builtins {
+		if _, ok := builtinArity[b.Name]; !ok {
+			t.Errorf("the arity of the builtin %s is unknown", b.Name)
+		}
+	}
+
+	if len(builtinArity) != len(object.Builtins) {
+		t.Errorf("expected %d builtins. got=%d", len(object.Builtins), len(builtinArity))
+	}
+}
@@ -0,0 +1,55 @@
+package lsp
+
+// builtin describes a builtin function for the hover and the completion
+type builtin struct {
+	signature string
+	doc       string
+}
+
+// The builtins of object.Builtins by name
+var builtins = map[string]builtin{
+	"len":         {"len(value)", "Returns the number of characters of a string, elements of an array or pairs of a hash."},
+	"first":       {"first(arr)", "Returns the first element of the array, NULL when it's empty."},
+	"last":        {"last(arr)", "Returns the last element of the array, NULL when it's empty."},
+	"rest":        {"rest(arr)", "Returns a new array without the first element, NULL when it's empty."},
+	"push":        {"push(arr, value)", "Adds the value at the end of the array and returns its new length."},
+	"pop":         {"pop(arr)", "Removes the last element of the array and returns it."},
+	"print":       {"print(values...)", "Writes the values one after the other."},
+	"keys":        {"keys(hash)", "Returns the keys of the hash in the order they were added."},
+	"values":      {"values(hash)", "Returns the values of the hash in the order they were added."},
+	"has":         {"has(hash, key)", "Tells if the hash has the key."},
+	"delete":      {"delete(hash, key)", "Removes the key from the hash and returns its value, NULL when it wasn't there."},
+	"merge":       {"merge(hashes...)", "Returns a new hash with the pairs of every hash, the later ones win."},
+	"int":         {"int(value)", "Converts a number or a string to an integer."},
+	"float":       {"float(value)", "Converts a number or a string to a float."},
+	"floor":       {"floor(number)", "Rounds the number down to an integer."},
+	"ceil":        {"ceil(number)", "Rounds the number up to an integer."},
+	"round":       {"round(number)", "Rounds the number to the nearest integer."},
+	"split":       {"split(s, sep)", "Splits the string around every separator."},
+	"join":        {"join(arr, sep)", "Joins the strings of the array with the separator between them."},
+	"trim":        {"trim(s, chars?)", "Removes the white space around the string, or the given characters."},
+	"upper":       {"upper(s)", "Returns the string in upper case."},
+	"lower":       {"lower(s)", "Returns the string in lower case."},
+	"contains":    {"contains(s, sub)", "Tells if the string contains sub."},
+	"replace":     {"replace(s, old, new)", "Replaces every old inside the string with new."},
+	"starts_with": {"starts_with(s, prefix)", "Tells if the string starts with the prefix."},
+	"ends_with":   {"ends_with(s, suffix)", "Tells if the string ends with the suffix."},
+	"index_of":    {"index_of(s, sub)", "Returns the index of the first character of sub inside the string, -1 when it's not there."},
+	"substr":      {"substr(s, start, length?)", "Returns the characters from start to the end, or just length of them."},
+	"repeat":      {"repeat(s, count)", "Returns the string repeated count times."},
+	"map":         {"map(arr, f)", "Returns the results of calling the function with every element."},
+	"filter":      {"filter(arr, f)", "Returns the elements for which the function returns a truthy value."},
+	"reduce":      {"reduce(arr, initial, f)", "Calls the function with the accumulated value and every element, starting with initial."},
+	"sort":        {"sort(arr, less?)", "Returns the numbers or strings sorted in increasing order, or in the order told by less."},
+	"reverse":     {"reverse(arr)", "Returns the elements in the opposite order."},
+	"range":       {"range(start?, end, step?)", "Returns the integers from start, 0 by default, up to end without it."},
+	"zip":         {"zip(arrays...)", "Returns arrays with the elements at the same index of every array, as long as the shortest one."},
+	"any":         {"any(arr, f?)", "Tells if any element, or the result of the function for it, is truthy."},
+	"all":         {"all(arr, f?)", "Tells if every element, or the result of the function for it, is truthy."},
+	"find":        {"find(arr, f)", "Returns the first element for which the function returns a truthy value, NULL when there is none."},
+	"flatten":     {"flatten(arr)", "Replaces the arrays inside the array with their elements, just one level deep."},
+	"unique":      {"unique(arr)", "Returns the elements without the repeated ones."},
+	"println":     {"println(values...)", "Writes the values one after the other followed by a new line."},
+	"input":       {"input(prompt?)", "Prints the prompt and reads a line, NULL at the end of the input."},
+	"readline":    {"readline()", "Reads a line, NULL at the end of the input."},
+}
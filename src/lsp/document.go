@@ -0,0 +1,126 @@
+package lsp
+
+import (
+	"errors"
+	"sort"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/dyxgou/parser/src/ast"
+	"github.com/dyxgou/parser/src/lexer"
+	"github.com/dyxgou/parser/src/parser"
+)
+
+// document is an open file parsed with its last text, the parser recovers from its errors so the
+// statements without them are still known
+type document struct {
+	uri        string
+	text       string
+	lineStarts []int // offset of the first byte of every line
+
+	program *ast.Program
+	errors  []error
+
+	root *scope
+	refs []reference // every identifier of the program in the order it's written
+}
+
+func newDocument(uri, text string) *document {
+	d := &document{uri: uri, text: text, lineStarts: []int{0}}
+
+	for i := range len(text) {
+		if text[i] == '\n' {
+			d.lineStarts = append(d.lineStarts, i+1)
+		}
+	}
+
+	p := parser.New(lexer.NewFile(uri, text))
+	d.program = p.ParseProgram()
+	d.errors = p.Errors()
+
+	d.root = &scope{start: 0, end: len(text)}
+	d.statements(d.root, d.program.Statements)
+
+	return d
+}
+
+// Returns the LSP position of an offset of the text
+func (d *document) position(offset int) position {
+	offset = min(max(offset, 0), len(d.text))
+
+	line := sort.Search(len(d.lineStarts), func(i int) bool { return d.lineStarts[i] > offset }) - 1
+	character := 0
+	for _, r := range d.text[d.lineStarts[line]:offset] {
+		character += utf16.RuneLen(r)
+	}
+
+	return position{Line: line, Character: character}
+}
+
+// Returns the offset of an LSP position, the positions past the end of their line are placed at its end
+func (d *document) offset(pos position) int {
+	if pos.Line < 0 {
+		return 0
+	}
+
+	if pos.Line >= len(d.lineStarts) {
+		return len(d.text)
+	}
+
+	offset := d.lineStarts[pos.Line]
+	for character := 0; character < pos.Character && offset < len(d.text); {
+		r, size := utf8.DecodeRuneInString(d.text[offset:])
+		if r == '\n' {
+			break
+		}
+
+		character += utf16.RuneLen(r)
+		offset += size
+	}
+
+	return offset
+}
+
+func (d *document) textRange(start, end int) textRange {
+	return textRange{Start: d.position(start), End: d.position(end)}
+}
+
+func (d *document) nodeRange(node ast.Node) textRange {
+	return d.textRange(node.Pos().Offset, node.End().Offset)
+}
+
+// Returns the diagnostics of the errors of the parser, each one marks the word where it was found
+func (d *document) diagnostics() []diagnostic {
+	diags := []diagnostic{}
+
+	for _, err := range d.errors {
+		var perr *parser.Error
+		if !errors.As(err, &perr) {
+			continue
+		}
+
+		start := perr.Pos.Offset
+		end := start
+		for end < len(d.text) && isIdentChar(d.text[end]) {
+			end++
+		}
+
+		if end == start && end < len(d.text) && d.text[end] != '\n' {
+			_, size := utf8.DecodeRuneInString(d.text[end:])
+			end += size
+		}
+
+		diags = append(diags, diagnostic{
+			Range:    d.textRange(start, end),
+			Severity: severityError,
+			Source:   "parser",
+			Message:  perr.Msg,
+		})
+	}
+
+	return diags
+}
+
+func isIdentChar(ch byte) bool {
+	return ch == '_' || 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || '0' <= ch && ch <= '9'
+}
@@ -0,0 +1,228 @@
+package lsp
+
+import "testing"
+
+func TestPositions(t *testing.T) {
+	d := newDocument("file:///a.lang", "let a = 1;\nlet é = \"😀\"; b\n")
+
+	tests := []struct {
+		offset int
+		pos    position
+	}{
+		{0, position{0, 0}},
+		{10, position{0, 10}},
+		{11, position{1, 0}},
+		{17, position{1, 5}},  // after é, two bytes and one UTF-16 unit
+		{25, position{1, 11}}, // after 😀, four bytes and two UTF-16 units
+		{30, position{2, 0}},
+	}
+
+	for _, tt := range tests {
+		if pos := d.position(tt.offset); pos != tt.pos {
+			t.Errorf("position(%d): expected=%v. got=%v", tt.offset, tt.pos, pos)
+		}
+
+		if offset := d.offset(tt.pos); offset != tt.offset {
+			t.Errorf("offset(%v): expected=%d. got=%d", tt.pos, tt.offset, offset)
+		}
+	}
+
+	// past the end of a line or of the document
+	if offset := d.offset(position{0, 99}); offset != 10 {
+		t.Errorf("expected the end of the line. got=%d", offset)
+	}
+
+	if offset := d.offset(position{9, 0}); offset != len(d.text) {
+		t.Errorf("expected the end of the document. got=%d", offset)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	d := newDocument("file:///a.lang", "let x = 1;\nlet = 2;\nlet y = (1;\nlet z = x + y;")
+
+	diags := d.diagnostics()
+	if len(diags) != 2 {
+		t.Fatalf("expected 2 diagnostics. got=%d: %v", len(diags), diags)
+	}
+
+	expected := []struct {
+		rng     textRange
+		message string
+	}{
+		{textRange{position{1, 4}, position{1, 5}}, `expected next token to be "variable_name" got="="`},
+		{textRange{position{2, 10}, position{2, 11}}, `expected next token to be ")" got=";"`},
+	}
+
+	for i, tt := range expected {
+		if diags[i].Range != tt.rng || diags[i].Message != tt.message || diags[i].Severity != severityError {
+			t.Errorf("diagnostic %d: expected=%v %q. got=%v %q", i, tt.rng, tt.message, diags[i].Range, diags[i].Message)
+		}
+	}
+
+	// the statements after the errors are still known
+	if def := d.root.lookup("z", len(d.text)); def == nil {
+		t.Errorf("expected the definition of z")
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	d := newDocument("file:///a.lang", `let x = 1;
+let add = fn(a, b) {
+  let x = a + b;
+  x + later()
+};
+let later = fn() { x };
+for (i in [1]) { add(i, x) }
+let x = 2;
+x;
+m.x;
+len([]);`)
+
+	tests := []struct {
+		name   string
+		offset int // of the identifier
+		def    int // offset of the definition, -1 for none
+	}{
+		{"parameter", find(t, d, "a + b", 1), find(t, d, "a, b", 1)},
+		{"let inside the function", find(t, d, "x + later", 1), find(t, d, "x = a", 1)},
+		{"let after its use", find(t, d, "later()", 1), find(t, d, "later =", 1)},
+		{"enclosing let", find(t, d, "x }", 1), find(t, d, "x = 1", 1)},
+		{"loop variable", find(t, d, "i, x", 1), find(t, d, "i in", 1)},
+		{"redefined let", find(t, d, "x;", 1), find(t, d, "x = 2", 1)},
+		{"the definition itself", find(t, d, "add =", 1), find(t, d, "add =", 1)},
+		{"keyword", 0, -1},
+		{"builtin", find(t, d, "len", 1), -1},
+		{"property of a module", find(t, d, "m.x", 1) + 2, -1},
+	}
+
+	for _, tt := range tests {
+		loc, ok := d.definition(tt.offset).(location)
+
+		if tt.def < 0 {
+			if ok {
+				t.Errorf("%s: expected no definition. got=%v", tt.name, loc)
+			}
+			continue
+		}
+
+		if !ok {
+			t.Errorf("%s: expected a definition", tt.name)
+			continue
+		}
+
+		if loc.URI != d.uri || loc.Range.Start != d.position(tt.def) {
+			t.Errorf("%s: expected=%v. got=%v", tt.name, d.position(tt.def), loc.Range.Start)
+		}
+	}
+}
+
+func TestHover(t *testing.T) {
+	d := newDocument("file:///a.lang", "let add = fn(a, b) { a + b };\nlet n = len([1]);\nadd(n, 2); missing")
+
+	tests := []struct {
+		offset   int
+		expected string // empty for no hover
+	}{
+		{find(t, d, "len", 1) + 1, "```\nlen(value)\n```\n" + builtins["len"].doc},
+		{find(t, d, "add(", 1), "```\nlet add = fn(a, b)\n```"},
+		{find(t, d, "n,", 1), "```\nlet n\n```"},
+		{find(t, d, "a +", 1), "```\nparameter a\n```"},
+		{find(t, d, "missing", 1), ""},
+		{find(t, d, "[1]", 1), ""},
+	}
+
+	for _, tt := range tests {
+		h, ok := d.hover(tt.offset).(hover)
+
+		if tt.expected == "" {
+			if ok {
+				t.Errorf("%d: expected no hover. got=%q", tt.offset, h.Contents.Value)
+			}
+			continue
+		}
+
+		if !ok || h.Contents.Value != tt.expected {
+			t.Errorf("%d: expected=%q. got=%q", tt.offset, tt.expected, h.Contents.Value)
+		}
+	}
+}
+
+func TestSymbols(t *testing.T) {
+	d := newDocument("file:///a.lang", "let x = 1;\nlet f = fn(a) {\n  let y = a;\n  let g = fn() { 1 };\n  y\n};\nfor (i in [1]) { let z = i }")
+
+	symbols := d.symbols(d.root)
+
+	expected := []struct {
+		name, detail string
+		kind         int
+		children     []string
+	}{
+		{"x", "", symbolVariable, nil},
+		{"f", "fn(a)", symbolFunction, []string{"y", "g"}},
+		{"z", "", symbolVariable, nil},
+	}
+
+	if len(symbols) != len(expected) {
+		t.Fatalf("expected %d symbols. got=%d: %v", len(expected), len(symbols), symbols)
+	}
+
+	for i, tt := range expected {
+		s := symbols[i]
+		if s.Name != tt.name || s.Detail != tt.detail || s.Kind != tt.kind || len(s.Children) != len(tt.children) {
+			t.Errorf("symbol %d: expected=%v. got=%v", i, tt, s)
+			continue
+		}
+
+		for j, child := range tt.children {
+			if s.Children[j].Name != child {
+				t.Errorf("symbol %d child %d: expected=%s. got=%s", i, j, child, s.Children[j].Name)
+			}
+		}
+	}
+
+	if r := symbols[1].Range; r.Start != (position{1, 0}) || r.End != (position{5, 1}) {
+		t.Errorf("wrong range of f: %v", r)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	d := newDocument("file:///a.lang", "let len = 1;\nlet f = fn(a) {\n  let b = a;\n  \n};\nlet c = 2;")
+
+	items := d.completion(find(t, d, "\n};", 1))
+
+	labels := make(map[string]completionItem)
+	var order []string
+	for _, item := range items {
+		labels[item.Label] = item
+		order = append(order, item.Label)
+	}
+
+	for _, name := range []string{"a", "b", "f", "c", "len", "map", "println"} {
+		if _, ok := labels[name]; !ok {
+			t.Errorf("expected %s in the completion", name)
+		}
+	}
+
+	if len(items) != len(builtins)+4 {
+		t.Errorf("expected %d items, the builtin len is hidden by the let. got=%d", len(builtins)+4, len(items))
+	}
+
+	if order[0] != "a" || order[1] != "b" {
+		t.Errorf("expected the bindings of the function first. got=%v", order[:2])
+	}
+
+	if labels["f"].Kind != completionFunction || labels["f"].Detail != "fn(a)" || labels["len"].Kind != completionVariable {
+		t.Errorf("wrong kinds: %v %v", labels["f"], labels["len"])
+	}
+
+	if labels["map"].Detail != "map(arr, f)" {
+		t.Errorf("expected the signature of map. got=%q", labels["map"].Detail)
+	}
+
+	// outside of the function its bindings aren't seen
+	for _, item := range d.completion(len(d.text)) {
+		if item.Label == "a" || item.Label == "b" {
+			t.Errorf("%s shouldn't be seen outside of the function", item.Label)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package lsp
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/dyxgou/parser/src/ast"
+)
+
+func (d *document) hover(offset int) any {
+	ref, ok := d.identAt(offset)
+	if !ok {
+		return nil
+	}
+
+	var value string
+	name := ref.ident.Value()
+
+	if def := ref.scope.lookup(name, offset); def != nil {
+		switch def.kind {
+		case defParam:
+			value = "```\nparameter " + name + "\n```"
+		case defLoop:
+			value = "```\nloop variable " + name + "\n```"
+		default:
+			value = "```\nlet " + name
+			if sig := signature(def); sig != "" {
+				value += " = " + sig
+			}
+			value += "\n```"
+		}
+	} else if b, ok := builtins[name]; ok {
+		value = "```\n" + b.signature + "\n```\n" + b.doc
+	} else {
+		return nil
+	}
+
+	return hover{
+		Contents: markupContent{Kind: "markdown", Value: value},
+		Range:    d.nodeRange(ref.ident),
+	}
+}
+
+// Returns the function given to a let without its body, like "fn(a, b)", or nothing for another value
+func signature(def *definition) string {
+	fn, ok := def.stmt.Value.(*ast.FunctionLiteral)
+	if !ok {
+		return ""
+	}
+
+	params := make([]string, len(fn.Params))
+	for i, param := range fn.Params {
+		params[i] = param.Value()
+	}
+
+	return "fn(" + strings.Join(params, ", ") + ")"
+}
+
+func (d *document) definition(offset int) any {
+	ref, ok := d.identAt(offset)
+	if !ok {
+		return nil
+	}
+
+	def := ref.scope.lookup(ref.ident.Value(), offset)
+	if def == nil {
+		return nil
+	}
+
+	return location{URI: d.uri, Range: d.nodeRange(def.name)}
+}
+
+// Returns the lets of the scope, the ones of a function have the lets inside it as children
+func (d *document) symbols(s *scope) []documentSymbol {
+	symbols := []documentSymbol{}
+
+	for _, def := range s.defs {
+		if def.kind != defLet {
+			continue
+		}
+
+		symbol := documentSymbol{
+			Name:           def.name.Value(),
+			Kind:           symbolVariable,
+			Range:          d.nodeRange(def.stmt),
+			SelectionRange: d.nodeRange(def.name),
+		}
+
+		if def.fn != nil {
+			symbol.Kind = symbolFunction
+			symbol.Detail = signature(def)
+			symbol.Children = d.symbols(def.fn)
+		}
+
+		symbols = append(symbols, symbol)
+	}
+
+	return symbols
+}
+
+// Returns the names seen at the offset, the innermost bindings first and then the builtins
+func (d *document) completion(offset int) []completionItem {
+	items := []completionItem{}
+	seen := make(map[string]bool)
+
+	for s := d.root.at(offset); s != nil; s = s.outer {
+		for _, def := range s.defs {
+			name := def.name.Value()
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			item := completionItem{Label: name, Kind: completionVariable}
+			if def.fn != nil {
+				item.Kind = completionFunction
+				item.Detail = signature(def)
+			}
+
+			items = append(items, item)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(builtins)) {
+		if seen[name] {
+			continue
+		}
+
+		b := builtins[name]
+		items = append(items, completionItem{Label: name, Kind: completionFunction, Detail: b.signature, Documentation: b.doc})
+	}
+
+	return items
+}
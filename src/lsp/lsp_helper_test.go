@@ -0,0 +1,76 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// Returns the messages framed like a client sends them, the ones with an id are requests
+func frame(t *testing.T, msgs ...map[string]any) io.Reader {
+	t.Helper()
+
+	var buf bytes.Buffer
+	for _, msg := range msgs {
+		msg["jsonrpc"] = "2.0"
+
+		body, err := json.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fmt.Fprintf(&buf, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+
+	return &buf
+}
+
+// Returns every message written by the server
+func readAll(t *testing.T, out []byte) []*message {
+	t.Helper()
+
+	r := bufio.NewReader(bytes.NewReader(out))
+
+	var msgs []*message
+	for {
+		msg, err := readMessage(r)
+		if err == io.EOF {
+			return msgs
+		}
+
+		if err != nil {
+			t.Fatalf("invalid message: %s", err)
+		}
+
+		msgs = append(msgs, msg)
+	}
+}
+
+// Returns the offset of the n-th appearance of the substring, counting from 1
+func find(t *testing.T, d *document, sub string, n int) int {
+	t.Helper()
+
+	offset := -1
+	for range n {
+		i := strings.Index(d.text[offset+1:], sub)
+		if i < 0 {
+			t.Fatalf("%q appears less than %d times", sub, n)
+		}
+
+		offset += i + 1
+	}
+
+	return offset
+}
+
+func decode(t *testing.T, data []byte, v any) {
+	t.Helper()
+
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("invalid JSON %s: %s", data, err)
+	}
+}
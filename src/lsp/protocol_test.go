@@ -0,0 +1,25 @@
+package lsp
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestReadMessageLength(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Content-Length: -1\r\n\r\n", `invalid Content-Length "-1"`},
+		{"Content-Length: x\r\n\r\n", `invalid Content-Length "x"`},
+		{"Content-Length: 9999999999\r\n\r\n", "Content-Length 9999999999 exceeds the maximum of 67108864 bytes"},
+	}
+
+	for _, tt := range tests {
+		_, err := readMessage(bufio.NewReader(strings.NewReader(tt.input)))
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%q: expected error %q. got=%v", tt.input, tt.expected, err)
+		}
+	}
+}
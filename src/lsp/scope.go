@@ -0,0 +1,190 @@
+package lsp
+
+import "github.com/dyxgou/parser/src/ast"
+
+// scope is the part of the document where the bindings of a function, or the ones of the program, are seen.
+// The blocks don't have their own ones
+type scope struct {
+	outer      *scope
+	start, end int // offsets of the function
+	defs       []*definition
+	inner      []*scope
+}
+
+// The kinds of the definitions
+const (
+	defLet = iota
+	defParam
+	defLoop
+)
+
+// definition is a name bound by a let, a parameter or the variable of a for loop
+type definition struct {
+	name *ast.Identifier
+	kind int
+	stmt *ast.LetStatement // the let defining it
+	fn   *scope            // the scope of the function given to the let
+}
+
+// reference is an identifier of the program and the scope where it's written
+type reference struct {
+	ident *ast.Identifier
+	scope *scope
+}
+
+// Returns the definition the name refers to at the offset. A let is seen by its whole function, but the one
+// used is the last one written before the offset, or the first one when all of them are after it
+func (s *scope) lookup(name string, offset int) *definition {
+	for ; s != nil; s = s.outer {
+		var found *definition
+
+		for _, def := range s.defs {
+			if def.name.Value() != name {
+				continue
+			}
+
+			if def.name.Pos().Offset <= offset {
+				found = def
+			} else {
+				if found == nil {
+					found = def
+				}
+
+				break
+			}
+		}
+
+		if found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// Returns the innermost scope holding the offset
+func (s *scope) at(offset int) *scope {
+	for _, inner := range s.inner {
+		if inner.start <= offset && offset < inner.end {
+			return inner.at(offset)
+		}
+	}
+
+	return s
+}
+
+// Returns the identifier written at the offset, the offset right after its last character is still part of it
+func (d *document) identAt(offset int) (reference, bool) {
+	for _, ref := range d.refs {
+		if ref.ident.Pos().Offset <= offset && offset <= ref.ident.End().Offset {
+			return ref, true
+		}
+	}
+
+	return reference{}, false
+}
+
+func (d *document) define(s *scope, name *ast.Identifier, kind int) *definition {
+	def := &definition{name: name, kind: kind}
+	s.defs = append(s.defs, def)
+	d.refs = append(d.refs, reference{name, s})
+
+	return def
+}
+
+func (d *document) function(s *scope, fn *ast.FunctionLiteral) *scope {
+	inner := &scope{outer: s, start: fn.Pos().Offset, end: fn.End().Offset}
+	s.inner = append(s.inner, inner)
+
+	for _, param := range fn.Params {
+		d.define(inner, param, defParam)
+	}
+
+	if fn.Body != nil {
+		d.statements(inner, fn.Body.Statements)
+	}
+
+	return inner
+}
+
+func (d *document) statements(s *scope, stmts []ast.Statement) {
+	for _, stmt := range stmts {
+		d.statement(s, stmt)
+	}
+}
+
+// Records the definitions and the identifiers of the statement in the order they are written
+func (d *document) statement(s *scope, stmt ast.Statement) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		def := d.define(s, stmt.Name, defLet)
+		def.stmt = stmt
+
+		if fn, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+			def.fn = d.function(s, fn)
+		} else {
+			d.expr(s, stmt.Value)
+		}
+	case *ast.ReturnStatement:
+		d.expr(s, stmt.Value)
+	case *ast.ExpressionStatement:
+		d.expr(s, stmt.Expression)
+	case *ast.BlockStatement:
+		d.statements(s, stmt.Statements)
+	case *ast.WhileStatement:
+		d.expr(s, stmt.Condition)
+		d.statement(s, stmt.Body)
+	case *ast.ForStatement:
+		d.define(s, stmt.Variable, defLoop)
+		d.expr(s, stmt.Iterable)
+		d.statement(s, stmt.Body)
+	}
+}
+
+func (d *document) expr(s *scope, exp ast.Expression) {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		d.refs = append(d.refs, reference{exp, s})
+	case *ast.PrefixExpression:
+		d.expr(s, exp.Right)
+	case *ast.InfixExpression:
+		d.expr(s, exp.Left)
+		d.expr(s, exp.Right)
+	case *ast.AssignExpression:
+		d.expr(s, exp.Target)
+		d.expr(s, exp.Value)
+	case *ast.IfExpression:
+		d.expr(s, exp.Condition)
+		d.statement(s, exp.Consequence)
+
+		if exp.Alternative != nil {
+			d.statement(s, exp.Alternative)
+		}
+	case *ast.FunctionLiteral:
+		d.function(s, exp)
+	case *ast.CallExpression:
+		d.expr(s, exp.Function)
+		for _, arg := range exp.Arguments {
+			d.expr(s, arg)
+		}
+	case *ast.ArrayLiteral:
+		for _, elem := range exp.Elements {
+			d.expr(s, elem)
+		}
+	case *ast.HashLiteral:
+		for _, pair := range exp.Pairs {
+			d.expr(s, pair.Key)
+			d.expr(s, pair.Value)
+		}
+	case *ast.IndexExpression:
+		d.expr(s, exp.Left)
+		d.expr(s, exp.Index)
+	case *ast.SliceExpression:
+		d.expr(s, exp.Left)
+		d.expr(s, exp.Low)
+		d.expr(s, exp.High)
+	case *ast.MemberExpression:
+		// the property is a name of the module, not a binding of the document
+		d.expr(s, exp.Object)
+	}
+}
@@ -0,0 +1,192 @@
+// Package lsp is a Language Server Protocol server for the language, it gives the editors the errors of the
+// parser, the signatures of the builtins, the definitions of the bindings, the symbols of a file and the
+// completion of names
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Server talks with one client through a pair of streams, usually the standard input and output
+type Server struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	docs     map[string]*document // the open documents by URI
+	shutdown bool                 // the client asked the server to stop, it just waits for the exit
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:   bufio.NewReader(in),
+		out:  out,
+		docs: make(map[string]*document),
+	}
+}
+
+// ErrNoShutdown is returned by Serve when the client exits without asking the server to shut down first
+var ErrNoShutdown = errors.New("exit without shutdown")
+
+// Answers the messages of the client until it sends the exit notification
+func (s *Server) Serve() error {
+	for {
+		msg, err := readMessage(s.in)
+		if err != nil {
+			return err
+		}
+
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return ErrNoShutdown
+			}
+
+			return nil
+		}
+
+		if err := s.handle(msg); err != nil {
+			return err
+		}
+	}
+}
+
+// Handles a request or a notification, the errors returned are the ones writing the answer
+func (s *Server) handle(msg *message) error {
+	if msg.Error != nil {
+		return s.reply(nil, nil, msg.Error)
+	}
+
+	// a request has an ID and expects a response, a notification doesn't
+	if msg.ID == nil {
+		s.notification(msg)
+		return nil
+	}
+
+	result, rerr := s.request(msg)
+	return s.reply(msg.ID, result, rerr)
+}
+
+func (s *Server) notification(msg *message) {
+	switch msg.Method {
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if json.Unmarshal(msg.Params, &params) == nil {
+			s.open(params.TextDocument.URI, params.TextDocument.Text)
+		}
+	case "textDocument/didChange":
+		// the server asks for the whole text on every change, so the last one has it
+		var params didChangeParams
+		if json.Unmarshal(msg.Params, &params) == nil && len(params.ContentChanges) > 0 {
+			s.open(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+		}
+	case "textDocument/didClose":
+		var params documentParams
+		if json.Unmarshal(msg.Params, &params) == nil {
+			delete(s.docs, params.TextDocument.URI)
+			s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []diagnostic{}})
+		}
+	}
+}
+
+func (s *Server) request(msg *message) (any, *responseError) {
+	switch msg.Method {
+	case "initialize":
+		return map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":       1, // the whole text is sent on every change
+				"hoverProvider":          true,
+				"definitionProvider":     true,
+				"documentSymbolProvider": true,
+				"completionProvider":     map[string]any{},
+			},
+			"serverInfo": map[string]any{"name": "lang-lsp"},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/hover", "textDocument/definition", "textDocument/completion":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &responseError{Code: invalidParams, Message: err.Error()}
+		}
+
+		doc, rerr := s.document(params.TextDocument.URI)
+		if rerr != nil {
+			return nil, rerr
+		}
+
+		offset := doc.offset(params.Position)
+
+		switch msg.Method {
+		case "textDocument/hover":
+			return doc.hover(offset), nil
+		case "textDocument/definition":
+			return doc.definition(offset), nil
+		default:
+			return doc.completion(offset), nil
+		}
+	case "textDocument/documentSymbol":
+		var params documentParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &responseError{Code: invalidParams, Message: err.Error()}
+		}
+
+		doc, rerr := s.document(params.TextDocument.URI)
+		if rerr != nil {
+			return nil, rerr
+		}
+
+		return doc.symbols(doc.root), nil
+	}
+
+	return nil, &responseError{Code: methodNotFound, Message: fmt.Sprintf("method %s not found", msg.Method)}
+}
+
+func (s *Server) document(uri string) (*document, *responseError) {
+	doc, ok := s.docs[uri]
+	if !ok {
+		return nil, &responseError{Code: invalidParams, Message: fmt.Sprintf("document %s is not open", uri)}
+	}
+
+	return doc, nil
+}
+
+// Parses the text of the document and publishes its diagnostics
+func (s *Server) open(uri, text string) {
+	doc := newDocument(uri, text)
+	s.docs[uri] = doc
+
+	s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: uri, Diagnostics: doc.diagnostics()})
+}
+
+func (s *Server) reply(id json.RawMessage, result any, rerr *responseError) error {
+	msg := &message{ID: id, Error: rerr}
+	if id == nil {
+		msg.ID = json.RawMessage("null")
+	}
+
+	if rerr == nil {
+		// a response without an error always has a result, even a null one
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+
+		msg.Result = data
+	}
+
+	return writeMessage(s.out, msg)
+}
+
+// The notifications are sent while handling another message, a failure writing them shows up on the next response
+func (s *Server) notify(method string, params any) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return
+	}
+
+	writeMessage(s.out, &message{Method: method, Params: data})
+}
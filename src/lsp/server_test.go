@@ -0,0 +1,151 @@
+package lsp
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dyxgou/parser/src/object"
+)
+
+func TestServe(t *testing.T) {
+	uri := "file:///main.lang"
+	doc := map[string]any{"uri": uri}
+	at := func(line, character int) map[string]any {
+		return map[string]any{"textDocument": doc, "position": map[string]any{"line": line, "character": character}}
+	}
+
+	in := frame(t,
+		map[string]any{"id": 1, "method": "initialize", "params": map[string]any{}},
+		map[string]any{"method": "initialized", "params": map[string]any{}},
+		map[string]any{"method": "textDocument/didOpen", "params": map[string]any{
+			"textDocument": map[string]any{"uri": uri, "languageId": "lang", "version": 1, "text": "let x = ;"},
+		}},
+		map[string]any{"method": "textDocument/didChange", "params": map[string]any{
+			"textDocument":   map[string]any{"uri": uri, "version": 2},
+			"contentChanges": []any{map[string]any{"text": "let x = 1;\nlen(x)"}},
+		}},
+		map[string]any{"id": 2, "method": "textDocument/hover", "params": at(1, 1)},
+		map[string]any{"id": 3, "method": "textDocument/definition", "params": at(1, 4)},
+		map[string]any{"id": 4, "method": "textDocument/documentSymbol", "params": map[string]any{"textDocument": doc}},
+		map[string]any{"id": 5, "method": "textDocument/completion", "params": at(1, 0)},
+		map[string]any{"id": 6, "method": "textDocument/hover", "params": at(0, 0)},
+		map[string]any{"id": 7, "method": "textDocument/rename", "params": at(0, 0)},
+		map[string]any{"id": 8, "method": "textDocument/hover", "params": map[string]any{
+			"textDocument": map[string]any{"uri": "file:///other.lang"}, "position": map[string]any{"line": 0, "character": 0},
+		}},
+		map[string]any{"method": "textDocument/didClose", "params": map[string]any{"textDocument": doc}},
+		map[string]any{"id": 9, "method": "shutdown"},
+		map[string]any{"method": "exit"},
+	)
+
+	var out bytes.Buffer
+	if err := NewServer(in, &out).Serve(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	msgs := readAll(t, out.Bytes())
+	if len(msgs) != 12 {
+		t.Fatalf("expected 12 messages. got=%d", len(msgs))
+	}
+
+	var initialize struct {
+		Capabilities map[string]any `json:"capabilities"`
+	}
+	decode(t, msgs[0].Result, &initialize)
+	for _, capability := range []string{"hoverProvider", "definitionProvider", "documentSymbolProvider", "completionProvider"} {
+		if _, ok := initialize.Capabilities[capability]; !ok {
+			t.Errorf("expected the capability %s", capability)
+		}
+	}
+
+	// the diagnostics of the opened text, then the ones of the changed text
+	var published publishDiagnosticsParams
+	decode(t, msgs[1].Params, &published)
+	if msgs[1].Method != "textDocument/publishDiagnostics" || published.URI != uri || len(published.Diagnostics) != 1 {
+		t.Errorf("expected the error of the opened text. got=%s %s", msgs[1].Method, msgs[1].Params)
+	}
+
+	decode(t, msgs[2].Params, &published)
+	if len(published.Diagnostics) != 0 {
+		t.Errorf("expected no errors after the change. got=%v", published.Diagnostics)
+	}
+
+	var h hover
+	decode(t, msgs[3].Result, &h)
+	if h.Contents.Value != "```\nlen(value)\n```\n"+builtins["len"].doc {
+		t.Errorf("wrong hover: %q", h.Contents.Value)
+	}
+
+	var loc location
+	decode(t, msgs[4].Result, &loc)
+	if loc.URI != uri || loc.Range != (textRange{position{0, 4}, position{0, 5}}) {
+		t.Errorf("wrong definition: %v", loc)
+	}
+
+	var symbols []documentSymbol
+	decode(t, msgs[5].Result, &symbols)
+	if len(symbols) != 1 || symbols[0].Name != "x" {
+		t.Errorf("wrong symbols: %v", symbols)
+	}
+
+	var items []completionItem
+	decode(t, msgs[6].Result, &items)
+	if len(items) != len(builtins)+1 || items[0].Label != "x" {
+		t.Errorf("wrong completion: %d items", len(items))
+	}
+
+	// a hover on a keyword has a null result
+	if string(msgs[7].Result) != "null" || msgs[7].Error != nil {
+		t.Errorf("expected a null result. got=%s", msgs[7].Result)
+	}
+
+	if msgs[8].Error == nil || msgs[8].Error.Code != methodNotFound {
+		t.Errorf("expected method not found. got=%v", msgs[8].Error)
+	}
+
+	if msgs[9].Error == nil || msgs[9].Error.Code != invalidParams {
+		t.Errorf("expected invalid params for a document that isn't open. got=%v", msgs[9].Error)
+	}
+
+	decode(t, msgs[10].Params, &published)
+	if published.URI != uri || len(published.Diagnostics) != 0 {
+		t.Errorf("expected the diagnostics to be cleared on close. got=%s", msgs[10].Params)
+	}
+
+	if string(msgs[11].ID) != "9" || string(msgs[11].Result) != "null" {
+		t.Errorf("expected the response to the shutdown. got=%s %s", msgs[11].ID, msgs[11].Result)
+	}
+}
+
+func TestServeExitWithoutShutdown(t *testing.T) {
+	in := frame(t, map[string]any{"method": "exit"})
+
+	var out bytes.Buffer
+	if err := NewServer(in, &out).Serve(); err != ErrNoShutdown {
+		t.Errorf("expected ErrNoShutdown. got=%v", err)
+	}
+}
+
+func TestServeInvalidMessage(t *testing.T) {
+	in := bytes.NewBufferString("Content-Length: 5\r\n\r\n{oops")
+
+	var out bytes.Buffer
+	NewServer(in, &out).Serve()
+
+	msgs := readAll(t, out.Bytes())
+	if len(msgs) != 1 || msgs[0].Error == nil || msgs[0].Error.Code != parseError {
+		t.Errorf("expected a parse error. got=%v", msgs)
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	for _, b := range object.Builtins {
+		if _, ok := builtins[b.Name]; !ok {
+			t.Errorf("the builtin %s has no signature", b.Name)
+		}
+	}
+
+	if len(builtins) != len(object.Builtins) {
+		t.Errorf("expected %d builtins. got=%d", len(object.Builtins), len(builtins))
+	}
+}
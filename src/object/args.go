@@ -0,0 +1,60 @@
+package object
+
+// Returns the error of a builtin called with a wrong number of arguments or arguments of the wrong type.
+// Every argument must have the type at its position, the optional ones are the ones after required.
+// FunctionType accepts anything that can be called, like a closure or a builtin
+func checkArgs(name string, args []Object, required int, types ...ObjectType) *Error {
+	if n := len(args); n < required || n > len(types) {
+		if required == len(types) {
+			return NewError("function `%s` expected %d arguments. got=%d", name, required, n)
+		}
+
+		return NewError("function `%s` expected %d to %d arguments. got=%d", name, required, len(types), n)
+	}
+
+	for i, arg := range args {
+		if !hasType(arg, types[i]) {
+			return NewError("argument %d to `%s` must be %s. got=%s", i+1, name, typeName(types[i]), arg.Inspect())
+		}
+	}
+
+	return nil
+}
+
+func hasType(obj Object, t ObjectType) bool {
+	if t == FunctionType {
+		return IsCallable(obj)
+	}
+
+	return obj.Type() == t
+}
+
+// Returns true when the object is a function of any engine or a builtin
+func IsCallable(obj Object) bool {
+	switch obj.Type() {
+	case FunctionType, ClosureType, BuiltInType:
+		return true
+	}
+
+	return false
+}
+
+func typeName(t ObjectType) string {
+	switch t {
+	case StringType:
+		return StringStr
+	case IntegerType:
+		return IntegerStr
+	case ArrayType:
+		return ArrayStr
+	case FunctionType:
+		return FunctionStr
+	}
+
+	return "?"
+}
+
+// NULL and false are the falsy values, every other one is truthy
+func IsTruthy(obj Object) bool {
+	return obj != NULL && obj != FALSE
+}
@@ -0,0 +1,311 @@
+package object
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+)
+
+// The builtins that take a function call it through the Runtime, so it runs on the engine that called them
+
+func builtinMap(rt Runtime, args ...Object) Object {
+	if err := checkArgs("map", args, 2, ArrayType, FunctionType); err != nil {
+		return err
+	}
+
+	elems := args[0].(*Array).Elements
+	mapped := make([]Object, 0, len(elems))
+
+	for _, elem := range elems {
+		val := rt.Apply(args[1], elem)
+		if isError(val) {
+			return val
+		}
+
+		mapped = append(mapped, val)
+	}
+
+	return &Array{Elements: mapped}
+}
+
+func builtinFilter(rt Runtime, args ...Object) Object {
+	if err := checkArgs("filter", args, 2, ArrayType, FunctionType); err != nil {
+		return err
+	}
+
+	var filtered []Object
+
+	for _, elem := range args[0].(*Array).Elements {
+		keep := rt.Apply(args[1], elem)
+		if isError(keep) {
+			return keep
+		}
+
+		if IsTruthy(keep) {
+			filtered = append(filtered, elem)
+		}
+	}
+
+	return &Array{Elements: filtered}
+}
+
+// reduce(arr, initial, f) calls f with the accumulated value and every element
+func builtinReduce(rt Runtime, args ...Object) Object {
+	if n := len(args); n != 3 {
+		return NewError("function `reduce` expected 3 arguments. got=%d", n)
+	}
+
+	if !hasType(args[0], ArrayType) {
+		return NewError("argument 1 to `reduce` must be %s. got=%s", ArrayStr, args[0].Inspect())
+	}
+
+	if !hasType(args[2], FunctionType) {
+		return NewError("argument 3 to `reduce` must be %s. got=%s", FunctionStr, args[2].Inspect())
+	}
+
+	acc := args[1]
+	for _, elem := range args[0].(*Array).Elements {
+		acc = rt.Apply(args[2], acc, elem)
+		if isError(acc) {
+			return acc
+		}
+	}
+
+	return acc
+}
+
+// sort(arr) sorts numbers or strings in increasing order, sort(arr, less) uses the function to tell
+// when its first argument goes before the second one. The sort is stable and returns a new array
+func builtinSort(rt Runtime, args ...Object) Object {
+	if err := checkArgs("sort", args, 1, ArrayType, FunctionType); err != nil {
+		return err
+	}
+
+	sorted := slices.Clone(args[0].(*Array).Elements)
+
+	var err Object
+	less := func(a, b Object) bool {
+		if err != nil {
+			return false
+		}
+
+		if len(args) == 1 {
+			c, cmpErr := compare(a, b)
+			err = cmpErr
+			return c < 0
+		}
+
+		result := rt.Apply(args[1], a, b)
+		if isError(result) {
+			err = result
+			return false
+		}
+
+		return IsTruthy(result)
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+	if err != nil {
+		return err
+	}
+
+	return &Array{Elements: sorted}
+}
+
+// Compares two numbers or two strings
+func compare(a, b Object) (int, Object) {
+	if x, ok := ToFloat(a); ok {
+		if y, ok := ToFloat(b); ok {
+			if a, ok := a.(*Integer); ok {
+				if b, ok := b.(*Integer); ok {
+					return cmp.Compare(a.Value, b.Value), nil
+				}
+			}
+
+			return cmp.Compare(x, y), nil
+		}
+	}
+
+	if x, ok := a.(*String); ok {
+		if y, ok := b.(*String); ok {
+			return cmp.Compare(x.Value, y.Value), nil
+		}
+	}
+
+	return 0, NewError("function `sort` can't compare %s with %s", a.Inspect(), b.Inspect())
+}
+
+func builtinReverse(_ Runtime, args ...Object) Object {
+	if len(args) == 1 {
+		if s, ok := args[0].(*String); ok {
+			runes := []rune(s.Value)
+			slices.Reverse(runes)
+
+			return &String{Value: string(runes)}
+		}
+	}
+
+	if err := checkArgs("reverse", args, 1, ArrayType); err != nil {
+		return err
+	}
+
+	reversed := slices.Clone(args[0].(*Array).Elements)
+	slices.Reverse(reversed)
+
+	return &Array{Elements: reversed}
+}
+
+// the longest array range can build
+const maxRangeLength = 1 << 24
+
+// range(end), range(start, end) and range(start, end, step) return the integers from start up to end, without it
+func builtinRange(_ Runtime, args ...Object) Object {
+	if err := checkArgs("range", args, 1, IntegerType, IntegerType, IntegerType); err != nil {
+		return err
+	}
+
+	var start, end, step int64 = 0, 0, 1
+
+	switch len(args) {
+	case 1:
+		end = args[0].(*Integer).Value
+	case 2:
+		start, end = args[0].(*Integer).Value, args[1].(*Integer).Value
+	case 3:
+		start, end, step = args[0].(*Integer).Value, args[1].(*Integer).Value, args[2].(*Integer).Value
+	}
+
+	if step == 0 {
+		return NewError("function `range` expected a step that isn't zero")
+	}
+
+	// the distances are unsigned so they don't overflow between the ends of the integers
+	var span, stride uint64
+	if step > 0 && end > start {
+		span, stride = uint64(end)-uint64(start), uint64(step)
+	} else if step < 0 && end < start {
+		span, stride = uint64(start)-uint64(end), uint64(-(step+1))+1
+	}
+
+	var n uint64
+	if stride > 0 {
+		n = span / stride
+		if span%stride != 0 {
+			n++
+		}
+	}
+
+	if n > maxRangeLength {
+		return NewError("function `range` would build an array longer than %d elements", maxRangeLength)
+	}
+
+	elems := make([]Object, n)
+	for i := range elems {
+		elems[i] = &Integer{Value: start + int64(i)*step}
+	}
+
+	return &Array{Elements: elems}
+}
+
+// zip(a, b, ...) returns arrays with the elements at the same index of every array, as long as the shortest one
+func builtinZip(_ Runtime, args ...Object) Object {
+	if len(args) == 0 {
+		return NewError("function `zip` expected at least 1 argument. got=0")
+	}
+
+	n := -1
+	for i, arg := range args {
+		arr, ok := arg.(*Array)
+		if !ok {
+			return NewError("argument %d to `zip` must be %s. got=%s", i+1, ArrayStr, arg.Inspect())
+		}
+
+		if n < 0 || len(arr.Elements) < n {
+			n = len(arr.Elements)
+		}
+	}
+
+	zipped := make([]Object, n)
+	for i := range zipped {
+		tuple := make([]Object, len(args))
+		for j, arg := range args {
+			tuple[j] = arg.(*Array).Elements[i]
+		}
+
+		zipped[i] = &Array{Elements: tuple}
+	}
+
+	return &Array{Elements: zipped}
+}
+
+// Returns a builtin that looks for the first element whose test is want, the test is the result
+// of the function given or the element itself when the function is optional
+func searchBuiltin(name string, required int, want bool, found func(elem Object) Object, notFound Object) BuiltInFunction {
+	return func(rt Runtime, args ...Object) Object {
+		if err := checkArgs(name, args, required, ArrayType, FunctionType); err != nil {
+			return err
+		}
+
+		for _, elem := range args[0].(*Array).Elements {
+			test := elem
+			if len(args) == 2 {
+				test = rt.Apply(args[1], elem)
+				if isError(test) {
+					return test
+				}
+			}
+
+			if IsTruthy(test) == want {
+				return found(elem)
+			}
+		}
+
+		return notFound
+	}
+}
+
+// flatten(arr) replaces the arrays inside arr with their elements, just one level deep
+func builtinFlatten(_ Runtime, args ...Object) Object {
+	if err := checkArgs("flatten", args, 1, ArrayType); err != nil {
+		return err
+	}
+
+	var flat []Object
+	for _, elem := range args[0].(*Array).Elements {
+		if arr, ok := elem.(*Array); ok {
+			flat = append(flat, arr.Elements...)
+		} else {
+			flat = append(flat, elem)
+		}
+	}
+
+	return &Array{Elements: flat}
+}
+
+// unique(arr) returns the elements without the repeated ones, keeping the first time they appear
+func builtinUnique(_ Runtime, args ...Object) Object {
+	if err := checkArgs("unique", args, 1, ArrayType); err != nil {
+		return err
+	}
+
+	seen := make(map[HashKey]bool)
+	var unique []Object
+
+	for _, elem := range args[0].(*Array).Elements {
+		key, ok := elem.(Hashable)
+		if !ok {
+			return NewError("unusable as hash key: %s", elem.Inspect())
+		}
+
+		if hk := key.HashKey(); !seen[hk] {
+			seen[hk] = true
+			unique = append(unique, elem)
+		}
+	}
+
+	return &Array{Elements: unique}
+}
+
+func isError(obj Object) bool {
+	return obj != nil && obj.Type() == ErrorType
+}
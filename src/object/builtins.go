@@ -0,0 +1,296 @@
+package object
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Builtins are shared by the evaluator and the vm, the compiler refers to them by their index
+var Builtins = []struct {
+	Name    string
+	BuiltIn *BuiltIn
+}{
+	{
+		"len",
+		&BuiltIn{Fn: func(_ Runtime, args ...Object) Object {
+			if n := len(args); n != 1 {
+				return NewError("len expected 1 argument. got=%d", n)
+			}
+
+			switch arg := args[0].(type) {
+			case *String:
+				return &Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+			case *Array:
+				return &Integer{Value: int64(len(arg.Elements))}
+			case *Hash:
+				return &Integer{Value: int64(arg.Len())}
+			default:
+				return NewError("argument to 'len' not supported. got=%T", arg.Inspect())
+			}
+		}},
+	},
+	{
+		"first",
+		&BuiltIn{Fn: func(_ Runtime, args ...Object) Object {
+			if n := len(args); n != 1 {
+				return NewError("function `first` supports just one argument. got=%d", n)
+			}
+
+			x := args[0]
+			if x.Type() != ArrayType {
+				return NewError("argument to `first` must be an array. got=%q", x.Inspect())
+			}
+
+			arr := x.(*Array)
+			if len(arr.Elements) > 0 {
+				return arr.Elements[0]
+			}
+
+			return NULL
+		}},
+	},
+	{
+		"last",
+		&BuiltIn{Fn: func(_ Runtime, args ...Object) Object {
+			if n := len(args); n != 1 {
+				return NewError("function `last` supports just one argument. got=%d", n)
+			}
+
+			x := args[0]
+			if x.Type() != ArrayType {
+				return NewError("argument to `last` must be an array. got=%q", x.Inspect())
+			}
+
+			arr := x.(*Array)
+			if n := len(arr.Elements); n > 0 {
+				return arr.Elements[n-1]
+			}
+
+			return NULL
+		}},
+	},
+	{
+		"rest",
+		&BuiltIn{Fn: func(_ Runtime, args ...Object) Object {
+			if n := len(args); n != 1 {
+				return NewError("function `rest` supports just one argument. got=%d", n)
+			}
+
+			x := args[0]
+			if x.Type() != ArrayType {
+				return NewError("argument to `rest` must be an array. got=%q", x.Inspect())
+			}
+
+			arr := x.(*Array)
+			if n := len(arr.Elements); n > 0 {
+				elems := make([]Object, n-1)
+				copy(elems, arr.Elements[1:n])
+
+				return &Array{Elements: elems}
+			}
+
+			return NULL
+		}},
+	},
+	{
+		"push",
+		&BuiltIn{Fn: func(_ Runtime, args ...Object) Object {
+			if n := len(args); n != 2 {
+				return NewError(
+					"function `push` supports just two argument. got=%d",
+					n,
+				)
+			}
+
+			arr, ok := args[0].(*Array)
+			if !ok {
+				return NewError(
+					"first argument to `push` must be an array. got=%q (%s)",
+					args[0].Inspect(), args[0].String(),
+				)
+			}
+
+			arr.Elements = append(arr.Elements, args[1])
+			return &Integer{Value: int64(len(arr.Elements))}
+		}},
+	},
+	{
+		"pop",
+		&BuiltIn{Fn: func(_ Runtime, args ...Object) Object {
+			if n := len(args); n != 1 {
+				return NewError("function `pop` supports just one argument. got=%d", n)
+			}
+
+			arr, ok := args[0].(*Array)
+			if !ok {
+				return NewError(
+					"first argument to `pop` must be an array. got=%q",
+					args[0].Inspect(),
+				)
+			}
+
+			n := len(arr.Elements)
+			if n == 0 {
+				return NewError("function `pop` called on an empty array")
+			}
+
+			item := arr.Elements[n-1]
+			arr.Elements = slices.Delete(arr.Elements, n-1, n)
+
+			return item
+		}},
+	},
+	{"print", &BuiltIn{Fn: printBuiltin("")}},
+	{
+		"keys",
+		&BuiltIn{Fn: func(_ Runtime, args ...Object) Object {
+			if n := len(args); n != 1 {
+				return NewError("function `keys` supports just one argument. got=%d", n)
+			}
+
+			hash, ok := args[0].(*Hash)
+			if !ok {
+				return NewError("argument to `keys` must be a hash. got=%q", args[0].Inspect())
+			}
+
+			elems := make([]Object, 0, hash.Len())
+			for _, pair := range hash.Pairs() {
+				elems = append(elems, pair.Key)
+			}
+
+			return &Array{Elements: elems}
+		}},
+	},
+	{
+		"values",
+		&BuiltIn{Fn: func(_ Runtime, args ...Object) Object {
+			if n := len(args); n != 1 {
+				return NewError("function `values` supports just one argument. got=%d", n)
+			}
+
+			hash, ok := args[0].(*Hash)
+			if !ok {
+				return NewError("argument to `values` must be a hash. got=%q", args[0].Inspect())
+			}
+
+			elems := make([]Object, 0, hash.Len())
+			for _, pair := range hash.Pairs() {
+				elems = append(elems, pair.Value)
+			}
+
+			return &Array{Elements: elems}
+		}},
+	},
+	{
+		"has",
+		&BuiltIn{Fn: func(_ Runtime, args ...Object) Object {
+			if n := len(args); n != 2 {
+				return NewError("function `has` supports just two arguments. got=%d", n)
+			}
+
+			hash, ok := args[0].(*Hash)
+			if !ok {
+				return NewError("first argument to `has` must be a hash. got=%q", args[0].Inspect())
+			}
+
+			key, ok := args[1].(Hashable)
+			if !ok {
+				return NewError("unusable as hash key: %s", args[1].Inspect())
+			}
+
+			_, ok = hash.Get(key)
+			return NativeBoolToBooleanObject(ok)
+		}},
+	},
+	{
+		"delete",
+		&BuiltIn{Fn: func(_ Runtime, args ...Object) Object {
+			if n := len(args); n != 2 {
+				return NewError("function `delete` supports just two arguments. got=%d", n)
+			}
+
+			hash, ok := args[0].(*Hash)
+			if !ok {
+				return NewError("first argument to `delete` must be a hash. got=%q", args[0].Inspect())
+			}
+
+			key, ok := args[1].(Hashable)
+			if !ok {
+				return NewError("unusable as hash key: %s", args[1].Inspect())
+			}
+
+			if val, ok := hash.Delete(key); ok {
+				return val
+			}
+
+			return NULL
+		}},
+	},
+	{
+		"merge",
+		&BuiltIn{Fn: func(_ Runtime, args ...Object) Object {
+			if n := len(args); n < 2 {
+				return NewError("function `merge` expected at least two arguments. got=%d", n)
+			}
+
+			merged := NewHash()
+
+			for i, arg := range args {
+				hash, ok := arg.(*Hash)
+				if !ok {
+					return NewError("argument %d to `merge` must be a hash. got=%q", i, arg.Inspect())
+				}
+
+				for _, pair := range hash.Pairs() {
+					merged.Set(pair.Key, pair.Value)
+				}
+			}
+
+			return merged
+		}},
+	},
+	{"int", &BuiltIn{Fn: builtinInt}},
+	{"float", &BuiltIn{Fn: builtinFloat}},
+	{"floor", &BuiltIn{Fn: roundingBuiltin("floor", math.Floor)}},
+	{"ceil", &BuiltIn{Fn: roundingBuiltin("ceil", math.Ceil)}},
+	{"round", &BuiltIn{Fn: roundingBuiltin("round", math.Round)}},
+	{"split", &BuiltIn{Fn: builtinSplit}},
+	{"join", &BuiltIn{Fn: builtinJoin}},
+	{"trim", &BuiltIn{Fn: builtinTrim}},
+	{"upper", &BuiltIn{Fn: stringBuiltin("upper", strings.ToUpper)}},
+	{"lower", &BuiltIn{Fn: stringBuiltin("lower", strings.ToLower)}},
+	{"contains", &BuiltIn{Fn: stringTestBuiltin("contains", strings.Contains)}},
+	{"replace", &BuiltIn{Fn: builtinReplace}},
+	{"starts_with", &BuiltIn{Fn: stringTestBuiltin("starts_with", strings.HasPrefix)}},
+	{"ends_with", &BuiltIn{Fn: stringTestBuiltin("ends_with", strings.HasSuffix)}},
+	{"index_of", &BuiltIn{Fn: builtinIndexOf}},
+	{"substr", &BuiltIn{Fn: builtinSubstr}},
+	{"repeat", &BuiltIn{Fn: builtinRepeat}},
+	{"map", &BuiltIn{Fn: builtinMap}},
+	{"filter", &BuiltIn{Fn: builtinFilter}},
+	{"reduce", &BuiltIn{Fn: builtinReduce}},
+	{"sort", &BuiltIn{Fn: builtinSort}},
+	{"reverse", &BuiltIn{Fn: builtinReverse}},
+	{"range", &BuiltIn{Fn: builtinRange}},
+	{"zip", &BuiltIn{Fn: builtinZip}},
+	{"any", &BuiltIn{Fn: searchBuiltin("any", 1, true, func(Object) Object { return TRUE }, FALSE)}},
+	{"all", &BuiltIn{Fn: searchBuiltin("all", 1, false, func(Object) Object { return FALSE }, TRUE)}},
+	{"find", &BuiltIn{Fn: searchBuiltin("find", 2, true, func(elem Object) Object { return elem }, NULL)}},
+	{"flatten", &BuiltIn{Fn: builtinFlatten}},
+	{"unique", &BuiltIn{Fn: builtinUnique}},
+	{"println", &BuiltIn{Fn: printBuiltin("\n")}},
+	{"input", &BuiltIn{Fn: builtinInput}},
+	{"readline", &BuiltIn{Fn: builtinReadline}},
+}
+
+func GetBuiltinByName(name string) (*BuiltIn, bool) {
+	for _, b := range Builtins {
+		if b.Name == name {
+			return b.BuiltIn, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,51 @@
+package object
+
+import (
+	"fmt"
+
+	"github.com/dyxgou/parser/src/code"
+)
+
+// CompiledFunction is a function lowered to bytecode by the compiler
+type CompiledFunction struct {
+	Instructions  code.Instructions
+	NumLocals     int
+	NumParameters int
+
+	Positions code.SourceMap // places the instructions in the source
+	Locals    []string       // names of the local slots, used to report undefined identifiers
+}
+
+func (*CompiledFunction) Type() ObjectType { return CompiledFunctionType }
+func (*CompiledFunction) Inspect() string  { return CompiledFunctionStr }
+func (o *CompiledFunction) String() string {
+	return fmt.Sprintf("CompiledFunction[%p]", o)
+}
+
+// Closure is the runtime value of a function in the vm, it keeps the free variables captured when it was created
+type Closure struct {
+	Fn   *CompiledFunction
+	Free []Object
+}
+
+func (*Closure) Type() ObjectType { return ClosureType }
+
+// A closure is a function for the scripts, so it reports itself as one
+func (*Closure) Inspect() string  { return FunctionStr }
+func (o *Closure) String() string { return fmt.Sprintf("Closure[%p]", o) }
+
+// Cell holds a variable captured by a closure, the closure and the scope that defined
+// the variable share the cell so an assignment in one of them is seen by the other
+type Cell struct {
+	Value Object
+}
+
+func (*Cell) Type() ObjectType { return CellType }
+func (*Cell) Inspect() string  { return CellStr }
+func (o *Cell) String() string {
+	if o.Value == nil {
+		return "nil"
+	}
+
+	return o.Value.String()
+}
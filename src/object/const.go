@@ -5,6 +5,7 @@ type ObjectString = string
 
 const (
 	IntegerType ObjectType = iota
+	FloatType
 	StringType
 	BooleanType
 	NullType
@@ -12,11 +13,21 @@ const (
 	FunctionType
 	BuiltInType
 	ArrayType
+	HashType
+	CompiledFunctionType
+	ClosureType
+	BreakType
+	ContinueType
+	IteratorType
+	CellType
+	TailCallType
+	ModuleType
 	ErrorType
 )
 
 const (
 	IntegerStr  ObjectString = "INTEGER"
+	FloatStr    ObjectString = "FLOAT"
 	StringStr   ObjectString = "STRING"
 	BooleanStr  ObjectString = "BOOLEAN"
 	NullStr     ObjectString = "NULL"
@@ -24,5 +35,14 @@ const (
 	FunctionStr ObjectString = "FUNCTION"
 	BuiltInStr  ObjectString = "BUILTIN"
 	ArrayStr    ObjectString = "ARRAY"
+	HashStr     ObjectString = "HASH"
 	ErrorStr    ObjectString = "ERROR"
+
+	CompiledFunctionStr ObjectString = "COMPILED_FUNCTION"
+	BreakStr            ObjectString = "BREAK"
+	ContinueStr         ObjectString = "CONTINUE"
+	IteratorStr         ObjectString = "ITERATOR"
+	CellStr             ObjectString = "CELL"
+	TailCallStr         ObjectString = "TAIL_CALL"
+	ModuleStr           ObjectString = "MODULE"
 )
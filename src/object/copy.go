@@ -0,0 +1,82 @@
+package object
+
+import "slices"
+
+// Copy returns a deep copy of the enviroment and its outer ones. The arrays, hashes, functions and modules
+// reached from it are copied too, so running code in the copy leaves the original enviroment untouched.
+// A binding is copied the first time it's used, so the cost of the copy is the one of what is read from it
+func (e *Enviroment) Copy() *Enviroment {
+	c := &copier{
+		envs: make(map[*Enviroment]*Enviroment),
+		objs: make(map[Object]Object),
+	}
+
+	return c.env(e)
+}
+
+// copier keeps the copies already made, so a value reached twice, or from itself, is copied once
+type copier struct {
+	envs map[*Enviroment]*Enviroment
+	objs map[Object]Object
+}
+
+func (c *copier) env(e *Enviroment) *Enviroment {
+	if e == nil {
+		return nil
+	}
+
+	if copied, ok := c.envs[e]; ok {
+		return copied
+	}
+
+	copied := &Enviroment{store: make(map[string]Object), original: e, copier: c}
+	c.envs[e] = copied
+
+	copied.outer = c.env(e.outer)
+
+	return copied
+}
+
+// The values that can't change, like integers, strings or builtins, are shared with the copy
+func (c *copier) object(obj Object) Object {
+	if copied, ok := c.objs[obj]; ok {
+		return copied
+	}
+
+	switch obj := obj.(type) {
+	case *Array:
+		copied := &Array{Elements: make([]Object, len(obj.Elements))}
+		c.objs[obj] = copied
+
+		for i, elem := range obj.Elements {
+			copied.Elements[i] = c.object(elem)
+		}
+
+		return copied
+	case *Hash:
+		copied := &Hash{pairs: make(map[HashKey]HashPair, len(obj.pairs)), keys: slices.Clone(obj.keys)}
+		c.objs[obj] = copied
+
+		for key, pair := range obj.pairs {
+			copied.pairs[key] = HashPair{Key: pair.Key, Value: c.object(pair.Value)}
+		}
+
+		return copied
+	case *Function:
+		copied := &Function{Parameters: obj.Parameters, Body: obj.Body}
+		c.objs[obj] = copied
+
+		copied.Env = c.env(obj.Env)
+
+		return copied
+	case *Module:
+		copied := &Module{Path: obj.Path}
+		c.objs[obj] = copied
+
+		copied.Env = c.env(obj.Env)
+
+		return copied
+	}
+
+	return obj
+}
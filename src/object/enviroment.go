@@ -1,8 +1,17 @@
 package object
 
+import (
+	"maps"
+	"slices"
+)
+
 type Enviroment struct {
 	store map[string]Object
 	outer *Enviroment
+
+	// a copy made by Copy takes the bindings of the original enviroment the first time they are used
+	original *Enviroment
+	copier   *copier
 }
 
 func NewEnviroment() *Enviroment {
@@ -20,7 +29,7 @@ func NewOuterEnviroment(outer *Enviroment) *Enviroment {
 }
 
 func (e *Enviroment) Get(name string) (Object, bool) {
-	obj, ok := e.store[name]
+	obj, ok := e.local(name)
 
 	if !ok && e.outer != nil {
 		obj, ok := e.outer.Get(name)
@@ -34,3 +43,54 @@ func (e *Enviroment) Get(name string) (Object, bool) {
 func (e *Enviroment) Set(name string, val Object) {
 	e.store[name] = val
 }
+
+// Updates the nearest binding of name walking the outer enviroments, returns false if the name is not defined
+func (e *Enviroment) Assign(name string, val Object) bool {
+	if _, ok := e.local(name); ok {
+		e.store[name] = val
+		return true
+	}
+
+	if e.outer != nil {
+		return e.outer.Assign(name, val)
+	}
+
+	return false
+}
+
+// Returns the sorted names defined in the enviroment, without the ones of the outer enviroments
+func (e *Enviroment) Names() []string {
+	if e.original == nil {
+		return slices.Sorted(maps.Keys(e.store))
+	}
+
+	return slices.Compact(slices.Sorted(func(yield func(string) bool) {
+		for name := range e.store {
+			if !yield(name) {
+				return
+			}
+		}
+
+		for _, name := range e.original.Names() {
+			if !yield(name) {
+				return
+			}
+		}
+	}))
+}
+
+// Returns the binding of name in the enviroment, without looking in the outer ones
+func (e *Enviroment) local(name string) (Object, bool) {
+	obj, ok := e.store[name]
+	if ok || e.original == nil {
+		return obj, ok
+	}
+
+	obj, ok = e.original.local(name)
+	if ok {
+		obj = e.copier.object(obj)
+		e.store[name] = obj
+	}
+
+	return obj, ok
+}
@@ -0,0 +1,129 @@
+package object
+
+import (
+	"hash/fnv"
+	"strings"
+)
+
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+}
+
+// Hashable is implemented by the objects that can be used as keys of a Hash
+type Hashable interface {
+	Object
+
+	HashKey() HashKey
+}
+
+func (i *Integer) HashKey() HashKey {
+	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
+}
+
+func (b *Boolean) HashKey() HashKey {
+	var v uint64
+	if b.Value {
+		v = 1
+	}
+
+	return HashKey{Type: b.Type(), Value: v}
+}
+
+func (s *String) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(s.Value))
+
+	return HashKey{Type: s.Type(), Value: h.Sum64()}
+}
+
+type HashPair struct {
+	Key   Hashable
+	Value Object
+}
+
+type Hash struct {
+	pairs map[HashKey]HashPair
+	keys  []HashKey // insertion order of the keys
+}
+
+func NewHash() *Hash {
+	return &Hash{
+		pairs: make(map[HashKey]HashPair),
+	}
+}
+
+func (*Hash) Type() ObjectType      { return HashType }
+func (*Hash) Inspect() ObjectString { return HashStr }
+func (h *Hash) String() string {
+	var sb strings.Builder
+
+	sb.WriteByte('{')
+	for i, pair := range h.Pairs() {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+
+		sb.WriteString(pair.Key.String())
+		sb.WriteString(": ")
+		sb.WriteString(pair.Value.String())
+	}
+	sb.WriteByte('}')
+
+	return sb.String()
+}
+
+func (h *Hash) Len() int {
+	return len(h.keys)
+}
+
+func (h *Hash) Get(key Hashable) (Object, bool) {
+	pair, ok := h.pairs[key.HashKey()]
+	if !ok {
+		return nil, false
+	}
+
+	return pair.Value, true
+}
+
+// Sets the value of the key, a new key is placed after the existing ones
+func (h *Hash) Set(key Hashable, val Object) {
+	hk := key.HashKey()
+
+	if _, ok := h.pairs[hk]; !ok {
+		h.keys = append(h.keys, hk)
+	}
+
+	h.pairs[hk] = HashPair{Key: key, Value: val}
+}
+
+// Removes the key and returns the value it had
+func (h *Hash) Delete(key Hashable) (Object, bool) {
+	hk := key.HashKey()
+
+	pair, ok := h.pairs[hk]
+	if !ok {
+		return nil, false
+	}
+
+	delete(h.pairs, hk)
+	for i, k := range h.keys {
+		if k == hk {
+			h.keys = append(h.keys[:i], h.keys[i+1:]...)
+			break
+		}
+	}
+
+	return pair.Value, true
+}
+
+// Returns the pairs in insertion order
+func (h *Hash) Pairs() []HashPair {
+	pairs := make([]HashPair, 0, len(h.keys))
+
+	for _, k := range h.keys {
+		pairs = append(pairs, h.pairs[k])
+	}
+
+	return pairs
+}
@@ -0,0 +1,68 @@
+package object
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// The builtins that print or read go through the streams of the Runtime, so the host decides where they are
+
+// Returns a builtin that writes its arguments one after the other and then end
+func printBuiltin(end string) BuiltInFunction {
+	return func(rt Runtime, args ...Object) Object {
+		var sb strings.Builder
+
+		for _, arg := range args {
+			sb.WriteString(arg.String())
+		}
+
+		sb.WriteString(end)
+
+		if _, err := io.WriteString(rt.Stdout(), sb.String()); err != nil {
+			return &Error{Message: "cannot print: " + err.Error(), Cause: err}
+		}
+
+		return NULL
+	}
+}
+
+// input() reads a line, input(prompt) prints the prompt before reading it
+func builtinInput(rt Runtime, args ...Object) Object {
+	if err := checkArgs("input", args, 0, StringType); err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		if _, err := io.WriteString(rt.Stdout(), args[0].(*String).Value); err != nil {
+			return &Error{Message: "cannot print: " + err.Error(), Cause: err}
+		}
+	}
+
+	return readLine(rt)
+}
+
+func builtinReadline(rt Runtime, args ...Object) Object {
+	if err := checkArgs("readline", args, 0); err != nil {
+		return err
+	}
+
+	return readLine(rt)
+}
+
+// Reads a line without its line break, NULL means there is nothing left to read
+func readLine(rt Runtime) Object {
+	line, err := rt.Stdin().ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return &Error{Message: "cannot read: " + err.Error(), Cause: err}
+	}
+
+	if err != nil && line == "" {
+		return NULL
+	}
+
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+
+	return &String{Value: line}
+}
@@ -0,0 +1,55 @@
+package object
+
+// Break and Continue travel up from the statement to the loop that handles them
+type Break struct{}
+
+func (*Break) Type() ObjectType { return BreakType }
+func (*Break) Inspect() string  { return BreakStr }
+func (*Break) String() string   { return "break" }
+
+type Continue struct{}
+
+func (*Continue) Type() ObjectType { return ContinueType }
+func (*Continue) Inspect() string  { return ContinueStr }
+func (*Continue) String() string   { return "continue" }
+
+// Iterator walks the values of a for loop: the elements of an array or the keys of a hash
+type Iterator struct {
+	elements []Object
+	index    int
+}
+
+func NewIterator(obj Object) (*Iterator, *Error) {
+	var elements []Object
+
+	switch obj := obj.(type) {
+	case *Array:
+		// the loop walks the elements the array had when it started
+		elements = make([]Object, len(obj.Elements))
+		copy(elements, obj.Elements)
+	case *Hash:
+		for _, pair := range obj.Pairs() {
+			elements = append(elements, pair.Key)
+		}
+	default:
+		return nil, NewError("cannot iterate over %s", obj.Inspect())
+	}
+
+	return &Iterator{elements: elements}, nil
+}
+
+func (*Iterator) Type() ObjectType { return IteratorType }
+func (*Iterator) Inspect() string  { return IteratorStr }
+func (*Iterator) String() string   { return IteratorStr }
+
+// Returns the next value, false once all of them were walked
+func (it *Iterator) Next() (Object, bool) {
+	if it.index >= len(it.elements) {
+		return nil, false
+	}
+
+	obj := it.elements[it.index]
+	it.index++
+
+	return obj, true
+}
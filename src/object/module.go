@@ -0,0 +1,22 @@
+package object
+
+import "strings"
+
+// Module is an imported file, its top level bindings are exported unless their name starts with "_"
+type Module struct {
+	Path string
+	Env  *Enviroment
+}
+
+func (*Module) Type() ObjectType { return ModuleType }
+func (*Module) Inspect() string  { return ModuleStr }
+func (m *Module) String() string { return "module(" + m.Path + ")" }
+
+// Returns the exported binding called name
+func (m *Module) Export(name string) (Object, bool) {
+	if strings.HasPrefix(name, "_") {
+		return nil, false
+	}
+
+	return m.Env.Get(name)
+}
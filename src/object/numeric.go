@@ -0,0 +1,98 @@
+package object
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Returns the value of an Integer or a Float as a float64
+func ToFloat(obj Object) (float64, bool) {
+	switch obj := obj.(type) {
+	case *Integer:
+		return float64(obj.Value), true
+	case *Float:
+		return obj.Value, true
+	}
+
+	return 0, false
+}
+
+// Returns true when both objects are numbers and at least one of them is a Float, so the
+// operation between them promotes the Integer to a Float
+func IsFloatOperation(left, right Object) bool {
+	_, leftOk := ToFloat(left)
+	_, rightOk := ToFloat(right)
+
+	return leftOk && rightOk && (left.Type() == FloatType || right.Type() == FloatType)
+}
+
+func floatToInteger(name string, v float64) Object {
+	if math.IsNaN(v) || math.IsInf(v, 0) || v > math.MaxInt64 || v < math.MinInt64 {
+		return NewError("argument to `%s` out of the integer range. got=%g", name, v)
+	}
+
+	return &Integer{Value: int64(v)}
+}
+
+func builtinInt(_ Runtime, args ...Object) Object {
+	if n := len(args); n != 1 {
+		return NewError("function `int` supports just one argument. got=%d", n)
+	}
+
+	switch arg := args[0].(type) {
+	case *Integer:
+		return arg
+	case *Float:
+		return floatToInteger("int", math.Trunc(arg.Value))
+	case *String:
+		v, err := strconv.ParseInt(strings.TrimSpace(arg.Value), 10, 64)
+		if err != nil {
+			return NewError("could not parse %q as an integer", arg.Value)
+		}
+
+		return &Integer{Value: v}
+	}
+
+	return NewError("argument to `int` not supported. got=%s", args[0].Inspect())
+}
+
+func builtinFloat(_ Runtime, args ...Object) Object {
+	if n := len(args); n != 1 {
+		return NewError("function `float` supports just one argument. got=%d", n)
+	}
+
+	switch arg := args[0].(type) {
+	case *Integer:
+		return &Float{Value: float64(arg.Value)}
+	case *Float:
+		return arg
+	case *String:
+		v, err := strconv.ParseFloat(strings.TrimSpace(arg.Value), 64)
+		if err != nil {
+			return NewError("could not parse %q as a float", arg.Value)
+		}
+
+		return &Float{Value: v}
+	}
+
+	return NewError("argument to `float` not supported. got=%s", args[0].Inspect())
+}
+
+// Creates a builtin that rounds a number to an Integer
+func roundingBuiltin(name string, round func(float64) float64) BuiltInFunction {
+	return func(_ Runtime, args ...Object) Object {
+		if n := len(args); n != 1 {
+			return NewError("function `%s` supports just one argument. got=%d", name, n)
+		}
+
+		switch arg := args[0].(type) {
+		case *Integer:
+			return arg
+		case *Float:
+			return floatToInteger(name, round(arg.Value))
+		}
+
+		return NewError("argument to `%s` must be a number. got=%s", name, args[0].Inspect())
+	}
+}
@@ -2,9 +2,17 @@ package object
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/dyxgou/parser/src/ast"
+	"github.com/dyxgou/parser/src/token"
+)
+
+var (
+	NULL  = &Null{}
+	TRUE  = &Boolean{Value: true}
+	FALSE = &Boolean{Value: false}
 )
 
 type Object interface {
@@ -13,7 +21,7 @@ type Object interface {
 	Inspect() string
 }
 
-type BuiltInFunction func(args ...Object) Object
+type BuiltInFunction func(rt Runtime, args ...Object) Object
 
 type BuiltIn struct {
 	Fn BuiltInFunction
@@ -21,7 +29,7 @@ type BuiltIn struct {
 
 func (*BuiltIn) Type() ObjectType { return BuiltInType }
 func (*BuiltIn) Inspect() string  { return BuiltInStr }
-func (b *BuiltIn) String() string { return b.String() }
+func (b *BuiltIn) String() string { return "builtin function" }
 
 type Integer struct {
 	Value int64
@@ -31,6 +39,23 @@ func (*Integer) Type() ObjectType { return IntegerType }
 func (*Integer) Inspect() string  { return IntegerStr }
 func (i *Integer) String() string { return fmt.Sprintf("%d", i.Value) }
 
+type Float struct {
+	Value float64
+}
+
+func (*Float) Type() ObjectType { return FloatType }
+func (*Float) Inspect() string  { return FloatStr }
+
+// A float always shows a decimal point or an exponent, so 2.0 isn't confused with 2
+func (f *Float) String() string {
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if strings.ContainsAny(s, ".eIN") {
+		return s
+	}
+
+	return s + ".0"
+}
+
 type String struct {
 	Value string
 }
@@ -63,12 +88,55 @@ func (o *ReturnValue) String() string   { return o.Value.Inspect() }
 
 type Error struct {
 	Message string
+	Pos     token.Position // position of the node that caused the error
+	Stack   []StackFrame   // functions being called when the error happened, the innermost first
+	Cause   error          // error behind it, like ErrCancelled or the one of a Go function
+}
+
+// StackFrame is a call to a function
+type StackFrame struct {
+	Function string         // name the function was called with
+	Pos      token.Position // position of the call
+}
+
+func (f StackFrame) String() string {
+	return fmt.Sprintf("%s (%s)", f.Function, f.Pos)
 }
 
+// TailCall is a call in tail position, the evaluator runs it once the current function returns
+// so the recursion doesn't grow the stack
+type TailCall struct {
+	Fn     Object
+	Args   []Object
+	Caller StackFrame
+}
+
+func (*TailCall) Type() ObjectType { return TailCallType }
+func (*TailCall) Inspect() string  { return TailCallStr }
+func (*TailCall) String() string   { return TailCallStr }
+
 func (_ *Error) Type() ObjectType { return ErrorType }
 func (_ *Error) Inspect() string  { return ErrorStr }
 func (o *Error) String() string   { return fmt.Sprintf("ERROR : %s", o.Message) }
 
+// Error lets the engines hand an *Error to Go code as an error
+func (o *Error) Error() string { return o.Message }
+func (o *Error) Unwrap() error { return o.Cause }
+
+func NewError(format string, a ...any) *Error {
+	return &Error{
+		Message: fmt.Sprintf(format, a...),
+	}
+}
+
+func NativeBoolToBooleanObject(input bool) *Boolean {
+	if input {
+		return TRUE
+	}
+
+	return FALSE
+}
+
 type Function struct {
 	Parameters []*ast.Identifier
 	Body       *ast.BlockStatement
@@ -85,7 +153,7 @@ func (o *Function) String() string {
 	sb.WriteByte('(')
 	for i, param := range o.Parameters {
 		if i > 0 && i < len(o.Parameters) {
-			sb.WriteString(" ,")
+			sb.WriteString(", ")
 		}
 
 		sb.WriteString(param.String())
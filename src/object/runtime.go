@@ -0,0 +1,90 @@
+package object
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Runtime is the engine that calls a builtin
+type Runtime interface {
+	// Context is done once the program must stop, a builtin that can take long checks it
+	Context() context.Context
+
+	// Apply calls a function of the program or a builtin, an error of the call is returned as an *Error
+	Apply(fn Object, args ...Object) Object
+
+	// Stdout is where the program prints
+	Stdout() io.Writer
+
+	// Stdin is where the program reads its input from
+	Stdin() *bufio.Reader
+}
+
+// IO holds the streams of a program, the ones left nil are the ones of the process.
+// The engines embed it to implement Stdout and Stdin of the Runtime
+type IO struct {
+	Out io.Writer
+	In  io.Reader // read through a buffer created on the first read, so it can't be changed after it
+
+	in *bufio.Reader
+}
+
+func (s *IO) Stdout() io.Writer {
+	if s.Out == nil {
+		return os.Stdout
+	}
+
+	return s.Out
+}
+
+func (s *IO) Stdin() *bufio.Reader {
+	if s.in == nil {
+		in := s.In
+		if in == nil {
+			in = os.Stdin
+		}
+
+		// a reader that is already a *bufio.Reader is used as it is, keeping what it buffered
+		s.in = bufio.NewReader(in)
+	}
+
+	return s.in
+}
+
+// DefaultMaxCallDepth is the number of nested calls the engines allow when they aren't given another limit
+const DefaultMaxCallDepth = 10000
+
+var (
+	ErrCancelled      = errors.New("execution cancelled")
+	ErrBudgetExceeded = errors.New("execution budget exceeded")
+)
+
+// Returns the error that stops a program whose context is done, nil while it can go on
+func ContextError(ctx context.Context) *Error {
+	err := ctx.Err()
+	if err == nil {
+		return nil
+	}
+
+	return &Error{
+		Message: fmt.Sprintf("%s: %s", ErrCancelled, err),
+		Cause:   fmt.Errorf("%w: %w", ErrCancelled, err),
+	}
+}
+
+// Returns the error that stops a program that ran more steps than allowed
+func BudgetError(maxSteps int) *Error {
+	return &Error{
+		Message: fmt.Sprintf("%s: more than %d steps", ErrBudgetExceeded, maxSteps),
+		Cause:   ErrBudgetExceeded,
+	}
+}
+
+// Returns the error of a call nested deeper than the maxDepth calls allowed
+func CallDepthError(maxDepth int) *Error {
+	return NewError("maximum call depth of %d exceeded", maxDepth)
+}
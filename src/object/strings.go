@@ -0,0 +1,207 @@
+package object
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// The strings are indexed by characters (runes) rather than bytes
+
+// Returns the character at the index i of s
+func StringIndex(s *String, i int64) Object {
+	var j int64
+	for _, r := range s.Value {
+		if j == i {
+			return &String{Value: string(r)}
+		}
+
+		j++
+	}
+
+	return NewError("index out of bounds. got=%d", i)
+}
+
+// Returns the characters of a string or the elements of an array from low up to high, a NULL bound
+// is the start or the end
+func Slice(left, low, high Object) Object {
+	var length int64
+
+	switch left := left.(type) {
+	case *String:
+		length = int64(utf8.RuneCountInString(left.Value))
+	case *Array:
+		length = int64(len(left.Elements))
+	default:
+		return NewError("slice operator not supported: %s", left.Inspect())
+	}
+
+	from, err := sliceBound(low, 0)
+	if err != nil {
+		return err
+	}
+
+	to, err := sliceBound(high, length)
+	if err != nil {
+		return err
+	}
+
+	if from < 0 || to > length || from > to {
+		return NewError("slice bounds out of range [%d:%d] with length %d", from, to, length)
+	}
+
+	if arr, ok := left.(*Array); ok {
+		elems := make([]Object, to-from)
+		copy(elems, arr.Elements[from:to])
+
+		return &Array{Elements: elems}
+	}
+
+	return &String{Value: string([]rune(left.(*String).Value)[from:to])}
+}
+
+func sliceBound(bound Object, def int64) (int64, *Error) {
+	switch bound := bound.(type) {
+	case *Null:
+		return def, nil
+	case *Integer:
+		return bound.Value, nil
+	}
+
+	return 0, NewError("slice index must be an integer. got=%s", bound.Inspect())
+}
+
+// Returns a builtin that maps a string to another one
+func stringBuiltin(name string, fn func(string) string) BuiltInFunction {
+	return func(_ Runtime, args ...Object) Object {
+		if err := checkArgs(name, args, 1, StringType); err != nil {
+			return err
+		}
+
+		return &String{Value: fn(args[0].(*String).Value)}
+	}
+}
+
+// Returns a builtin that tests a string with another one
+func stringTestBuiltin(name string, fn func(s, sub string) bool) BuiltInFunction {
+	return func(_ Runtime, args ...Object) Object {
+		if err := checkArgs(name, args, 2, StringType, StringType); err != nil {
+			return err
+		}
+
+		return NativeBoolToBooleanObject(fn(args[0].(*String).Value, args[1].(*String).Value))
+	}
+}
+
+func builtinSplit(_ Runtime, args ...Object) Object {
+	if err := checkArgs("split", args, 2, StringType, StringType); err != nil {
+		return err
+	}
+
+	parts := strings.Split(args[0].(*String).Value, args[1].(*String).Value)
+
+	elems := make([]Object, len(parts))
+	for i, part := range parts {
+		elems[i] = &String{Value: part}
+	}
+
+	return &Array{Elements: elems}
+}
+
+func builtinJoin(_ Runtime, args ...Object) Object {
+	if err := checkArgs("join", args, 2, ArrayType, StringType); err != nil {
+		return err
+	}
+
+	elems := args[0].(*Array).Elements
+
+	parts := make([]string, len(elems))
+	for i, elem := range elems {
+		s, ok := elem.(*String)
+		if !ok {
+			return NewError("function `join` expected an array of strings. got=%s at index %d", elem.Inspect(), i)
+		}
+
+		parts[i] = s.Value
+	}
+
+	return &String{Value: strings.Join(parts, args[1].(*String).Value)}
+}
+
+// trim(s) removes the white space around s, trim(s, chars) removes the given characters instead
+func builtinTrim(_ Runtime, args ...Object) Object {
+	if err := checkArgs("trim", args, 1, StringType, StringType); err != nil {
+		return err
+	}
+
+	s := args[0].(*String).Value
+	if len(args) == 2 {
+		return &String{Value: strings.Trim(s, args[1].(*String).Value)}
+	}
+
+	return &String{Value: strings.TrimSpace(s)}
+}
+
+func builtinReplace(_ Runtime, args ...Object) Object {
+	if err := checkArgs("replace", args, 3, StringType, StringType, StringType); err != nil {
+		return err
+	}
+
+	s, old, replacement := args[0].(*String).Value, args[1].(*String).Value, args[2].(*String).Value
+
+	return &String{Value: strings.ReplaceAll(s, old, replacement)}
+}
+
+// Returns the index of the first character of sub inside s or -1 when it's not there
+func builtinIndexOf(_ Runtime, args ...Object) Object {
+	if err := checkArgs("index_of", args, 2, StringType, StringType); err != nil {
+		return err
+	}
+
+	s, sub := args[0].(*String).Value, args[1].(*String).Value
+
+	i := strings.Index(s, sub)
+	if i < 0 {
+		return &Integer{Value: -1}
+	}
+
+	return &Integer{Value: int64(utf8.RuneCountInString(s[:i]))}
+}
+
+// substr(s, start) returns the characters from start to the end, substr(s, start, length) just length of them
+func builtinSubstr(_ Runtime, args ...Object) Object {
+	if err := checkArgs("substr", args, 2, StringType, IntegerType, IntegerType); err != nil {
+		return err
+	}
+
+	start := args[1].(*Integer)
+	if len(args) == 2 {
+		return Slice(args[0], start, NULL)
+	}
+
+	length := args[2].(*Integer).Value
+	if length < 0 {
+		return NewError("function `substr` expected a length that isn't negative. got=%d", length)
+	}
+
+	return Slice(args[0], start, &Integer{Value: start.Value + length})
+}
+
+// the longest string repeat can build, so a script can't take all the memory of the host with one call
+const maxRepeatLength = 1 << 26
+
+func builtinRepeat(_ Runtime, args ...Object) Object {
+	if err := checkArgs("repeat", args, 2, StringType, IntegerType); err != nil {
+		return err
+	}
+
+	s, count := args[0].(*String).Value, args[1].(*Integer).Value
+	if count < 0 {
+		return NewError("function `repeat` expected a count that isn't negative. got=%d", count)
+	}
+
+	if len(s) > 0 && count > maxRepeatLength/int64(len(s)) {
+		return NewError("function `repeat` would build a string longer than %d bytes", maxRepeatLength)
+	}
+
+	return &String{Value: strings.Repeat(s, int(count))}
+}
@@ -0,0 +1,206 @@
+// Package optimizer simplifies a program before running it without changing what it does, the errors
+// included: an expression that fails, like a division by zero, is left for the engine to report
+package optimizer
+
+import (
+	"strconv"
+
+	"github.com/dyxgou/parser/src/ast"
+	"github.com/dyxgou/parser/src/token"
+)
+
+// Rewrites the program in place folding the operations between literals, dropping the branches of the ifs
+// whose condition is a literal and the statements of a block after a return, break or continue. Returns
+// the same program
+func Optimize(program *ast.Program) *ast.Program {
+	ast.Rewrite(program, optimize)
+
+	return program
+}
+
+// The children are optimized before their parent, so the folded literals are folded again inside it
+func optimize(node ast.Node) ast.Node {
+	switch node := node.(type) {
+	case *ast.PrefixExpression:
+		if folded := foldPrefix(node); folded != nil {
+			return folded
+		}
+	case *ast.InfixExpression:
+		if folded := foldInfix(node); folded != nil {
+			return folded
+		}
+	case *ast.IfExpression:
+		dropDeadBranch(node)
+	case *ast.BlockStatement:
+		node.Statements = reachable(node.Statements)
+	}
+
+	return node
+}
+
+// Returns the literal with the value of the prefix expression, nil when it can't be known before running it
+func foldPrefix(exp *ast.PrefixExpression) ast.Expression {
+	switch exp.Operator() {
+	case "-":
+		if right, ok := exp.Right.(*ast.IntegerLiteral); ok {
+			return integer(exp, -right.Value)
+		}
+	case "!":
+		if truthy, ok := isTruthy(exp.Right); ok {
+			return boolean(exp, !truthy)
+		}
+	}
+
+	return nil
+}
+
+// Returns the literal with the value of the infix expression, nil when it can't be known before running it
+// or when running it fails
+func foldInfix(exp *ast.InfixExpression) ast.Expression {
+	op := exp.Operator()
+
+	// the logical operators take any value, the result is a boolean
+	if op == "&&" || op == "||" {
+		left, leftOk := isTruthy(exp.Left)
+		if !leftOk {
+			return nil
+		}
+
+		// the right side isn't run when the left one decides the result
+		if op == "&&" && !left || op == "||" && left {
+			return boolean(exp, left)
+		}
+
+		right, rightOk := isTruthy(exp.Right)
+		if !rightOk {
+			return nil
+		}
+
+		return boolean(exp, right)
+	}
+
+	switch left := exp.Left.(type) {
+	case *ast.IntegerLiteral:
+		if right, ok := exp.Right.(*ast.IntegerLiteral); ok {
+			return foldIntegers(exp, op, left.Value, right.Value)
+		}
+	case *ast.StringLiteral:
+		if right, ok := exp.Right.(*ast.StringLiteral); ok {
+			return foldStrings(exp, op, left.Value(), right.Value())
+		}
+	case *ast.Boolean:
+		if right, ok := exp.Right.(*ast.Boolean); ok {
+			switch op {
+			case "==":
+				return boolean(exp, left.Value == right.Value)
+			case "!=":
+				return boolean(exp, left.Value != right.Value)
+			}
+		}
+	}
+
+	return nil
+}
+
+func foldIntegers(exp ast.Expression, op string, left, right int64) ast.Expression {
+	switch op {
+	case "+":
+		return integer(exp, left+right)
+	case "-":
+		return integer(exp, left-right)
+	case "*":
+		return integer(exp, left*right)
+	case "/":
+		if right != 0 {
+			return integer(exp, left/right)
+		}
+	case "%":
+		if right != 0 {
+			return integer(exp, left%right)
+		}
+	case "==":
+		return boolean(exp, left == right)
+	case "!=":
+		return boolean(exp, left != right)
+	case "<":
+		return boolean(exp, left < right)
+	case "<=":
+		return boolean(exp, left <= right)
+	case ">":
+		return boolean(exp, left > right)
+	case ">=":
+		return boolean(exp, left >= right)
+	}
+
+	return nil
+}
+
+func foldStrings(exp ast.Expression, op string, left, right string) ast.Expression {
+	switch op {
+	case "+":
+		return &ast.StringLiteral{Token: literalToken(exp, token.STRING, left+right)}
+	case "==":
+		return boolean(exp, left == right)
+	case "!=":
+		return boolean(exp, left != right)
+	}
+
+	return nil
+}
+
+// Keeps just the branch of the if that runs when its condition is a literal, an empty block takes the
+// place of a consequence that never runs
+func dropDeadBranch(exp *ast.IfExpression) {
+	truthy, ok := isTruthy(exp.Condition)
+	if !ok {
+		return
+	}
+
+	if truthy {
+		exp.Alternative = nil
+	} else if exp.Consequence != nil {
+		exp.Consequence = &ast.BlockStatement{Token: exp.Consequence.Token, Rbrace: exp.Consequence.Rbrace}
+	}
+}
+
+// Returns the statements up to the first one leaving the block, the ones after it never run
+func reachable(stmts []ast.Statement) []ast.Statement {
+	for i, stmt := range stmts {
+		switch stmt.(type) {
+		case *ast.ReturnStatement, *ast.BreakStatement, *ast.ContinueStatement:
+			return stmts[:i+1]
+		}
+	}
+
+	return stmts
+}
+
+// Tells if the value of a literal is truthy, ok is false for the expressions that aren't literals
+func isTruthy(exp ast.Expression) (truthy bool, ok bool) {
+	switch exp := exp.(type) {
+	case *ast.Boolean:
+		return exp.Value, true
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral:
+		return true, true
+	}
+
+	return false, false
+}
+
+// The folded literals take the place of the whole expression in the source
+
+func literalToken(exp ast.Expression, kind token.TokenKind, literal string) token.Token {
+	return token.Token{Kind: kind, Literal: literal, Pos: exp.Pos(), End: exp.End()}
+}
+
+func integer(exp ast.Expression, v int64) *ast.IntegerLiteral {
+	return &ast.IntegerLiteral{Token: literalToken(exp, token.INT, strconv.FormatInt(v, 10)), Value: v}
+}
+
+func boolean(exp ast.Expression, v bool) *ast.Boolean {
+	if v {
+		return &ast.Boolean{Token: literalToken(exp, token.TRUE, "true"), Value: true}
+	}
+
+	return &ast.Boolean{Token: literalToken(exp, token.FALSE, "false"), Value: false}
+}
@@ -0,0 +1,61 @@
+package optimizer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dyxgou/parser/src/ast"
+	"github.com/dyxgou/parser/src/compiler"
+	"github.com/dyxgou/parser/src/evaluator"
+	"github.com/dyxgou/parser/src/lexer"
+	"github.com/dyxgou/parser/src/object"
+	"github.com/dyxgou/parser/src/parser"
+	"github.com/dyxgou/parser/src/vm"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+
+	if p.ErrorsLen() != 0 {
+		t.Fatalf("%q: parser had errors: %v", input, p.Errors())
+	}
+
+	return program
+}
+
+// Returns the result of the program on the evaluator and on the vm, the errors with their position
+func run(t *testing.T, program *ast.Program) (string, string) {
+	t.Helper()
+
+	evaluated := evaluator.New().Eval(program, object.NewEnviroment())
+
+	comp := compiler.New()
+	if err := comp.Compile(program); err != nil {
+		return describe(evaluated), "compile error: " + err.Error()
+	}
+
+	machine := vm.New(comp.Bytecode())
+	if err := machine.Run(); err != nil {
+		if objErr, ok := err.(*object.Error); ok {
+			return describe(evaluated), describe(objErr)
+		}
+
+		return describe(evaluated), err.Error()
+	}
+
+	return describe(evaluated), describe(machine.Result())
+}
+
+func describe(obj object.Object) string {
+	switch obj := obj.(type) {
+	case nil:
+		return "nil"
+	case *object.Error:
+		return fmt.Sprintf("ERROR %s at %s", obj.Message, obj.Pos)
+	}
+
+	return fmt.Sprintf("%s %s", obj.Inspect(), obj.String())
+}
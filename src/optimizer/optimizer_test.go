@@ -0,0 +1,76 @@
+package optimizer
+
+import (
+	"testing"
+
+	"github.com/dyxgou/parser/src/format"
+)
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2 * 60 * 60", "7200;\n"},
+		{"let x = 1 + 2 * 3 - -4", "let x = 11;\n"},
+		{"7 / 2; 7 % 2; 1 < 2; 2 <= 1; 3 > 2; 3 >= 4; 1 == 1; 1 != 1", "3;\n1;\ntrue;\nfalse;\ntrue;\nfalse;\ntrue;\nfalse;\n"},
+		{`"a" + "b" + "c"; "a" == "a"; "a" != "a"`, "\"abc\";\ntrue;\nfalse;\n"},
+		{"!true; !0; !\"\"; true == false; true != false", "false;\nfalse;\nfalse;\nfalse;\ntrue;\n"},
+		{"1 < 2 && 3 > 4; false && f(); true || f(); 1 && \"a\"; x && true", "false;\nfalse;\ntrue;\ntrue;\nx && true;\n"},
+		// the operations that fail or that need the value of a name are left as they are
+		{"1 / 0; 1 % (2 - 2); \"a\" + 1; \"a\" - \"b\"; x + 1 + 2; 1.5 + 1", "1 / 0;\n1 % 0;\n\"a\" + 1;\n\"a\" - \"b\";\nx + 1 + 2;\n1.5 + 1;\n"},
+		{"let f = fn(x) { x * (60 * 60) }", "let f = fn(x) { x * 3600 };\n"},
+		{"if (1 < 2) { a } else { b }", "if (true) { a }\n"},
+		{"if (\"\" == \"x\") { a } else { b }", "if (false) {} else { b }\n"},
+		{"if (x) { a } else { b }", "if (x) { a } else { b }\n"},
+		{"let f = fn() {\n  g();\n  return 1;\n  g();\n  return 2;\n}", "let f = fn() {\n  g();\n  return 1;\n};\n"},
+		{"while (x) {\n  break;\n  y\n}\nfor (i in a) {\n  if (i) {\n    continue;\n    z\n  }\n}", "while (x) {\n  break;\n}\nfor (i in a) {\n  if (i) {\n    continue;\n  }\n}\n"},
+	}
+
+	for _, tt := range tests {
+		program := Optimize(parse(t, tt.input))
+
+		if got := format.Node(program); got != tt.expected {
+			t.Errorf("%q: expected=%q. got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+// The optimized programs give the same results and the same errors, at the same positions, on both engines
+func TestOptimizeKeepsBehavior(t *testing.T) {
+	tests := []string{
+		"2 * 60 * 60",
+		"let x = 9223372036854775807 + 1; x",
+		"-9223372036854775807 - 1 - 1",
+		"(0 - 9223372036854775807 - 1) / -1",
+		"let f = fn(n) { n + 10 / 0 }; f(1)",
+		"10 % (5 - 5)",
+		`"a" + 1`,
+		`"a" - "b"`,
+		"-\"a\"",
+		"true + false",
+		"if (1 > 2) { 1 }",
+		"if (1 < 2) { 1 } else { 2 }",
+		"if (0) { let y = 1 } else { let y = 2 }; y",
+		"let f = fn() { return 1; 1 / 0 }; f()",
+		"let f = fn(n) { if (n > 1 + 1) { return n * 2; n } else { return 0 - n; } }; [f(1), f(5)]",
+		"let total = 0; for (i in range(10)) { if (i % 2 == 0) { continue; total = 100 } total += i; if (i > 3 * 2) { break; total = 0 } }; total",
+		`let s = "a" + "b"; s == "ab" && !false`,
+		"false && 1 / 0",
+		"1 == 1 || missing",
+		"if (!(1 == 2)) { \"yes\" } else { \"no\" }",
+	}
+
+	for _, input := range tests {
+		expectedEval, expectedVM := run(t, parse(t, input))
+		gotEval, gotVM := run(t, Optimize(parse(t, input)))
+
+		if gotEval != expectedEval {
+			t.Errorf("%q on the evaluator: expected=%s. got=%s", input, expectedEval, gotEval)
+		}
+
+		if gotVM != expectedVM {
+			t.Errorf("%q on the vm: expected=%s. got=%s", input, expectedVM, gotVM)
+		}
+	}
+}
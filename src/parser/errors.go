@@ -0,0 +1,144 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/dyxgou/parser/src/token"
+)
+
+// Error is a parsing error placed in the source, Err is the kind of failure behind it,
+// like an *UnexpectedTokenError, and can be reached with errors.As
+type Error struct {
+	Pos token.Position
+	Msg string
+	Err error
+
+	src string // source being parsed, used to show the offending line
+}
+
+func (e *Error) Error() string {
+	if snippet := e.Pos.Snippet(e.src); snippet != "" {
+		return fmt.Sprintf("%s: %s\n%s", e.Pos, e.Msg, snippet)
+	}
+
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// The kinds of failure, every one of them knows where it happened
+
+// kindError is implemented by the kinds of failure
+type kindError interface {
+	error
+	position() token.Position
+}
+
+// SyntaxError is a malformed piece of source found by the lexer, like a string without its closing quote
+type SyntaxError struct {
+	Pos token.Position
+	Msg string
+}
+
+func (e *SyntaxError) Error() string            { return e.Msg }
+func (e *SyntaxError) position() token.Position { return e.Pos }
+
+// UnexpectedTokenError is a token found where another one was expected
+type UnexpectedTokenError struct {
+	Pos      token.Position
+	Expected string // what should have been there, like ")" or "variable_name"
+	Got      token.Token
+}
+
+func (e *UnexpectedTokenError) Error() string {
+	return fmt.Sprintf("expected next token to be %q got=%q", e.Expected, tokenText(e.Got))
+}
+
+func (e *UnexpectedTokenError) position() token.Position { return e.Pos }
+
+// NoPrefixParseFnError is a token that can't start an expression
+type NoPrefixParseFnError struct {
+	Pos   token.Position
+	Token token.Token
+}
+
+func (e *NoPrefixParseFnError) Error() string {
+	return fmt.Sprintf("no prefix parse function for %q found", e.Token.Kind)
+}
+
+func (e *NoPrefixParseFnError) position() token.Position { return e.Pos }
+
+// InvalidLiteralError is a number that doesn't fit in its type
+type InvalidLiteralError struct {
+	Pos     token.Position
+	Literal string
+	Type    string // Integer or Float
+}
+
+func (e *InvalidLiteralError) Error() string {
+	article := "a"
+	if e.Type == "Integer" {
+		article = "an"
+	}
+
+	return fmt.Sprintf("could not parse %s into %s %s", e.Literal, article, e.Type)
+}
+
+func (e *InvalidLiteralError) position() token.Position { return e.Pos }
+
+// InvalidAssignmentError is an assignment to something that isn't a variable or an index
+type InvalidAssignmentError struct {
+	Pos    token.Position
+	Target string
+}
+
+func (e *InvalidAssignmentError) Error() string {
+	return fmt.Sprintf("cannot assign to %s", e.Target)
+}
+
+func (e *InvalidAssignmentError) position() token.Position { return e.Pos }
+
+// LoopControlError is a break or a continue outside of a loop
+type LoopControlError struct {
+	Pos     token.Position
+	Keyword string
+}
+
+func (e *LoopControlError) Error() string {
+	return fmt.Sprintf("%s outside of a loop", e.Keyword)
+}
+
+func (e *LoopControlError) position() token.Position { return e.Pos }
+
+// InvalidImportError is an import whose argument isn't a string
+type InvalidImportError struct {
+	Pos token.Position
+	Got token.Token
+}
+
+func (e *InvalidImportError) Error() string {
+	return fmt.Sprintf("import expects the path of a file as a string. got=%q", tokenText(e.Got))
+}
+
+func (e *InvalidImportError) position() token.Position { return e.Pos }
+
+// TooManyErrorsError is reported instead of the error that went past the limit of the parser, which stops after it
+type TooManyErrorsError struct {
+	Pos token.Position
+	Max int
+}
+
+func (e *TooManyErrorsError) Error() string {
+	return fmt.Sprintf("too many errors, stopped after %d", e.Max)
+}
+
+func (e *TooManyErrorsError) position() token.Position { return e.Pos }
+
+// Returns the literal of the token or its name when it has none, like the EOF
+func tokenText(t token.Token) string {
+	if t.Literal == "" {
+		return t.Kind.String()
+	}
+
+	return t.Literal
+}
@@ -18,6 +18,9 @@ type Precendence byte
 
 const (
 	LOWEST Precendence = iota
+	ASSIGN
+	OR
+	AND
 	EQUALS
 	LESSGREATER
 	SUM
@@ -28,32 +31,61 @@ const (
 )
 
 var precendences = map[token.TokenKind]Precendence{
-	token.EQUAL:          EQUALS,
-	token.NOT_EQUAL:      EQUALS,
-	token.LESS:           LESSGREATER,
-	token.GREATER:        LESSGREATER,
-	token.PLUS:           SUM,
-	token.MINUS:          SUM,
-	token.DIVISION:       PRODUCT,
-	token.MULTIPLICATION: PRODUCT,
-	token.LPAREN:         CALL,
-	token.LBRACKET:       INDEX,
-}
+	token.ASSIGN:                ASSIGN,
+	token.PLUS_ASSIGN:           ASSIGN,
+	token.MINUS_ASSIGN:          ASSIGN,
+	token.MULTIPLICATION_ASSIGN: ASSIGN,
+	token.DIVISION_ASSIGN:       ASSIGN,
+	token.MODULO_ASSIGN:         ASSIGN,
+	token.OR:                    OR,
+	token.AND:                   AND,
+	token.EQUAL:                 EQUALS,
+	token.NOT_EQUAL:             EQUALS,
+	token.LESS:                  LESSGREATER,
+	token.GREATER:               LESSGREATER,
+	token.LESS_EQUAL:            LESSGREATER,
+	token.GREATER_EQUAL:         LESSGREATER,
+	token.PLUS:                  SUM,
+	token.MINUS:                 SUM,
+	token.DIVISION:              PRODUCT,
+	token.MULTIPLICATION:        PRODUCT,
+	token.MODULO:                PRODUCT,
+	token.LPAREN:                CALL,
+	token.LBRACKET:              INDEX,
+	token.DOT:                   INDEX,
+}
+
+// Returns the precedence of the infix operator k, LOWEST when k isn't one
+func InfixPrecedence(k token.TokenKind) Precendence {
+	return precendences[k]
+}
+
+// DefaultMaxErrors is the number of errors after which a new Parser stops
+const DefaultMaxErrors = 10
 
 type Parser struct {
+	// MaxErrors is the number of errors after which the parser stops parsing, zero means no limit
+	MaxErrors int
+
 	l *lexer.Lexer
 
-	errors []error
+	errors    []error
+	panicking bool // an error was found in the current statement, the errors that follow it are dropped
+	stopped   bool // too many errors were found
+	blocks    int  // number of blocks enclosing the current token
 
 	curToken  token.Token
 	readToken token.Token
 
+	loops int // number of loops enclosing the current token inside the current function
+
 	prefixParseFns map[token.TokenKind]prefixParseFn
 	infixParseFns  map[token.TokenKind]infixParseFn
 }
 
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
+		MaxErrors:      DefaultMaxErrors,
 		l:              l,
 		prefixParseFns: make(map[token.TokenKind]prefixParseFn, 20),
 		infixParseFns:  make(map[token.TokenKind]infixParseFn, 20),
@@ -61,6 +93,7 @@ func New(l *lexer.Lexer) *Parser {
 
 	// Prefix Funcs
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.NOT, p.parsePrefixExpression)
@@ -71,6 +104,8 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.IF, p.parseIfExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionExpression)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
+	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
+	p.registerPrefix(token.IMPORT, p.parseImportExpression)
 
 	// Infix Funcs
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
@@ -81,8 +116,25 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.NOT_EQUAL, p.parseInfixExpression)
 	p.registerInfix(token.LESS, p.parseInfixExpression)
 	p.registerInfix(token.GREATER, p.parseInfixExpression)
+	p.registerInfix(token.LESS_EQUAL, p.parseInfixExpression)
+	p.registerInfix(token.GREATER_EQUAL, p.parseInfixExpression)
+	p.registerInfix(token.MODULO, p.parseInfixExpression)
+	p.registerInfix(token.AND, p.parseInfixExpression)
+	p.registerInfix(token.OR, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.DOT, p.parseMemberExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PLUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MINUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MULTIPLICATION_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.DIVISION_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MODULO_ASSIGN, p.parseAssignExpression)
+
+	// the lexer reads ahead of the statement being parsed, so its errors are never dropped
+	l.SetErrorHandler(func(pos token.Position, msg string) {
+		p.addError(&SyntaxError{Pos: pos, Msg: msg})
+	})
 
 	p.nextToken()
 	p.nextToken()
@@ -129,15 +181,78 @@ func (p *Parser) expectRead(k token.TokenKind) bool {
 	return false
 }
 
-func (p *Parser) notExpectedTokenErr(expected string, got string) {
-	err := fmt.Errorf("expected next token to be %q got=%q", expected, got)
+// Records the error of the statement being parsed, the ones after the first are dropped because they are
+// most likely caused by it
+func (p *Parser) fail(err kindError) {
+	if p.panicking {
+		return
+	}
 
-	p.errors = append(p.errors, err)
+	p.panicking = true
+	p.addError(err)
 }
 
-func (p *Parser) notPrefixParseFnError(t token.TokenKind) {
-	err := fmt.Errorf("no prefix parse function for %d found", t)
-	p.errors = append(p.errors, err)
+func (p *Parser) addError(err kindError) {
+	if p.stopped {
+		return
+	}
+
+	if p.MaxErrors > 0 && len(p.errors) == p.MaxErrors {
+		err = &TooManyErrorsError{Pos: err.position(), Max: p.MaxErrors}
+		p.stopped = true
+	}
+
+	p.errors = append(p.errors, &Error{
+		Pos: err.position(),
+		Msg: err.Error(),
+		Err: err,
+		src: p.l.Input(),
+	})
+}
+
+func (p *Parser) notExpectedTokenErr(expected string, got token.Token) {
+	p.fail(&UnexpectedTokenError{Pos: got.Pos, Expected: expected, Got: got})
+}
+
+func (p *Parser) notPrefixParseFnError(t token.Token) {
+	p.fail(&NoPrefixParseFnError{Pos: t.Pos, Token: t})
+}
+
+// Skips the rest of a statement with an error, stopping before a token where a new statement can start.
+// The braces opened while skipping are skipped up to their closing one
+func (p *Parser) synchronize() {
+	depth := 0
+
+	for !p.readTokenIs(token.EOF) {
+		if depth == 0 {
+			if p.curTokenIs(token.SEMI) || startsStatement(p.readToken.Kind) {
+				return
+			}
+
+			// the brace that closes the block around the statement ends the block
+			if p.readTokenIs(token.RBRACE) && p.blocks > 0 {
+				return
+			}
+		}
+
+		p.nextToken()
+
+		switch {
+		case p.curTokenIs(token.LBRACE):
+			depth++
+		case p.curTokenIs(token.RBRACE) && depth > 0:
+			depth--
+		}
+	}
+}
+
+func startsStatement(k token.TokenKind) bool {
+	switch k {
+	case token.LET, token.RETURN, token.IF, token.WHILE, token.FOR, token.BREAK, token.CONTINUE:
+		return true
+	}
+
+	return false
 }
 
 func (p *Parser) Errors() []error {
@@ -167,10 +282,8 @@ func (p *Parser) curPrecendence() Precendence {
 func (p *Parser) ParseProgram() *ast.Program {
 	program := ast.NewProgram()
 
-	for p.curToken.Kind != token.EOF {
-		stmt := p.parseStatement()
-
-		if stmt != nil {
+	for p.curToken.Kind != token.EOF && !p.stopped {
+		if stmt := p.parseNextStatement(); stmt != nil {
 			program.Statements = append(program.Statements, stmt)
 		}
 
@@ -180,12 +293,36 @@ func (p *Parser) ParseProgram() *ast.Program {
 	return program
 }
 
+// Parses a statement, a statement with errors is skipped and nil is returned
+func (p *Parser) parseNextStatement() ast.Statement {
+	p.panicking = false
+	stmt := p.parseStatement()
+
+	if p.panicking {
+		p.synchronize()
+		return nil
+	}
+
+	return stmt
+}
+
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Kind {
 	case token.LET:
-		return p.parseLetStatement()
+		// avoid returning a typed nil when the let statement is malformed
+		if stmt := p.parseLetStatement(); stmt != nil {
+			return stmt
+		}
+
+		return nil
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.WHILE:
+		return p.parseWhileStatement()
+	case token.FOR:
+		return p.parseForStatement()
+	case token.BREAK, token.CONTINUE:
+		return p.parseLoopControlStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -215,7 +352,7 @@ func (p *Parser) parseExpression(pr Precendence) ast.Expression {
 	prefixFn, ok := p.prefixParseFns[p.curToken.Kind]
 
 	if !ok {
-		p.notPrefixParseFnError(p.curToken.Kind)
+		p.notPrefixParseFnError(p.curToken)
 		return nil
 	}
 
@@ -262,18 +399,39 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return exp
 }
 
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	exp := &ast.AssignExpression{
+		Token:  p.curToken,
+		Target: target,
+	}
+
+	switch target.(type) {
+	case *ast.Identifier, *ast.IndexExpression:
+	default:
+		p.fail(&InvalidAssignmentError{Pos: p.curToken.Pos, Target: fmt.Sprint(target)})
+		return nil
+	}
+
+	p.nextToken()
+
+	// assignments are right associative, a = b = c assigns c to b and then to a
+	exp.Value = p.parseExpression(ASSIGN - 1)
+
+	return exp
+}
+
 func (p *Parser) parseLetStatement() *ast.LetStatement {
 	letStmt := &ast.LetStatement{Token: p.curToken}
 
 	if !p.expectRead(token.IDENT) {
-		p.notExpectedTokenErr("variable_name", p.curToken.Literal)
+		p.notExpectedTokenErr("variable_name", p.readToken)
 		return nil
 	}
 
 	letStmt.Name = &ast.Identifier{Token: p.curToken}
 
 	if !p.expectRead(token.ASSIGN) {
-		p.notExpectedTokenErr("=", p.readToken.Literal)
+		p.notExpectedTokenErr("=", p.readToken)
 		return nil
 	}
 
@@ -295,8 +453,7 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	v, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 
 	if err != nil {
-		err := fmt.Errorf("could not parse %s into an Integer", p.curToken.Literal)
-		p.errors = append(p.errors, err)
+		p.fail(&InvalidLiteralError{Pos: p.curToken.Pos, Literal: p.curToken.Literal, Type: "Integer"})
 		return nil
 	}
 
@@ -305,6 +462,17 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	return intStmt
 }
 
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	v, err := strconv.ParseFloat(p.curToken.Literal, 64)
+
+	if err != nil {
+		p.fail(&InvalidLiteralError{Pos: p.curToken.Pos, Literal: p.curToken.Literal, Type: "Float"})
+		return nil
+	}
+
+	return &ast.FloatLiteral{Token: p.curToken, Value: v}
+}
+
 func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken}
 }
@@ -331,11 +499,11 @@ func (p *Parser) parseGroupingExpression() ast.Expression {
 
 	exp := p.parseExpression(LOWEST)
 
-	if !p.readTokenIs(token.RPAREN) {
+	if !p.expectRead(token.RPAREN) {
+		p.notExpectedTokenErr(")", p.readToken)
 		return nil
 	}
 
-	p.nextToken()
 	return exp
 }
 
@@ -343,7 +511,7 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	ifExp := &ast.IfExpression{Token: p.curToken}
 
 	if !p.expectRead(token.LPAREN) {
-		p.notExpectedTokenErr("(", p.readToken.Literal)
+		p.notExpectedTokenErr("(", p.readToken)
 		return nil
 	}
 
@@ -351,12 +519,12 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	ifExp.Condition = p.parseExpression(LOWEST)
 
 	if !p.expectRead(token.RPAREN) {
-		p.notExpectedTokenErr(")", p.readToken.Literal)
+		p.notExpectedTokenErr(")", p.readToken)
 		return nil
 	}
 
 	if !p.expectRead(token.LBRACE) {
-		p.notExpectedTokenErr("{", p.readToken.Literal)
+		p.notExpectedTokenErr("{", p.readToken)
 		return nil
 	}
 
@@ -364,7 +532,7 @@ func (p *Parser) parseIfExpression() ast.Expression {
 
 	if p.expectRead(token.ELSE) {
 		if !p.expectRead(token.LBRACE) {
-			p.notExpectedTokenErr("{", p.readToken.Literal)
+			p.notExpectedTokenErr("{", p.readToken)
 			return nil
 		}
 
@@ -380,37 +548,144 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 		Statements: make([]ast.Statement, 0, 20),
 	}
 
+	// the errors of the statements inside the block don't belong to the statement around it
+	panicking := p.panicking
+	p.blocks++
 	p.nextToken()
 
-	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
-		stmt := p.parseStatement()
-
-		if stmt != nil {
+	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) && !p.stopped {
+		if stmt := p.parseNextStatement(); stmt != nil {
 			block.Statements = append(block.Statements, stmt)
 		}
 
 		p.nextToken()
 	}
 
+	p.blocks--
+	p.panicking = panicking
+
+	if p.curTokenIs(token.RBRACE) {
+		block.Rbrace = p.curToken.Pos
+	}
+
 	return block
 }
 
+func (p *Parser) parseWhileStatement() ast.Statement {
+	stmt := &ast.WhileStatement{Token: p.curToken}
+
+	if !p.expectRead(token.LPAREN) {
+		p.notExpectedTokenErr("(", p.readToken)
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectRead(token.RPAREN) {
+		p.notExpectedTokenErr(")", p.readToken)
+		return nil
+	}
+
+	if !p.expectRead(token.LBRACE) {
+		p.notExpectedTokenErr("{", p.readToken)
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+
+	return stmt
+}
+
+func (p *Parser) parseForStatement() ast.Statement {
+	stmt := &ast.ForStatement{Token: p.curToken}
+
+	if !p.expectRead(token.LPAREN) {
+		p.notExpectedTokenErr("(", p.readToken)
+		return nil
+	}
+
+	if !p.expectRead(token.IDENT) {
+		p.notExpectedTokenErr("variable_name", p.readToken)
+		return nil
+	}
+
+	stmt.Variable = &ast.Identifier{Token: p.curToken}
+
+	if !p.expectRead(token.IN) {
+		p.notExpectedTokenErr("in", p.readToken)
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectRead(token.RPAREN) {
+		p.notExpectedTokenErr(")", p.readToken)
+		return nil
+	}
+
+	if !p.expectRead(token.LBRACE) {
+		p.notExpectedTokenErr("{", p.readToken)
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+
+	return stmt
+}
+
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+	p.loops++
+	body := p.parseBlockStatement()
+	p.loops--
+
+	if p.readTokenIs(token.SEMI) {
+		p.nextToken()
+	}
+
+	return body
+}
+
+func (p *Parser) parseLoopControlStatement() ast.Statement {
+	tok := p.curToken
+
+	if p.readTokenIs(token.SEMI) {
+		p.nextToken()
+	}
+
+	if p.loops == 0 {
+		p.fail(&LoopControlError{Pos: tok.Pos, Keyword: tok.Literal})
+		return nil
+	}
+
+	if tok.Kind == token.BREAK {
+		return &ast.BreakStatement{Token: tok}
+	}
+
+	return &ast.ContinueStatement{Token: tok}
+}
+
 func (p *Parser) parseFunctionExpression() ast.Expression {
 	funcExp := &ast.FunctionLiteral{Token: p.curToken}
 
 	if !p.expectRead(token.LPAREN) {
-		p.notExpectedTokenErr("(", p.readToken.Literal)
+		p.notExpectedTokenErr("(", p.readToken)
 		return nil
 	}
 
 	funcExp.Params = p.parseFunctionParams()
 
 	if !p.expectRead(token.LBRACE) {
-		p.notExpectedTokenErr("{", p.curToken.Literal)
+		p.notExpectedTokenErr("{", p.readToken)
 		return nil
 	}
 
+	// a loop around the function can't be controlled from its body
+	loops := p.loops
+	p.loops = 0
 	funcExp.Body = p.parseBlockStatement()
+	p.loops = loops
 
 	return funcExp
 }
@@ -420,19 +695,121 @@ func (p *Parser) parseArrayLiteral() ast.Expression {
 
 	al.Elements = p.parseExpressionList("]", token.RBRACKET)
 
+	if p.curTokenIs(token.RBRACKET) {
+		al.Rbracket = p.curToken.Pos
+	}
+
 	return al
 }
 
+func (p *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{
+		Token: p.curToken,
+		Pairs: make([]ast.HashPair, 0, 10),
+	}
+
+	for !p.readTokenIs(token.RBRACE) {
+		p.nextToken()
+		key := p.parseExpression(LOWEST)
+
+		if !p.expectRead(token.COLON) {
+			p.notExpectedTokenErr(":", p.readToken)
+			return nil
+		}
+
+		p.nextToken()
+		value := p.parseExpression(LOWEST)
+
+		if key == nil || value == nil {
+			return nil
+		}
+
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
+
+		if !p.readTokenIs(token.RBRACE) && !p.expectRead(token.COMMA) {
+			p.notExpectedTokenErr("}", p.readToken)
+			return nil
+		}
+	}
+
+	p.nextToken()
+	hash.Rbrace = p.curToken.Pos
+
+	return hash
+}
+
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	ie := &ast.IndexExpression{Token: p.curToken, Left: left}
 	p.nextToken()
 
-	ie.Index = p.parseExpression(LOWEST)
+	if !p.curTokenIs(token.COLON) {
+		ie.Index = p.parseExpression(LOWEST)
+		p.nextToken()
+	}
+
+	if p.curTokenIs(token.COLON) {
+		return p.parseSliceExpression(ie)
+	}
+
+	if !p.curTokenIs(token.RBRACKET) {
+		p.notExpectedTokenErr("]", p.curToken)
+		return nil
+	}
+	ie.Rbracket = p.curToken.Pos
+
+	return ie
+}
+
+// Parses the rest of left[low:high] from the ":", the index already parsed is the low bound
+func (p *Parser) parseSliceExpression(ie *ast.IndexExpression) ast.Expression {
+	se := &ast.SliceExpression{Token: ie.Token, Left: ie.Left, Low: ie.Index}
 	p.nextToken()
 
 	if !p.curTokenIs(token.RBRACKET) {
+		se.High = p.parseExpression(LOWEST)
+		p.nextToken()
+	}
+
+	if !p.curTokenIs(token.RBRACKET) {
+		p.notExpectedTokenErr("]", p.curToken)
 		return nil
 	}
+	se.Rbracket = p.curToken.Pos
+
+	return se
+}
+
+func (p *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
+	me := &ast.MemberExpression{Token: p.curToken, Object: object}
+
+	if !p.expectRead(token.IDENT) {
+		p.notExpectedTokenErr("identifier", p.readToken)
+		return nil
+	}
+	me.Property = &ast.Identifier{Token: p.curToken}
+
+	return me
+}
+
+func (p *Parser) parseImportExpression() ast.Expression {
+	ie := &ast.ImportExpression{Token: p.curToken}
+
+	if !p.expectRead(token.LPAREN) {
+		p.notExpectedTokenErr("(", p.readToken)
+		return nil
+	}
+
+	if !p.expectRead(token.STRING) {
+		p.fail(&InvalidImportError{Pos: p.readToken.Pos, Got: p.readToken})
+		return nil
+	}
+	ie.Path = &ast.StringLiteral{Token: p.curToken}
+
+	if !p.expectRead(token.RPAREN) {
+		p.notExpectedTokenErr(")", p.readToken)
+		return nil
+	}
+	ie.Rparen = p.curToken.Pos
 
 	return ie
 }
@@ -445,20 +822,21 @@ func (p *Parser) parseFunctionParams() []*ast.Identifier {
 		return params
 	}
 
-	p.nextToken()
-	ident := &ast.Identifier{Token: p.curToken}
-	params = append(params, ident)
+	for {
+		if !p.expectRead(token.IDENT) {
+			p.notExpectedTokenErr("parameter_name", p.readToken)
+			return nil
+		}
 
-	for p.readTokenIs(token.COMMA) {
-		p.nextToken()
-		p.nextToken()
+		params = append(params, &ast.Identifier{Token: p.curToken})
 
-		ident := &ast.Identifier{Token: p.curToken}
-		params = append(params, ident)
+		if !p.expectRead(token.COMMA) {
+			break
+		}
 	}
 
 	if !p.expectRead(token.RPAREN) {
-		p.notExpectedTokenErr(")", p.readToken.Literal)
+		p.notExpectedTokenErr(")", p.readToken)
 		return nil
 	}
 
@@ -473,6 +851,10 @@ func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 
 	callExp.Arguments = p.parseExpressionList(")", token.RPAREN)
 
+	if p.curTokenIs(token.RPAREN) {
+		callExp.Rparen = p.curToken.Pos
+	}
+
 	return callExp
 }
 
@@ -494,7 +876,7 @@ func (p *Parser) parseExpressionList(lit string, end token.TokenKind) []ast.Expr
 	}
 
 	if !p.expectRead(end) {
-		p.notExpectedTokenErr(lit, p.readToken.Literal)
+		p.notExpectedTokenErr(lit, p.readToken)
 		return nil
 	}
 
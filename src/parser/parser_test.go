@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/dyxgou/parser/src/ast"
@@ -376,6 +378,30 @@ func TestOperatorPrecendence(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		{
+			"a + b % c",
+			"(a + (b % c))",
+		},
+		{
+			"a <= b == c >= d",
+			"((a <= b) == (c >= d))",
+		},
+		{
+			"a || b && c",
+			"(a || (b && c))",
+		},
+		{
+			"a && b || c && d",
+			"((a && b) || (c && d))",
+		},
+		{
+			"a < b && b != c || !d",
+			"(((a < b) && (b != c)) || (!d))",
+		},
+		{
+			"x = a || b",
+			"(x = (a || b))",
+		},
 	}
 
 	for _, tt := range tests {
@@ -747,3 +773,520 @@ func TestParseIndexExpression(t *testing.T) {
 		return
 	}
 }
+
+func TestParseSliceExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"s[1:2]", "(s[1:2])"},
+		{"s[:n - 1]", "(s[:(n - 1)])"},
+		{"s[i:]", "(s[i:])"},
+		{"s[:]", "(s[:])"},
+		{"s[1:][0]", "((s[1:])[0])"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		if s := stmt.Expression.String(); s != tt.expected {
+			t.Errorf("%q: expected=%q. got=%q", tt.input, tt.expected, s)
+		}
+	}
+
+	for _, input := range []string{"s[1:2", "s[1:2:3]", "s[1:2] = 3"} {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+
+		if len(p.errors) == 0 {
+			t.Errorf("%q: expected a parser error", input)
+		}
+	}
+}
+
+func TestParserErrorPosition(t *testing.T) {
+	input := `let a = 1;
+let b 2;`
+
+	p := New(lexer.NewFile("main.lang", input))
+	p.ParseProgram()
+
+	if len(p.errors) == 0 {
+		t.Fatal("the parser hasn't detected the error")
+	}
+
+	err, ok := p.errors[0].(*Error)
+	if !ok {
+		t.Fatalf("err is not *parser.Error. got=%T", p.errors[0])
+	}
+
+	if pos := err.Pos.String(); pos != "main.lang:2:7" {
+		t.Errorf("err.Pos expected=%q. got=%q", "main.lang:2:7", pos)
+	}
+
+	expected := "main.lang:2:7: expected next token to be \"=\" got=\"2\"\nlet b 2;\n      ^"
+	if err.Error() != expected {
+		t.Errorf("err.Error() expected=%q. got=%q", expected, err.Error())
+	}
+}
+
+func TestNodePosition(t *testing.T) {
+	input := `let add = fn(x, y) {
+  x + y
+};
+add(1, [2][0]);`
+
+	tests := []struct {
+		node       func(*ast.Program) ast.Node
+		start, end string
+	}{
+		{
+			func(p *ast.Program) ast.Node { return p.Statements[0] },
+			"1:1", "3:2",
+		},
+		{
+			func(p *ast.Program) ast.Node {
+				return p.Statements[0].(*ast.LetStatement).Value.(*ast.FunctionLiteral).Body.Statements[0]
+			},
+			"2:3", "2:8",
+		},
+		{
+			func(p *ast.Program) ast.Node { return p.Statements[1] },
+			"4:1", "4:15",
+		},
+		{
+			func(p *ast.Program) ast.Node {
+				call := p.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+				return call.Arguments[1]
+			},
+			"4:8", "4:14",
+		},
+	}
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	for i, tt := range tests {
+		node := tt.node(program)
+
+		if pos := node.Pos().String(); pos != tt.start {
+			t.Errorf("tests[%d] - node.Pos expected=%q. got=%q", i, tt.start, pos)
+		}
+
+		if end := node.End().String(); end != tt.end {
+			t.Errorf("tests[%d] - node.End expected=%q. got=%q", i, tt.end, end)
+		}
+	}
+}
+
+func TestParseHashLiteral(t *testing.T) {
+	input := `{"one": 1, "two": 2 + 3, three: 3}`
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+
+	if !ok {
+		t.Fatalf("exp not *ast.HashLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(hash.Pairs) != 3 {
+		t.Fatalf("len(hash.Pairs) not 3. got=%d", len(hash.Pairs))
+	}
+
+	if key, ok := hash.Pairs[0].Key.(*ast.StringLiteral); !ok || key.Value() != "one" {
+		t.Errorf("hash.Pairs[0].Key not %q. got=%s", "one", hash.Pairs[0].Key)
+	}
+
+	testIntegerLiteral(t, hash.Pairs[0].Value, 1)
+	testInfixExpression(t, hash.Pairs[1].Value, 2, "+", 3)
+	testIdentifier(t, hash.Pairs[2].Key, "three")
+
+	if s := hash.String(); s != "{one: 1, two: (2 + 3), three: 3}" {
+		t.Errorf("hash.String() wrong. got=%q", s)
+	}
+}
+
+func TestParseEmptyHashLiteral(t *testing.T) {
+	p := New(lexer.New("let h = {};"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	let := program.Statements[0].(*ast.LetStatement)
+	hash, ok := let.Value.(*ast.HashLiteral)
+
+	if !ok {
+		t.Fatalf("exp not *ast.HashLiteral. got=%T", let.Value)
+	}
+
+	if len(hash.Pairs) != 0 {
+		t.Errorf("len(hash.Pairs) not 0. got=%d", len(hash.Pairs))
+	}
+}
+
+func TestWhileStatement(t *testing.T) {
+	input := "while (x < 10) { break; continue; };"
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if ps := len(program.Statements); ps != 1 {
+		t.Fatalf("program.Statements expected=1 statements. got=%d", ps)
+	}
+
+	stmt, ok := program.Statements[0].(*ast.WhileStatement)
+	if !ok {
+		t.Fatalf("stmt is not *ast.WhileStatement. got=%T", program.Statements[0])
+	}
+
+	testInfixExpression(t, stmt.Condition, "x", "<", 10)
+
+	if n := len(stmt.Body.Statements); n != 2 {
+		t.Fatalf("body expected=2 statements. got=%d", n)
+	}
+
+	if _, ok := stmt.Body.Statements[0].(*ast.BreakStatement); !ok {
+		t.Errorf("body[0] is not *ast.BreakStatement. got=%T", stmt.Body.Statements[0])
+	}
+
+	if _, ok := stmt.Body.Statements[1].(*ast.ContinueStatement); !ok {
+		t.Errorf("body[1] is not *ast.ContinueStatement. got=%T", stmt.Body.Statements[1])
+	}
+}
+
+func TestForStatement(t *testing.T) {
+	input := "for (x in items) { x }"
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ForStatement)
+	if !ok {
+		t.Fatalf("stmt is not *ast.ForStatement. got=%T", program.Statements[0])
+	}
+
+	testIdentifier(t, stmt.Variable, "x")
+	testIdentifier(t, stmt.Iterable, "items")
+
+	if n := len(stmt.Body.Statements); n != 1 {
+		t.Fatalf("body expected=1 statement. got=%d", n)
+	}
+}
+
+func TestLoopControlOutsideLoop(t *testing.T) {
+	tests := []string{
+		"break;",
+		"if (true) { continue; }",
+		"while (true) { fn() { break; } }",
+	}
+
+	for _, input := range tests {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+
+		if len(p.errors) == 0 {
+			t.Errorf("%q: expected a parser error", input)
+		}
+	}
+}
+
+func TestAssignExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x = 5", "(x = 5)"},
+		{"x = y = 1 + 2", "(x = (y = (1 + 2)))"},
+		{"x += y * 2", "(x += (y * 2))"},
+		{"x -= 1", "(x -= 1)"},
+		{"a[i] *= 2", "((a[i]) *= 2)"},
+		{"h[\"k\"] /= 2", "((h[k]) /= 2)"},
+		{"x = a == b", "(x = (a == b))"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if s := program.String(); s != tt.expected {
+			t.Errorf("expected=%q. got=%q", tt.expected, s)
+		}
+	}
+}
+
+func TestInvalidAssignTarget(t *testing.T) {
+	tests := []string{
+		"1 = 2",
+		"f() = 1",
+		"a + b = c",
+	}
+
+	for _, input := range tests {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+
+		if len(p.errors) == 0 {
+			t.Errorf("%q: expected a parser error", input)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// adds two numbers
+let add = fn(a, b) {
+  a + b /* the sum */
+};
+add(1, 2) // 3`
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if s := program.String(); s != "let add = fn(a, b){(a + b)};add(1, 2)" {
+		t.Errorf("wrong program. got=%q", s)
+	}
+}
+
+func TestUnterminatedComment(t *testing.T) {
+	p := New(lexer.New("let x = 1; /* never closed"))
+	p.ParseProgram()
+
+	if len(p.errors) != 1 {
+		t.Fatalf("expected 1 error. got=%d (%v)", len(p.errors), p.errors)
+	}
+
+	err := p.errors[0].(*Error)
+	if err.Msg != "unterminated block comment" || err.Pos.Column != 12 {
+		t.Errorf("wrong error. got=%s", err)
+	}
+}
+
+func TestFloatLiteral(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"1.5", 1.5},
+		{".25", 0.25},
+		{"1e3", 1000},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		lit, ok := stmt.Expression.(*ast.FloatLiteral)
+		if !ok {
+			t.Fatalf("exp is not *ast.FloatLiteral. got=%T", stmt.Expression)
+		}
+
+		if lit.Value != tt.expected {
+			t.Errorf("lit.Value expected=%g. got=%g", tt.expected, lit.Value)
+		}
+	}
+}
+
+func TestImportExpression(t *testing.T) {
+	p := New(lexer.New(`let m = import("lib/list.lang"); m.reduce(m.items, 0)`))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if s := program.String(); s != "let m = import(lib/list.lang);(m.reduce)((m.items), 0)" {
+		t.Errorf("wrong program. got=%q", s)
+	}
+
+	let := program.Statements[0].(*ast.LetStatement)
+	ie, ok := let.Value.(*ast.ImportExpression)
+	if !ok {
+		t.Fatalf("let.Value is not *ast.ImportExpression. got=%T", let.Value)
+	}
+
+	if ie.Path.Value() != "lib/list.lang" {
+		t.Errorf("wrong path. got=%q", ie.Path.Value())
+	}
+
+	if end := ie.End(); end.Column != 32 {
+		t.Errorf("wrong end. got=%s", end)
+	}
+
+	call := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+	member, ok := call.Function.(*ast.MemberExpression)
+	if !ok {
+		t.Fatalf("call.Function is not *ast.MemberExpression. got=%T", call.Function)
+	}
+
+	if !testIdentifier(t, member.Object, "m") || !testIdentifier(t, member.Property, "reduce") {
+		return
+	}
+}
+
+func TestInvalidImport(t *testing.T) {
+	tests := []string{
+		"import",
+		`import "lib.lang"`,
+		"import(path)",
+		`import("lib.lang"`,
+		`m."x"`,
+		"m.",
+		"m.x = 1",
+	}
+
+	for _, input := range tests {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+
+		if len(p.errors) == 0 {
+			t.Errorf("%q: expected a parser error", input)
+		}
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input      string
+		errors     []string
+		statements string // the statements without errors
+	}{
+		{
+			"let x 1; let y = 2; y",
+			[]string{`1:7: expected next token to be "=" got="1"`},
+			"let y = 2;y",
+		},
+		{
+			"foo(1, 2; let a = 1; a +",
+			[]string{`1:9: expected next token to be ")" got=";"`, `1:25: no prefix parse function for "EOF" found`},
+			"let a = 1;",
+		},
+		{
+			"if (x { 1 } else { 2 }; 5",
+			[]string{`1:7: expected next token to be ")" got="{"`},
+			"5",
+		},
+		{
+			"fn(a, 1) { a }; let b = (1 + 2;",
+			[]string{`1:7: expected next token to be "parameter_name" got="1"`, `1:31: expected next token to be ")" got=";"`},
+			"",
+		},
+		{
+			"let f = fn() { let = 1; break; 2 }; f()",
+			[]string{`1:20: expected next token to be "variable_name" got="="`, "1:25: break outside of a loop"},
+			"let f = fn(){2};f()",
+		},
+		{
+			"let a = ]; let b = 1 }; b",
+			[]string{`1:9: no prefix parse function for "]" found`, `1:22: no prefix parse function for "}" found`},
+			"let b = 1;b",
+		},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+
+		if len(p.errors) != len(tt.errors) {
+			t.Errorf("%q: expected %d errors. got=%d (%v)", tt.input, len(tt.errors), len(p.errors), p.errors)
+			continue
+		}
+
+		for i, err := range p.errors {
+			if msg := strings.SplitN(err.Error(), "\n", 2)[0]; msg != tt.errors[i] {
+				t.Errorf("%q: errors[%d] expected=%q. got=%q", tt.input, i, tt.errors[i], msg)
+			}
+		}
+
+		if s := program.String(); s != tt.statements {
+			t.Errorf("%q: expected statements=%q. got=%q", tt.input, tt.statements, s)
+		}
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	tests := []struct {
+		input string
+		check func(err error) bool
+	}{
+		{"let x 1", func(err error) bool {
+			var kind *UnexpectedTokenError
+			return errors.As(err, &kind) && kind.Expected == "=" && kind.Got.Literal == "1" && kind.Pos.Column == 7
+		}},
+		{"1 + }", func(err error) bool {
+			var kind *NoPrefixParseFnError
+			return errors.As(err, &kind) && kind.Token.Kind.String() == "}"
+		}},
+		{"99999999999999999999", func(err error) bool {
+			var kind *InvalidLiteralError
+			return errors.As(err, &kind) && kind.Type == "Integer"
+		}},
+		{"1 = 2", func(err error) bool {
+			var kind *InvalidAssignmentError
+			return errors.As(err, &kind) && kind.Target == "1"
+		}},
+		{"continue", func(err error) bool {
+			var kind *LoopControlError
+			return errors.As(err, &kind) && kind.Keyword == "continue"
+		}},
+		{"import(1)", func(err error) bool {
+			var kind *InvalidImportError
+			return errors.As(err, &kind) && kind.Got.Literal == "1"
+		}},
+		{"/* never closed", func(err error) bool {
+			var kind *SyntaxError
+			return errors.As(err, &kind)
+		}},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		if len(p.errors) == 0 {
+			t.Errorf("%q: expected a parser error", tt.input)
+			continue
+		}
+
+		var err *Error
+		if !errors.As(p.errors[0], &err) || !err.Pos.IsValid() {
+			t.Errorf("%q: expected a placed *Error. got=%T", tt.input, p.errors[0])
+		}
+
+		if !tt.check(p.errors[0]) {
+			t.Errorf("%q: wrong kind of error. got=%#v", tt.input, err.Err)
+		}
+	}
+}
+
+func TestMaxErrors(t *testing.T) {
+	input := strings.Repeat("let ;\n", 20)
+
+	p := New(lexer.New(input))
+	p.ParseProgram()
+
+	if len(p.errors) != DefaultMaxErrors+1 {
+		t.Fatalf("expected %d errors. got=%d", DefaultMaxErrors+1, len(p.errors))
+	}
+
+	var tooMany *TooManyErrorsError
+	if !errors.As(p.errors[DefaultMaxErrors], &tooMany) || tooMany.Pos.Line != DefaultMaxErrors+1 {
+		t.Errorf("expected the last error to be a TooManyErrorsError. got=%s", p.errors[DefaultMaxErrors])
+	}
+
+	p = New(lexer.New(input))
+	p.MaxErrors = 0
+	p.ParseProgram()
+
+	if len(p.errors) != 20 {
+		t.Errorf("expected 20 errors without a limit. got=%d", len(p.errors))
+	}
+}
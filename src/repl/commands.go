@@ -0,0 +1,232 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/dyxgou/parser/src/ast"
+	"github.com/dyxgou/parser/src/evaluator"
+	"github.com/dyxgou/parser/src/lexer"
+	"github.com/dyxgou/parser/src/object"
+	"github.com/dyxgou/parser/src/parser"
+	"github.com/dyxgou/parser/src/token"
+)
+
+// session is the state the REPL keeps between its inputs
+type session struct {
+	env     *object.Enviroment
+	modules *evaluator.Modules // imported files, loaded once for all the inputs
+	in      io.Reader          // shared with the programs, which read from it
+	out     io.Writer
+}
+
+func newSession(in io.Reader, out io.Writer) *session {
+	return &session{
+		env:     object.NewEnviroment(),
+		modules: evaluator.NewModules(),
+		in:      in,
+		out:     out,
+	}
+}
+
+// Returns an evaluator printing to the output of the REPL and reading from its input
+func (s *session) evaluator() *evaluator.Evaluator {
+	e := evaluator.New()
+	e.IO = object.IO{Out: s.out, In: s.in}
+	e.Modules = s.modules
+
+	return e
+}
+
+var commandHelp = []struct {
+	usage, help string
+}{
+	{":help", "shows this help"},
+	{":env", "lists the bindings of the session"},
+	{":type <expression>", "shows the type of the value of the expression, without changing the session"},
+	{":ast <source>", "shows the syntax tree of the source"},
+	{":tokens <source>", "shows the tokens of the source"},
+	{":load <file>", "runs a file in the session, keeping its bindings"},
+	{":reset", "removes every binding of the session and forgets the imported files"},
+}
+
+// Runs a line starting with ":"
+func (s *session) runCommand(line string) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+	arg = strings.TrimSpace(arg)
+
+	switch name {
+	case ":help":
+		for _, cmd := range commandHelp {
+			fmt.Fprintf(s.out, "%-20s %s\n", cmd.usage, cmd.help)
+		}
+	case ":env":
+		s.printEnv()
+	case ":reset":
+		s.env = object.NewEnviroment()
+		s.modules = evaluator.NewModules()
+	case ":type", ":ast", ":tokens", ":load":
+		if arg == "" {
+			fmt.Fprintf(s.out, "%s expects an argument, :help shows how to use it\n", name)
+			return
+		}
+
+		switch name {
+		case ":type":
+			s.printType(arg)
+		case ":ast":
+			s.printAST(arg)
+		case ":tokens":
+			s.printTokens(arg)
+		case ":load":
+			s.load(arg)
+		}
+	default:
+		fmt.Fprintf(s.out, "unknown command %s, :help lists the commands\n", name)
+	}
+}
+
+func (s *session) printEnv() {
+	names := s.env.Names()
+	if len(names) == 0 {
+		io.WriteString(s.out, "no bindings\n")
+		return
+	}
+
+	for _, name := range names {
+		obj, _ := s.env.Get(name)
+
+		switch obj.(type) {
+		case *object.Function, *object.BuiltIn, *object.Module:
+			// their values are too long for a list
+			fmt.Fprintf(s.out, "%s: %s\n", name, obj.Inspect())
+		default:
+			fmt.Fprintf(s.out, "%s: %s = %s\n", name, obj.Inspect(), obj.String())
+		}
+	}
+}
+
+// Parses the source, the errors are printed and nil is returned when there are any
+func (s *session) parse(filename, src string) *ast.Program {
+	p := parser.New(lexer.NewFile(filename, src))
+	program := p.ParseProgram()
+
+	if p.ErrorsLen() != 0 {
+		printParserErrors(s.out, p.Errors())
+		return nil
+	}
+
+	return program
+}
+
+// typeMaxSteps is the budget of the expressions of :type, so one that never ends doesn't hang the session
+const typeMaxSteps = 1_000_000
+
+// The expression is evaluated in a copy of the session, without its input and output, so its lets, its
+// assignments and what it prints are dropped. The imported files are the ones of the session
+func (s *session) printType(src string) {
+	program := s.parse("", src)
+	if program == nil {
+		return
+	}
+
+	e := evaluator.New()
+	e.IO = object.IO{Out: io.Discard, In: strings.NewReader("")}
+	e.Modules = s.modules
+	e.MaxSteps = typeMaxSteps
+
+	evaluated := e.Eval(program, s.env.Copy())
+
+	switch evaluated := evaluated.(type) {
+	case nil:
+		io.WriteString(s.out, "no value\n")
+	case *object.Error:
+		printError(s.out, source{text: src}, evaluated)
+	default:
+		fmt.Fprintln(s.out, evaluated.Inspect())
+	}
+}
+
+func (s *session) printAST(src string) {
+	program := s.parse("", src)
+	if program == nil {
+		return
+	}
+
+	printNode(s.out, 0, "", reflect.ValueOf(program))
+}
+
+// Prints a node and the nodes inside it, one per line indented below their parent
+func printNode(out io.Writer, depth int, label string, v reflect.Value) {
+	if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+		return
+	}
+
+	node, ok := v.Interface().(ast.Node)
+	if !ok {
+		return
+	}
+
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	fmt.Fprintf(out, "%s%s%s %q %s\n", strings.Repeat("  ", depth), label, v.Elem().Type().Name(), node.String(), node.Pos())
+
+	printChildren(out, depth+1, "", v.Elem())
+}
+
+// Prints the nodes held by the fields of a node or of a struct inside it, like an ast.HashPair
+func printChildren(out io.Writer, depth int, prefix string, v reflect.Value) {
+	for i := range v.NumField() {
+		field := v.Field(i)
+		name := prefix + v.Type().Field(i).Name
+
+		switch field.Kind() {
+		case reflect.Slice:
+			for j := range field.Len() {
+				elem := field.Index(j)
+				label := fmt.Sprintf("%s[%d]", name, j)
+
+				if elem.Kind() == reflect.Struct {
+					printChildren(out, depth, label+".", elem)
+				} else {
+					printNode(out, depth, label+": ", elem)
+				}
+			}
+		case reflect.Pointer, reflect.Interface:
+			printNode(out, depth, name+": ", field)
+		}
+	}
+}
+
+func (s *session) printTokens(src string) {
+	l := lexer.New(src)
+	l.SetErrorHandler(func(pos token.Position, msg string) {
+		fmt.Fprintf(s.out, "   %s: %s\n", pos, msg)
+	})
+
+	for tok := l.NextToken(); tok.Kind != token.EOF; tok = l.NextToken() {
+		fmt.Fprintf(s.out, "%s %s %q\n", tok.Pos, tok.Kind, tok.Literal)
+	}
+}
+
+// Runs the file in the enviroment of the session, the imports are resolved from its directory
+func (s *session) load(path string) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(s.out, "ERROR : %s\n", err)
+		return
+	}
+
+	program := s.parse(path, string(text))
+	if program == nil {
+		return
+	}
+
+	evaluated := s.evaluator().Eval(program, s.env)
+	printObject(s.out, source{path, string(text)}, evaluated)
+}
@@ -2,53 +2,161 @@ package repl
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
+	"strings"
+	"time"
 
+	"github.com/dyxgou/parser/src/ast"
+	"github.com/dyxgou/parser/src/compiler"
 	"github.com/dyxgou/parser/src/evaluator"
 	"github.com/dyxgou/parser/src/lexer"
 	"github.com/dyxgou/parser/src/object"
+	"github.com/dyxgou/parser/src/optimizer"
 	"github.com/dyxgou/parser/src/parser"
+	"github.com/dyxgou/parser/src/token"
+	"github.com/dyxgou/parser/src/vm"
 )
 
-const PROMPT = ">> "
+const (
+	PROMPT              = ">> "
+	CONTINUATION_PROMPT = ".. " // shown while the input is incomplete
+)
 
 func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
-	env := object.NewEnviroment()
+	// the lines of the REPL and the ones read by the program share the buffer
+	reader := bufio.NewReader(in)
+	s := newSession(reader, out)
+
+	var input strings.Builder
 
 	for {
-		fmt.Print(PROMPT)
-		scanned := scanner.Scan()
+		if input.Len() == 0 {
+			io.WriteString(out, PROMPT)
+		} else {
+			io.WriteString(out, CONTINUATION_PROMPT)
+		}
+
+		line, err := reader.ReadString('\n')
+
+		if input.Len() == 0 && strings.HasPrefix(strings.TrimSpace(line), ":") {
+			s.run(func() { s.runCommand(line) })
+		} else {
+			input.WriteString(line)
+
+			// the input is run when it's complete or there is nothing else to complete it
+			if err == nil && incomplete(input.String()) {
+				continue
+			}
 
-		if !scanned {
+			if input.Len() > 0 {
+				text := strings.TrimSuffix(input.String(), "\n")
+				s.run(func() { s.evalLine(text) })
+			}
+
+			input.Reset()
+		}
+
+		if err != nil {
 			return
 		}
+	}
+}
 
-		text := scanner.Text()
+// Runs an input of the REPL, a panic is reported so it doesn't end the session
+func (s *session) run(fn func()) {
+	defer recoverPanic(s.out)
+	fn()
+}
 
-		l := lexer.New(text)
-		p := parser.New(l)
+func (s *session) evalLine(text string) {
+	l := lexer.New(text)
+	p := parser.New(l)
 
-		program := p.ParseProgram()
+	program := p.ParseProgram()
 
-		if p.ErrorsLen() != 0 {
-			printParserErrors(out, p.Errors())
-		}
+	if p.ErrorsLen() != 0 {
+		printParserErrors(s.out, p.Errors())
+	}
 
-		evaluated := evaluator.Eval(program, env)
+	evaluated := s.evaluator().Eval(program, s.env)
+	printObject(s.out, source{text: text}, evaluated)
+}
 
-		if evaluated != nil {
-			io.WriteString(out, evaluated.String())
-			io.WriteString(out, "\n")
+// Returns true when the text has brackets, braces or parens left open, or a string or a comment without its end
+func incomplete(text string) bool {
+	l := lexer.New(text)
+
+	unterminated := false
+	l.SetErrorHandler(func(_ token.Position, msg string) {
+		// the text ends inside a string or a comment
+		if msg == lexer.ErrUnterminatedString || msg == lexer.ErrUnterminatedComment {
+			unterminated = true
+		}
+	})
+
+	depth := 0
+	for tok := l.NextToken(); tok.Kind != token.EOF; tok = l.NextToken() {
+		switch tok.Kind {
+		case token.LPAREN, token.LBRACKET, token.LBRACE:
+			depth++
+		case token.RPAREN, token.RBRACKET, token.RBRACE:
+			depth--
 		}
 	}
+
+	return unterminated || depth > 0
+}
+
+type Engine string
+
+const (
+	EngineEval Engine = "eval" // tree walking evaluator
+	EngineVM   Engine = "vm"   // bytecode compiler and virtual machine
+)
+
+type Config struct {
+	Filename string // errors are reported inside this file
+	Engine   Engine // defaults to EngineEval
+
+	// limits of the execution, zero means no limit
+	MaxSteps int           // evaluated nodes or executed instructions
+	Timeout  time.Duration // wall clock time
+
+	// MaxCallDepth is the maximum number of nested function calls, zero means object.DefaultMaxCallDepth
+	MaxCallDepth int
+
+	// Stdin is read by input and readline, nil means os.Stdin. The program prints to the out of the execution
+	Stdin io.Reader
+
+	Optimize bool // simplify the program with the optimizer before running it
 }
 
 func Execute(text string, out io.Writer) {
-	env := object.NewEnviroment()
+	ExecuteWith(Config{}, text, out)
+}
 
-	l := lexer.New(text)
+// Executes the source of a file, errors are reported inside filename
+func ExecuteFile(filename, text string, out io.Writer) {
+	ExecuteWith(Config{Filename: filename}, text, out)
+}
+
+func ExecuteWith(cfg Config, text string, out io.Writer) {
+	ExecuteContext(context.Background(), cfg, text, out)
+}
+
+// Executes the source until it's done, ctx is done or it runs out of the limits of cfg
+func ExecuteContext(ctx context.Context, cfg Config, text string, out io.Writer) {
+	defer recoverPanic(out)
+
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+
+	l := lexer.NewFile(cfg.Filename, text)
 	p := parser.New(l)
 
 	program := p.ParseProgram()
@@ -57,18 +165,126 @@ func Execute(text string, out io.Writer) {
 		printParserErrors(out, p.Errors())
 	}
 
-	evaluated := evaluator.Eval(program, env)
+	if cfg.Optimize {
+		optimizer.Optimize(program)
+	}
 
-	if evaluated != nil {
-		io.WriteString(out, evaluated.String())
+	switch cfg.Engine {
+	case EngineVM:
+		runVM(ctx, cfg, out, source{cfg.Filename, text}, program)
+	case EngineEval, "":
+		e := evaluator.New()
+		e.MaxSteps = cfg.MaxSteps
+		if cfg.MaxCallDepth != 0 {
+			e.MaxCallDepth = cfg.MaxCallDepth
+		}
+		e.IO = object.IO{Out: out, In: cfg.Stdin}
+
+		evaluated := e.EvalContext(ctx, program, object.NewEnviroment())
+		printObject(out, source{cfg.Filename, text}, evaluated)
+	default:
+		fmt.Fprintf(out, "unknown engine %q\n", cfg.Engine)
+	}
+}
+
+func runVM(ctx context.Context, cfg Config, out io.Writer, src source, program *ast.Program) {
+	comp := compiler.New()
+
+	if err := comp.Compile(program); err != nil {
+		printIndented(out, err.Error())
+		return
+	}
+
+	machine := vm.New(comp.Bytecode())
+	machine.MaxSteps = cfg.MaxSteps
+	if cfg.MaxCallDepth != 0 {
+		machine.MaxCallDepth = cfg.MaxCallDepth
+	}
+	machine.IO = object.IO{Out: out, In: cfg.Stdin}
+
+	if err := machine.RunContext(ctx); err != nil {
+		if objErr, ok := err.(*object.Error); ok {
+			printError(out, src, objErr)
+		} else {
+			printIndented(out, err.Error())
+		}
+
+		return
+	}
+
+	printObject(out, src, machine.Result())
+}
+
+// Reports a panic of the interpreter as an error so it doesn't take down the host process
+func recoverPanic(out io.Writer) {
+	if r := recover(); r != nil {
+		fmt.Fprintf(out, "ERROR : internal interpreter error: %v\n", r)
+	}
+}
+
+// source is the text being run, the errors placed in another file, like an imported one, are shown without a snippet
+type source struct {
+	filename string
+	text     string
+}
+
+// NULL isn't printed, so a program ending with a print doesn't show it after what it printed
+func printObject(out io.Writer, src source, obj object.Object) {
+	if obj == nil || obj == object.NULL {
+		return
+	}
+
+	if err, ok := obj.(*object.Error); ok {
+		printError(out, src, err)
+		return
+	}
+
+	io.WriteString(out, obj.String())
+	io.WriteString(out, "\n")
+}
+
+func printError(out io.Writer, src source, err *object.Error) {
+	if !err.Pos.IsValid() {
+		io.WriteString(out, err.String())
 		io.WriteString(out, "\n")
+		return
+	}
+
+	fmt.Fprintf(out, "ERROR : %s: %s\n", err.Pos, err.Message)
+
+	if err.Pos.Filename == src.filename {
+		if snippet := err.Pos.Snippet(src.text); snippet != "" {
+			printIndented(out, snippet)
+		}
+	}
+
+	printStack(out, err.Stack)
+}
+
+// number of stack frames shown for an error, a deep recursion shows the innermost ones
+const maxStackFrames = 10
+
+func printStack(out io.Writer, stack []object.StackFrame) {
+	for i, frame := range stack {
+		if i == maxStackFrames {
+			fmt.Fprintf(out, "   ... %d more\n", len(stack)-i)
+			return
+		}
+
+		fmt.Fprintf(out, "   at %s\n", frame)
 	}
 }
 
 func printParserErrors(out io.Writer, errors []error) {
 	for _, err := range errors {
+		printIndented(out, err.Error())
+	}
+}
+
+func printIndented(out io.Writer, text string) {
+	for _, line := range strings.Split(text, "\n") {
 		io.WriteString(out, "   ")
-		io.WriteString(out, err.Error())
+		io.WriteString(out, line)
 		io.WriteString(out, "\n")
 	}
 }
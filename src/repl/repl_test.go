@@ -0,0 +1,271 @@
+package repl
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		input    string
+		engine   Engine
+		expected string
+	}{
+		{"1 + 2", EngineEval, "3\n"},
+		{"1 + 2", EngineVM, "3\n"},
+		{"let x = 1; x / 0", EngineEval, "ERROR : 1:12: division by zero: 1 / 0\n"},
+		{"let x = 1; x / 0", EngineVM, "ERROR : 1:12: division by zero: 1 / 0\n"},
+		{"pop([])", EngineVM, "ERROR : 1:1: function `pop` called on an empty array\n"},
+		{"let f = fn() { if (false) { let a = 1 }; a }; f()", EngineVM, "ERROR : 1:42: identifier not found: a\n"},
+		// the vm doesn't load modules
+		{`println("never"); let m = import("lib.lang"); m.f()`, EngineVM, "   1:27: modules are not supported by the vm\n"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		ExecuteWith(Config{Engine: tt.engine}, tt.input, &out)
+
+		if !strings.HasPrefix(out.String(), tt.expected) {
+			t.Errorf("%s (%s): expected=%q. got=%q", tt.input, tt.engine, tt.expected, out.String())
+		}
+	}
+}
+
+func TestRecoverPanic(t *testing.T) {
+	var out bytes.Buffer
+
+	func() {
+		defer recoverPanic(&out)
+		panic("boom")
+	}()
+
+	if s := out.String(); s != "ERROR : internal interpreter error: boom\n" {
+		t.Errorf("wrong output. got=%q", s)
+	}
+}
+
+func TestExecuteStackTrace(t *testing.T) {
+	input := `let f = fn(n) { if (n == 0) { 1 / 0 } else { 1 + f(n - 1) } };
+f(20)`
+
+	for _, engine := range []Engine{EngineEval, EngineVM} {
+		var out bytes.Buffer
+		ExecuteWith(Config{Engine: engine}, input, &out)
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if lines[0] != "ERROR : 1:31: division by zero: 1 / 0" {
+			t.Errorf("%s: wrong error line. got=%q", engine, lines[0])
+		}
+
+		if !strings.Contains(out.String(), "   at f (1:50)\n") || !strings.HasSuffix(out.String(), "   ... 11 more\n") {
+			t.Errorf("%s: wrong stack trace. got=%q", engine, out.String())
+		}
+	}
+}
+
+func TestExecuteLimits(t *testing.T) {
+	tests := []struct {
+		cfg      Config
+		expected string
+	}{
+		{Config{MaxSteps: 500}, "execution budget exceeded: more than 500 steps"},
+		{Config{Engine: EngineVM, MaxSteps: 500}, "execution budget exceeded: more than 500 steps"},
+		{Config{Timeout: 10 * time.Millisecond}, "execution cancelled: context deadline exceeded"},
+		{Config{Engine: EngineVM, Timeout: 10 * time.Millisecond}, "execution cancelled: context deadline exceeded"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		ExecuteWith(tt.cfg, "while (true) { 1 }", &out)
+
+		if !strings.Contains(out.String(), tt.expected) {
+			t.Errorf("%+v: expected %q. got=%q", tt.cfg, tt.expected, out.String())
+		}
+	}
+}
+
+func TestExecuteOptimize(t *testing.T) {
+	// the folded program gives the same result and still reports the division by zero
+	input := "let total = 0; for (i in range(100)) { total += 2 * 60 * 60 }; total"
+
+	for _, engine := range []Engine{EngineEval, EngineVM} {
+		var out bytes.Buffer
+		ExecuteWith(Config{Engine: engine, Optimize: true}, input+"; 1 / (1 - 1)", &out)
+
+		if !strings.Contains(out.String(), "division by zero") {
+			t.Errorf("%s: expected the division by zero. got=%q", engine, out.String())
+		}
+
+		out.Reset()
+		ExecuteWith(Config{Engine: engine, Optimize: true}, input, &out)
+
+		if out.String() != "720000\n" {
+			t.Errorf("%s: expected=%q. got=%q", engine, "720000\n", out.String())
+		}
+	}
+}
+
+func TestExecuteFileImport(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"main.lang":         `let list = import("lib/list.lang"); list.sum([1, 2, 3])`,
+		"lib/list.lang":     `let sum = fn(arr) { if (len(arr) == 0) { 0 } else { first(arr) + sum(rest(arr)) } };`,
+		"bad.lang":          `let lib = import("lib/fails.lang"); lib.f()`,
+		"lib/fails.lang":    "let f = fn() {\n  1 / 0\n};",
+		"imports_self.lang": `import("imports_self.lang")`,
+	}
+
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		file     string
+		expected string
+	}{
+		{"main.lang", "6\n"},
+		{"bad.lang", "ERROR : " + filepath.Join(dir, "lib/fails.lang") + ":2:3: division by zero: 1 / 0\n   at lib.f (" + filepath.Join(dir, "bad.lang") + ":1:37)\n"},
+		{"imports_self.lang", "ERROR : " + filepath.Join(dir, "imports_self.lang") + ":1:1: import cycle: "},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.file)
+
+		var out bytes.Buffer
+		ExecuteFile(path, files[tt.file], &out)
+
+		if !strings.HasPrefix(out.String(), tt.expected) {
+			t.Errorf("%s: expected=%q. got=%q", tt.file, tt.expected, out.String())
+		}
+	}
+}
+
+func TestExecuteIO(t *testing.T) {
+	input := `let name = input("name? "); println("hi ", name); print(readline())`
+
+	for _, engine := range []Engine{EngineEval, EngineVM} {
+		var out bytes.Buffer
+		ExecuteWith(Config{Engine: engine, Stdin: strings.NewReader("ana\nbye")}, input, &out)
+
+		if s := out.String(); s != "name? hi ana\nbye" {
+			t.Errorf("%s: wrong output. got=%q", engine, s)
+		}
+	}
+}
+
+func TestStartReadsInput(t *testing.T) {
+	// the line after the one calling readline is read by the program instead of the REPL
+	in := strings.NewReader("let name = readline();\nana\nprintln(name)\nname\n")
+
+	var out bytes.Buffer
+	Start(in, &out)
+
+	if s := out.String(); s != ">> >> ana\n>> ana\n>> " {
+		t.Errorf("wrong output. got=%q", s)
+	}
+}
+
+func TestStartMultiLine(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let add = fn(a, b) {\n  a + b\n};\nadd(1, 2)\n", ">> .. .. >> 3\n>> "},
+		{"[1,\n2][1]\n", ">> .. 2\n>> "},
+		{"let s = \"a\nb\";\nlen(s)\n", ">> .. >> 3\n>> "},
+		{"/* a\n comment */ 1\n", ">> .. 1\n>> "},
+		{"1 }\n2\n", ">>    1:3: no prefix parse function for \"}\" found\n   1 }\n     ^\n1\n>> 2\n>> "},
+		// the input left open when the reader ends is still run
+		{"if (true) {\n  1", ">> .. 1\n"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		Start(strings.NewReader(tt.input), &out)
+
+		if s := out.String(); s != tt.expected {
+			t.Errorf("%q: expected=%q. got=%q", tt.input, tt.expected, s)
+		}
+	}
+}
+
+func TestCommands(t *testing.T) {
+	dir := t.TempDir()
+	lib := filepath.Join(dir, "lib.lang")
+
+	if err := os.WriteFile(lib, []byte(`let double = fn(x) { x * 2 }; let _hidden = 1;`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{":env\nlet x = 1;\nlet f = fn() { x };\n:env\n", ">> no bindings\n>> >> >> f: FUNCTION\nx: INTEGER = 1\n>> "},
+		{"let x = 1;\n:type x + 0.5\n:type let y = 1\n:type y\n", ">> >> FLOAT\n>> no value\n>> ERROR : 1:1: identifier not found: y\n   y\n   ^\n>> "},
+		// :type runs in a copy of the session, its assignments, changes and prints are dropped
+		{"let x = 1;\nlet a = [1];\nlet inc = fn() { x += 1 };\n:type x = 5\n:type println(1)\n:type push(a, 2)\n:type inc()\nx\nlen(a)\n",
+			">> >> >> >> INTEGER\n>> NULL\n>> INTEGER\n>> INTEGER\n>> 1\n>> 1\n>> "},
+		// an expression that never ends runs out of the budget of :type
+		{":type while (true) { 1 }\n1\n", ">> ERROR : 1:16: execution budget exceeded: more than 1000000 steps\n   while (true) { 1 }\n                  ^\n>> 1\n>> "},
+		{":tokens let s = \"hi\";\n", ">> 1:1 let \"let\"\n1:5 IDENT \"s\"\n1:7 = \"=\"\n1:9 STRING \"hi\"\n1:13 ; \";\"\n>> "},
+		{":ast -x[1]\n", ">> Program \"(-(x[1]))\" 1:1\n" +
+			"  Statements[0]: ExpressionStatement \"(-(x[1]))\" 1:1\n" +
+			"    Expression: PrefixExpression \"(-(x[1]))\" 1:1\n" +
+			"      Right: IndexExpression \"(x[1])\" 1:2\n" +
+			"        Left: Identifier \"x\" 1:2\n" +
+			"        Index: IntegerLiteral \"1\" 1:4\n>> "},
+		{":ast let = 1\n", ">>    1:5: expected next token to be \"variable_name\" got=\"=\"\n   let = 1\n       ^\n>> "},
+		{":load " + lib + "\ndouble(2)\n", ">> >> 4\n>> "},
+		{":load missing.lang\n", ">> ERROR : open missing.lang: no such file or directory\n>> "},
+		{"let x = 1;\n:reset\nx\n", ">> >> >> ERROR : 1:1: identifier not found: x\n   x\n   ^\n>> "},
+		{":type\n:nope\n", ">> :type expects an argument, :help shows how to use it\n>> unknown command :nope, :help lists the commands\n>> "},
+		// a line starting with ":" inside a multi-line input isn't a command
+		{"let h = {\"a\"\n: 1};\n:env\n", ">> .. >> h: HASH = {a: 1}\n>> "},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		Start(strings.NewReader(tt.input), &out)
+
+		if s := out.String(); s != tt.expected {
+			t.Errorf("%q: expected=%q. got=%q", tt.input, tt.expected, s)
+		}
+	}
+}
+
+func TestStartImportsOnce(t *testing.T) {
+	lib := filepath.Join(t.TempDir(), "lib.lang")
+
+	if err := os.WriteFile(lib, []byte(`println("loading"); let counter = [0];`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the lines share the module, so what one changes is seen by the next one
+	input := fmt.Sprintf(`let a = import(%[1]q);
+push(import(%[1]q).counter, 1);
+len(a.counter)
+:reset
+len(import(%[1]q).counter)
+`, lib)
+
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+
+	if s := out.String(); s != ">> loading\n>> 2\n>> 2\n>> >> loading\n1\n>> " {
+		t.Errorf("wrong output. got=%q", s)
+	}
+}
@@ -0,0 +1,14 @@
+package token
+
+// Comment is a line or block comment of the source, with its delimiters
+type Comment struct {
+	Text string
+
+	Pos Position // position of the first character
+	End Position // position right after the last character
+}
+
+// Returns true for a /* */ comment
+func (c Comment) IsBlock() bool {
+	return len(c.Text) >= 2 && c.Text[1] == '*'
+}
@@ -0,0 +1,67 @@
+package token
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Position describes a place in the source. Line and Column start at 1,
+// Column counts bytes.
+type Position struct {
+	Filename string
+	Offset   int
+	Line     int
+	Column   int
+}
+
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// Returns "file:line:col", "line:col" when there is no file name or "-" when the position is not valid
+func (p Position) String() string {
+	if !p.IsValid() {
+		if p.Filename != "" {
+			return p.Filename
+		}
+
+		return "-"
+	}
+
+	if p.Filename == "" {
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+
+	return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+}
+
+// Returns the source line where the position is placed followed by a line with a caret pointing to the column
+func (p Position) Snippet(src string) string {
+	if !p.IsValid() {
+		return ""
+	}
+
+	lines := strings.Split(src, "\n")
+	if p.Line > len(lines) {
+		return ""
+	}
+
+	line := strings.TrimRight(lines[p.Line-1], "\r")
+
+	var sb strings.Builder
+
+	sb.WriteString(line)
+	sb.WriteByte('\n')
+
+	for i := 0; i < p.Column-1 && i < len(line); i++ {
+		// keep the tabs so the caret is aligned with the source line
+		if line[i] == '\t' {
+			sb.WriteByte('\t')
+		} else {
+			sb.WriteByte(' ')
+		}
+	}
+	sb.WriteByte('^')
+
+	return sb.String()
+}
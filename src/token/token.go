@@ -1,5 +1,7 @@
 package token
 
+import "fmt"
+
 type TokenKind byte
 
 const (
@@ -10,23 +12,35 @@ const (
 	IDENT
 	STRING
 	INT
+	FLOAT
 
 	// Operators
 	ASSIGN
+	PLUS_ASSIGN
+	MINUS_ASSIGN
+	MULTIPLICATION_ASSIGN
+	DIVISION_ASSIGN
+	MODULO_ASSIGN
 	PLUS
 	MINUS
 	MULTIPLICATION
 	DIVISION
+	MODULO
 	LESS
+	LESS_EQUAL
 	GREATER
+	GREATER_EQUAL
 	NOT
 	EQUAL
 	NOT_EQUAL
+	AND
+	OR
 
 	// Delimiters
 	COMMA
 	COLON
 	SEMI
+	DOT
 
 	LPAREN
 	RPAREN
@@ -43,11 +57,88 @@ const (
 	RETURN
 	IF
 	ELSE
+	WHILE
+	FOR
+	IN
+	BREAK
+	CONTINUE
+	IMPORT
 )
 
+var names = [...]string{
+	EOF:     "EOF",
+	ILLEGAL: "ILLEGAL",
+
+	IDENT:  "IDENT",
+	STRING: "STRING",
+	INT:    "INT",
+	FLOAT:  "FLOAT",
+
+	ASSIGN:                "=",
+	PLUS_ASSIGN:           "+=",
+	MINUS_ASSIGN:          "-=",
+	MULTIPLICATION_ASSIGN: "*=",
+	DIVISION_ASSIGN:       "/=",
+	MODULO_ASSIGN:         "%=",
+	PLUS:                  "+",
+	MINUS:                 "-",
+	MULTIPLICATION:        "*",
+	DIVISION:              "/",
+	MODULO:                "%",
+	LESS:                  "<",
+	LESS_EQUAL:            "<=",
+	GREATER:               ">",
+	GREATER_EQUAL:         ">=",
+	NOT:                   "!",
+	EQUAL:                 "==",
+	NOT_EQUAL:             "!=",
+	AND:                   "&&",
+	OR:                    "||",
+
+	COMMA: ",",
+	COLON: ":",
+	SEMI:  ";",
+	DOT:   ".",
+
+	LPAREN:   "(",
+	RPAREN:   ")",
+	LBRACE:   "{",
+	RBRACE:   "}",
+	LBRACKET: "[",
+	RBRACKET: "]",
+
+	FUNCTION: "fn",
+	LET:      "let",
+	TRUE:     "true",
+	FALSE:    "false",
+	RETURN:   "return",
+	IF:       "if",
+	ELSE:     "else",
+	WHILE:    "while",
+	FOR:      "for",
+	IN:       "in",
+	BREAK:    "break",
+	CONTINUE: "continue",
+	IMPORT:   "import",
+}
+
+// Returns the name of the kind, the operators, delimiters and keywords are named as they are written
+func (k TokenKind) String() string {
+	if int(k) < len(names) && names[k] != "" {
+		return names[k]
+	}
+
+	return fmt.Sprintf("TokenKind(%d)", k)
+}
+
 type Token struct {
 	Kind    TokenKind
 	Literal string
+
+	Pos Position // position of the first character
+	End Position // position right after the last character
+
+	Comments []Comment // comments found between the previous token and this one
 }
 
 // Creates a new token
@@ -66,6 +157,13 @@ var keywords = map[string]TokenKind{
 	"return": RETURN,
 	"true":   TRUE,
 	"false":  FALSE,
+
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"import":   IMPORT,
 }
 
 func LookupIdent(ident string) TokenKind {
@@ -0,0 +1,58 @@
+package vm
+
+import (
+	"github.com/dyxgou/parser/src/code"
+	"github.com/dyxgou/parser/src/object"
+	"github.com/dyxgou/parser/src/token"
+)
+
+type Frame struct {
+	cl          *object.Closure
+	ip          int
+	basePointer int // stack position where the locals of the frame start
+
+	// the OpCall running the frame, in the function that made it. A tail call replaces it with its own
+	callerFn *object.CompiledFunction
+	callerIP int
+}
+
+func NewFrame(cl *object.Closure, basePointer int) *Frame {
+	return &Frame{
+		cl:          cl,
+		ip:          -1,
+		basePointer: basePointer,
+	}
+}
+
+func (f *Frame) Instructions() code.Instructions {
+	return f.cl.Fn.Instructions
+}
+
+// Returns the position of the instruction being run
+func (f *Frame) position() token.Position {
+	return f.cl.Fn.Positions.Lookup(f.ip).Pos
+}
+
+// Remembers that the frame runs the call at the current instruction of the caller
+func (f *Frame) calledBy(caller *Frame) {
+	f.callerFn, f.callerIP = caller.cl.Fn, caller.ip
+}
+
+// Returns the call that runs the frame, the main frame has none
+func (f *Frame) caller() object.StackFrame {
+	if f.callerFn == nil {
+		return object.StackFrame{}
+	}
+
+	entry := f.callerFn.Positions.Lookup(f.callerIP)
+
+	return object.StackFrame{Function: entry.Function, Pos: entry.Pos}
+}
+
+func (f *Frame) localName(index int) string {
+	if index < len(f.cl.Fn.Locals) {
+		return f.cl.Fn.Locals[index]
+	}
+
+	return ""
+}
@@ -0,0 +1,233 @@
+package vm
+
+import (
+	"math"
+
+	"github.com/dyxgou/parser/src/code"
+	"github.com/dyxgou/parser/src/object"
+)
+
+var infixOperators = map[code.Opcode]string{
+	code.OpAdd:          "+",
+	code.OpSub:          "-",
+	code.OpMul:          "*",
+	code.OpDiv:          "/",
+	code.OpMod:          "%",
+	code.OpEqual:        "==",
+	code.OpNotEqual:     "!=",
+	code.OpGreaterThan:  ">",
+	code.OpLessThan:     "<",
+	code.OpGreaterEqual: ">=",
+	code.OpLessEqual:    "<=",
+}
+
+// The operations follow the evaluator, so both engines give the same results and errors
+
+func executeInfix(op code.Opcode, left, right object.Object) object.Object {
+	operator := infixOperators[op]
+
+	// an Integer with a Float becomes a Float
+	if object.IsFloatOperation(left, right) {
+		return executeFloatInfix(op, left, right)
+	}
+
+	if right.Type() != left.Type() {
+		return object.NewError("type mismatch: %s %s %s", left.Inspect(), operator, right.Inspect())
+	}
+
+	switch {
+	case right.Type() == object.IntegerType:
+		return executeIntegerInfix(op, left, right)
+	case right.Type() == object.StringType && op == code.OpAdd:
+		leftVal := left.(*object.String).Value
+		rightVal := right.(*object.String).Value
+
+		return &object.String{Value: leftVal + rightVal}
+	case right.Type() == object.StringType && (op == code.OpEqual || op == code.OpNotEqual):
+		equal := left.(*object.String).Value == right.(*object.String).Value
+
+		return object.NativeBoolToBooleanObject(equal == (op == code.OpEqual))
+	case op == code.OpEqual:
+		return object.NativeBoolToBooleanObject(left == right)
+	case op == code.OpNotEqual:
+		return object.NativeBoolToBooleanObject(left != right)
+	}
+
+	return object.NewError("unknown operator: %s %s %s", right.Inspect(), operator, left.Inspect())
+}
+
+func executeIntegerInfix(op code.Opcode, left, right object.Object) object.Object {
+	leftVal := left.(*object.Integer).Value
+	rightVal := right.(*object.Integer).Value
+
+	switch op {
+	case code.OpAdd:
+		return &object.Integer{Value: leftVal + rightVal}
+	case code.OpSub:
+		return &object.Integer{Value: leftVal - rightVal}
+	case code.OpMul:
+		return &object.Integer{Value: leftVal * rightVal}
+	case code.OpDiv:
+		if rightVal == 0 {
+			return object.NewError("division by zero: %d / 0", leftVal)
+		}
+		return &object.Integer{Value: leftVal / rightVal}
+	case code.OpMod:
+		if rightVal == 0 {
+			return object.NewError("division by zero: %d %% 0", leftVal)
+		}
+		return &object.Integer{Value: leftVal % rightVal}
+	case code.OpEqual:
+		return object.NativeBoolToBooleanObject(leftVal == rightVal)
+	case code.OpNotEqual:
+		return object.NativeBoolToBooleanObject(leftVal != rightVal)
+	case code.OpGreaterThan:
+		return object.NativeBoolToBooleanObject(leftVal > rightVal)
+	case code.OpLessThan:
+		return object.NativeBoolToBooleanObject(leftVal < rightVal)
+	case code.OpGreaterEqual:
+		return object.NativeBoolToBooleanObject(leftVal >= rightVal)
+	case code.OpLessEqual:
+		return object.NativeBoolToBooleanObject(leftVal <= rightVal)
+	}
+
+	return NULL
+}
+
+func executeFloatInfix(op code.Opcode, left, right object.Object) object.Object {
+	leftVal, _ := object.ToFloat(left)
+	rightVal, _ := object.ToFloat(right)
+
+	switch op {
+	case code.OpAdd:
+		return &object.Float{Value: leftVal + rightVal}
+	case code.OpSub:
+		return &object.Float{Value: leftVal - rightVal}
+	case code.OpMul:
+		return &object.Float{Value: leftVal * rightVal}
+	case code.OpDiv:
+		return &object.Float{Value: leftVal / rightVal}
+	case code.OpMod:
+		return &object.Float{Value: math.Mod(leftVal, rightVal)}
+	case code.OpEqual:
+		return object.NativeBoolToBooleanObject(leftVal == rightVal)
+	case code.OpNotEqual:
+		return object.NativeBoolToBooleanObject(leftVal != rightVal)
+	case code.OpGreaterThan:
+		return object.NativeBoolToBooleanObject(leftVal > rightVal)
+	case code.OpLessThan:
+		return object.NativeBoolToBooleanObject(leftVal < rightVal)
+	case code.OpGreaterEqual:
+		return object.NativeBoolToBooleanObject(leftVal >= rightVal)
+	case code.OpLessEqual:
+		return object.NativeBoolToBooleanObject(leftVal <= rightVal)
+	}
+
+	return NULL
+}
+
+func executeNot(right object.Object) object.Object {
+	switch right {
+	case TRUE:
+		return FALSE
+	case FALSE:
+		return TRUE
+	case NULL:
+		return TRUE
+	}
+
+	return FALSE
+}
+
+func executeMinus(right object.Object) object.Object {
+	if f, ok := right.(*object.Float); ok {
+		return &object.Float{Value: -f.Value}
+	}
+
+	integer, ok := right.(*object.Integer)
+	if !ok {
+		return object.NewError("unknown operator: -%s", right.Inspect())
+	}
+
+	return &object.Integer{Value: -integer.Value}
+}
+
+func executeIndex(left, index object.Object) object.Object {
+	switch {
+	case left.Type() == object.ArrayType && index.Type() == object.IntegerType:
+		elems := left.(*object.Array).Elements
+		i := index.(*object.Integer).Value
+
+		if i < 0 || i >= int64(len(elems)) {
+			return object.NewError("index out of bounds. got=%d", i)
+		}
+
+		return elems[i]
+	case left.Type() == object.StringType && index.Type() == object.IntegerType:
+		return object.StringIndex(left.(*object.String), index.(*object.Integer).Value)
+	case left.Type() == object.HashType:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return object.NewError("unusable as hash key: %s", index.Inspect())
+		}
+
+		if val, ok := left.(*object.Hash).Get(key); ok {
+			return val
+		}
+
+		return NULL
+	}
+
+	return object.NewError("index operator not supported: %s", left.Inspect())
+}
+
+// Stores a value in an index of an array or hash and returns the value
+func executeSetIndex(left, index, val object.Object) object.Object {
+	switch {
+	case left.Type() == object.ArrayType && index.Type() == object.IntegerType:
+		elems := left.(*object.Array).Elements
+		i := index.(*object.Integer).Value
+
+		if i < 0 || i >= int64(len(elems)) {
+			return object.NewError("index out of bounds. got=%d", i)
+		}
+
+		elems[i] = val
+		return val
+	case left.Type() == object.HashType:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return object.NewError("unusable as hash key: %s", index.Inspect())
+		}
+
+		left.(*object.Hash).Set(key, val)
+		return val
+	}
+
+	return object.NewError("index assignment not supported: %s", left.Inspect())
+}
+
+// Builds a hash from a slice of keys followed by their values
+func buildHash(elems []object.Object) object.Object {
+	hash := object.NewHash()
+
+	for i := 0; i < len(elems); i += 2 {
+		key, ok := elems[i].(object.Hashable)
+		if !ok {
+			return object.NewError("unusable as hash key: %s", elems[i].Inspect())
+		}
+
+		hash.Set(key, elems[i+1])
+	}
+
+	return hash
+}
+
+func isTruthy(obj object.Object) bool {
+	switch obj {
+	case NULL, FALSE:
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,626 @@
+package vm
+
+import (
+	"context"
+
+	"github.com/dyxgou/parser/src/code"
+	"github.com/dyxgou/parser/src/compiler"
+	"github.com/dyxgou/parser/src/object"
+)
+
+const (
+	StackSize    = 2048    // slots the stack starts with, it grows with the calls
+	MaxStackSize = 1 << 24 // slots the stack can grow to
+	GlobalsSize  = 65536
+)
+
+// the context is checked once every contextCheckSteps instructions
+const contextCheckSteps = 1024
+
+var (
+	NULL  = object.NULL
+	TRUE  = object.TRUE
+	FALSE = object.FALSE
+)
+
+type VM struct {
+	constants   []object.Object
+	globals     []object.Object
+	globalNames []string
+
+	stack []object.Object
+	sp    int // Points to the next free slot, the top of the stack is stack[sp-1]
+
+	frames      []*Frame
+	framesIndex int
+
+	result object.Object // value of the last statement executed
+
+	// MaxCallDepth is the maximum number of nested function calls, tail calls don't add to it.
+	// Zero means no limit
+	MaxCallDepth int
+
+	// MaxSteps is the maximum number of instructions executed, zero means no limit
+	MaxSteps int
+
+	// streams of print and input
+	object.IO
+
+	ctx   context.Context
+	steps int
+}
+
+func New(bytecode *compiler.Bytecode) *VM {
+	return NewWithGlobalsStore(bytecode, make([]object.Object, GlobalsSize))
+}
+
+// Creates a vm that keeps the globals of a previous run, used by the REPL
+func NewWithGlobalsStore(bytecode *compiler.Bytecode, globals []object.Object) *VM {
+	mainFn := &object.CompiledFunction{Instructions: bytecode.Instructions, Positions: bytecode.Positions}
+	mainFrame := NewFrame(&object.Closure{Fn: mainFn}, 0)
+
+	frames := make([]*Frame, 1, 64)
+	frames[0] = mainFrame
+
+	return &VM{
+		constants:   bytecode.Constants,
+		globals:     globals,
+		globalNames: bytecode.Globals,
+
+		stack: make([]object.Object, StackSize),
+		sp:    0,
+
+		frames:      frames,
+		framesIndex: 1,
+
+		MaxCallDepth: object.DefaultMaxCallDepth,
+	}
+}
+
+// Returns the value of the last statement executed, nil if it had no value
+func (vm *VM) Result() object.Object {
+	return vm.result
+}
+
+// Context is done once the vm must stop
+func (vm *VM) Context() context.Context {
+	if vm.ctx == nil {
+		return context.Background()
+	}
+
+	return vm.ctx
+}
+
+func (vm *VM) Run() error {
+	return vm.RunContext(context.Background())
+}
+
+// Runs the program until it's done or ctx is, a cancelled run returns an error whose Cause is object.ErrCancelled
+func (vm *VM) RunContext(ctx context.Context) error {
+	if err := object.ContextError(ctx); err != nil {
+		return err
+	}
+
+	vm.ctx = ctx
+	defer func() { vm.ctx = nil }()
+
+	return vm.run(0)
+}
+
+// Executes instructions until the number of frames goes back to depth, the main frame runs until
+// its instructions are over. The error is placed at the instruction that caused it
+func (vm *VM) run(depth int) error {
+	err := vm.execute(depth)
+
+	if objErr, ok := err.(*object.Error); ok && !objErr.Pos.IsValid() {
+		objErr.Pos = vm.currentFrame().position()
+		objErr.Stack = vm.stackTrace()
+	}
+
+	return err
+}
+
+func (vm *VM) execute(depth int) error {
+	for vm.framesIndex > depth && vm.currentFrame().ip < len(vm.currentFrame().Instructions())-1 {
+		if err := vm.step(); err != nil {
+			return err
+		}
+
+		vm.currentFrame().ip++
+
+		ip := vm.currentFrame().ip
+		ins := vm.currentFrame().Instructions()
+		op := code.Opcode(ins[ip])
+
+		var err error
+
+		switch op {
+		case code.OpConstant:
+			constIndex := code.ReadUint16(ins[ip+1:])
+			vm.currentFrame().ip += 2
+
+			err = vm.push(vm.constants[constIndex])
+		case code.OpPop:
+			vm.result = vm.pop()
+		case code.OpClearResult:
+			vm.result = nil
+		case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv, code.OpMod,
+			code.OpEqual, code.OpNotEqual, code.OpGreaterThan, code.OpLessThan,
+			code.OpGreaterEqual, code.OpLessEqual:
+			right := vm.pop()
+			left := vm.pop()
+
+			err = vm.pushResult(executeInfix(op, left, right))
+		case code.OpBang:
+			err = vm.pushResult(executeNot(vm.pop()))
+		case code.OpMinus:
+			err = vm.pushResult(executeMinus(vm.pop()))
+		case code.OpTrue:
+			err = vm.push(TRUE)
+		case code.OpFalse:
+			err = vm.push(FALSE)
+		case code.OpNull:
+			err = vm.push(NULL)
+		case code.OpJump:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			vm.currentFrame().ip = pos - 1
+		case code.OpJumpNotTruthy:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			vm.currentFrame().ip += 2
+
+			if !isTruthy(vm.pop()) {
+				vm.currentFrame().ip = pos - 1
+			}
+		case code.OpIter:
+			it, errObj := object.NewIterator(vm.pop())
+			if errObj != nil {
+				return errObj
+			}
+
+			err = vm.push(it)
+		case code.OpIterNext:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			vm.currentFrame().ip += 2
+
+			elem, ok := vm.stack[vm.sp-1].(*object.Iterator).Next()
+			if !ok {
+				vm.currentFrame().ip = pos - 1
+				break
+			}
+
+			err = vm.push(elem)
+		case code.OpSetGlobal:
+			globalIndex := code.ReadUint16(ins[ip+1:])
+			vm.currentFrame().ip += 2
+
+			vm.globals[globalIndex] = vm.pop()
+		case code.OpGetGlobal:
+			globalIndex := code.ReadUint16(ins[ip+1:])
+			vm.currentFrame().ip += 2
+
+			val := vm.globals[globalIndex]
+			if val == nil {
+				return object.NewError("identifier not found: %s", vm.globalName(int(globalIndex)))
+			}
+
+			err = vm.push(val)
+		case code.OpAssignGlobal:
+			globalIndex := code.ReadUint16(ins[ip+1:])
+			vm.currentFrame().ip += 2
+
+			if vm.globals[globalIndex] == nil {
+				return object.NewError("cannot assign to undefined identifier: %s", vm.globalName(int(globalIndex)))
+			}
+
+			vm.globals[globalIndex] = vm.pop()
+		case code.OpSetLocal:
+			localIndex := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			slot := &vm.stack[vm.currentFrame().basePointer+int(localIndex)]
+			setVariable(slot, vm.pop())
+		case code.OpGetLocal:
+			localIndex := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			val := getVariable(vm.stack[vm.currentFrame().basePointer+int(localIndex)])
+			if val == nil {
+				return object.NewError("identifier not found: %s", vm.currentFrame().localName(int(localIndex)))
+			}
+
+			err = vm.push(val)
+		case code.OpGetLocalCell:
+			localIndex := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			// the local moves to a cell the first time a closure captures it
+			slot := &vm.stack[vm.currentFrame().basePointer+int(localIndex)]
+			if _, ok := (*slot).(*object.Cell); !ok {
+				*slot = &object.Cell{Value: *slot}
+			}
+
+			err = vm.push(*slot)
+		case code.OpGetBuiltin:
+			builtinIndex := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			err = vm.push(object.Builtins[builtinIndex].BuiltIn)
+		case code.OpGetFree:
+			freeIndex := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			err = vm.push(getVariable(vm.currentFrame().cl.Free[freeIndex]))
+		case code.OpSetFree:
+			freeIndex := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			setVariable(&vm.currentFrame().cl.Free[freeIndex], vm.pop())
+		case code.OpGetFreeCell:
+			freeIndex := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			err = vm.push(vm.currentFrame().cl.Free[freeIndex])
+		case code.OpCurrentClosure:
+			err = vm.push(vm.currentFrame().cl)
+		case code.OpArray:
+			numElements := int(code.ReadUint16(ins[ip+1:]))
+			vm.currentFrame().ip += 2
+
+			elems := make([]object.Object, numElements)
+			copy(elems, vm.stack[vm.sp-numElements:vm.sp])
+			vm.sp -= numElements
+
+			err = vm.push(&object.Array{Elements: elems})
+		case code.OpHash:
+			numElements := int(code.ReadUint16(ins[ip+1:]))
+			vm.currentFrame().ip += 2
+
+			hash := buildHash(vm.stack[vm.sp-numElements : vm.sp])
+			vm.sp -= numElements
+
+			err = vm.pushResult(hash)
+		case code.OpIndex:
+			index := vm.pop()
+			left := vm.pop()
+
+			err = vm.pushResult(executeIndex(left, index))
+		case code.OpSetIndex:
+			val := vm.pop()
+			index := vm.pop()
+			left := vm.pop()
+
+			err = vm.pushResult(executeSetIndex(left, index, val))
+		case code.OpSlice:
+			high := vm.pop()
+			low := vm.pop()
+			left := vm.pop()
+
+			err = vm.pushResult(object.Slice(left, low, high))
+		case code.OpUpdateIndex:
+			op := code.Opcode(code.ReadUint8(ins[ip+1:]))
+			vm.currentFrame().ip += 1
+
+			val := vm.pop()
+			index := vm.pop()
+			left := vm.pop()
+
+			current := executeIndex(left, index)
+			if errObj, ok := current.(*object.Error); ok {
+				return errObj
+			}
+
+			val = executeInfix(op, current, val)
+			if errObj, ok := val.(*object.Error); ok {
+				return errObj
+			}
+
+			err = vm.pushResult(executeSetIndex(left, index, val))
+		case code.OpCall:
+			numArgs := code.ReadUint8(ins[ip+1:])
+			vm.currentFrame().ip += 1
+
+			// a function returning the value of the call runs it in its own frame
+			if vm.framesIndex > 1 && returnsValue(ins, ip+2) {
+				err = vm.executeTailCall(int(numArgs))
+			} else {
+				err = vm.executeCall(int(numArgs))
+			}
+		case code.OpReturnValue:
+			returnValue := vm.pop()
+
+			// a return in the main program stops it
+			if vm.framesIndex == 1 {
+				vm.result = returnValue
+				return nil
+			}
+
+			frame := vm.popFrame()
+			vm.sp = frame.basePointer - 1
+
+			err = vm.push(returnValue)
+		case code.OpReturn:
+			frame := vm.popFrame()
+			vm.sp = frame.basePointer - 1
+
+			err = vm.push(NULL)
+		case code.OpClosure:
+			constIndex := code.ReadUint16(ins[ip+1:])
+			numFree := code.ReadUint8(ins[ip+3:])
+			vm.currentFrame().ip += 3
+
+			err = vm.pushClosure(int(constIndex), int(numFree))
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Counts an executed instruction, returns the error that stops the program once it runs out of budget or its context is done
+func (vm *VM) step() *object.Error {
+	vm.steps++
+
+	if vm.MaxSteps > 0 && vm.steps > vm.MaxSteps {
+		return object.BudgetError(vm.MaxSteps)
+	}
+
+	if vm.steps%contextCheckSteps == 0 {
+		return object.ContextError(vm.ctx)
+	}
+
+	return nil
+}
+
+func (vm *VM) executeCall(numArgs int) error {
+	callee := vm.stack[vm.sp-1-numArgs]
+
+	switch callee := callee.(type) {
+	case *object.Closure:
+		return vm.callClosure(callee, numArgs)
+	case *object.BuiltIn:
+		args := vm.stack[vm.sp-numArgs : vm.sp]
+		result := callee.Fn(vm, args...)
+		vm.sp = vm.sp - numArgs - 1
+
+		if result == nil {
+			result = NULL
+		}
+
+		return vm.pushResult(result)
+	}
+
+	return object.NewError("not a function. got=%q", callee.String())
+}
+
+// Apply calls a closure or a builtin from a builtin, the closure runs on top of the current stack
+func (vm *VM) Apply(fn object.Object, args ...object.Object) object.Object {
+	switch fn := fn.(type) {
+	case *object.BuiltIn:
+		if result := fn.Fn(vm, args...); result != nil {
+			return result
+		}
+
+		return NULL
+	case *object.Closure:
+		sp, depth := vm.sp, vm.framesIndex
+		defer func() { vm.sp, vm.framesIndex = sp, depth }()
+
+		err := vm.push(fn)
+		for _, arg := range args {
+			if err == nil {
+				err = vm.push(arg)
+			}
+		}
+
+		if err == nil {
+			err = vm.callClosure(fn, len(args))
+		}
+
+		if err == nil {
+			err = vm.run(depth)
+		}
+
+		if err != nil {
+			if objErr, ok := err.(*object.Error); ok {
+				return objErr
+			}
+
+			return object.NewError("%s", err)
+		}
+
+		return vm.pop()
+	}
+
+	return object.NewError("not a function. got=%q", fn.String())
+}
+
+// Runs a call whose value is returned right away by the current function, a closure takes the place
+// of the function in its frame so the recursion doesn't grow the stack
+func (vm *VM) executeTailCall(numArgs int) error {
+	cl, ok := vm.stack[vm.sp-1-numArgs].(*object.Closure)
+	if !ok {
+		return vm.executeCall(numArgs)
+	}
+
+	if numArgs != cl.Fn.NumParameters {
+		return object.NewError("wrong number of arguments: want=%d, got=%d", cl.Fn.NumParameters, numArgs)
+	}
+
+	// the closure and its arguments take the place of the ones of the current call
+	frame := vm.currentFrame()
+	copy(vm.stack[frame.basePointer-1:], vm.stack[vm.sp-1-numArgs:vm.sp])
+	vm.sp = frame.basePointer + numArgs
+
+	frame.calledBy(frame)
+	frame.cl = cl
+	frame.ip = -1
+
+	return vm.allocateLocals(frame)
+}
+
+func (vm *VM) callClosure(cl *object.Closure, numArgs int) error {
+	if numArgs != cl.Fn.NumParameters {
+		return object.NewError("wrong number of arguments: want=%d, got=%d", cl.Fn.NumParameters, numArgs)
+	}
+
+	// the main frame isn't a call
+	if vm.MaxCallDepth > 0 && vm.framesIndex-1 >= vm.MaxCallDepth {
+		return object.CallDepthError(vm.MaxCallDepth)
+	}
+
+	frame := NewFrame(cl, vm.sp-numArgs)
+	frame.calledBy(vm.currentFrame())
+	vm.pushFrame(frame)
+
+	return vm.allocateLocals(frame)
+}
+
+// Makes room in the stack for the locals of the frame, the arguments are already in their slots
+func (vm *VM) allocateLocals(frame *Frame) error {
+	newSp := frame.basePointer + frame.cl.Fn.NumLocals
+	if err := vm.growStack(newSp); err != nil {
+		return err
+	}
+
+	// the slots of the locals may hold values of a previous frame
+	clear(vm.stack[vm.sp:newSp])
+	vm.sp = newSp
+
+	return nil
+}
+
+// Grows the stack so it has at least size slots
+func (vm *VM) growStack(size int) error {
+	if size <= len(vm.stack) {
+		return nil
+	}
+
+	if size > MaxStackSize {
+		return object.NewError("stack overflow")
+	}
+
+	stack := make([]object.Object, min(max(size, 2*len(vm.stack)), MaxStackSize))
+	copy(stack, vm.stack[:vm.sp])
+	vm.stack = stack
+
+	return nil
+}
+
+// Tells if the instruction at ip returns the value on top of the stack, following the jumps that lead to it
+func returnsValue(ins code.Instructions, ip int) bool {
+	for ip < len(ins) {
+		switch code.Opcode(ins[ip]) {
+		case code.OpReturnValue:
+			return true
+		case code.OpJump:
+			ip = int(code.ReadUint16(ins[ip+1:]))
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+func (vm *VM) pushClosure(constIndex, numFree int) error {
+	fn, ok := vm.constants[constIndex].(*object.CompiledFunction)
+	if !ok {
+		return object.NewError("not a function: %+v", vm.constants[constIndex])
+	}
+
+	free := make([]object.Object, numFree)
+	copy(free, vm.stack[vm.sp-numFree:vm.sp])
+	vm.sp -= numFree
+
+	return vm.push(&object.Closure{Fn: fn, Free: free})
+}
+
+// Returns the value of a local or free variable, going through its cell if it was captured
+func getVariable(slot object.Object) object.Object {
+	if cell, ok := slot.(*object.Cell); ok {
+		return cell.Value
+	}
+
+	return slot
+}
+
+// Stores the value of a local or free variable, going through its cell if it was captured
+func setVariable(slot *object.Object, val object.Object) {
+	if cell, ok := (*slot).(*object.Cell); ok {
+		cell.Value = val
+		return
+	}
+
+	*slot = val
+}
+
+func (vm *VM) globalName(index int) string {
+	if index < len(vm.globalNames) {
+		return vm.globalNames[index]
+	}
+
+	return ""
+}
+
+// Returns the functions being called, the innermost first, every one placed at the call that runs it
+func (vm *VM) stackTrace() []object.StackFrame {
+	if vm.framesIndex <= 1 {
+		return nil
+	}
+
+	stack := make([]object.StackFrame, 0, vm.framesIndex-1)
+	for i := vm.framesIndex - 1; i > 0; i-- {
+		stack = append(stack, vm.frames[i].caller())
+	}
+
+	return stack
+}
+
+func (vm *VM) currentFrame() *Frame {
+	return vm.frames[vm.framesIndex-1]
+}
+
+func (vm *VM) pushFrame(f *Frame) {
+	if vm.framesIndex < len(vm.frames) {
+		vm.frames[vm.framesIndex] = f
+	} else {
+		vm.frames = append(vm.frames, f)
+	}
+
+	vm.framesIndex++
+}
+
+func (vm *VM) popFrame() *Frame {
+	vm.framesIndex--
+	return vm.frames[vm.framesIndex]
+}
+
+func (vm *VM) push(obj object.Object) error {
+	if err := vm.growStack(vm.sp + 1); err != nil {
+		return err
+	}
+
+	vm.stack[vm.sp] = obj
+	vm.sp++
+
+	return nil
+}
+
+func (vm *VM) pop() object.Object {
+	obj := vm.stack[vm.sp-1]
+	vm.sp--
+
+	return obj
+}
+
+// Pushes the result of an operation, an error result stops the vm
+func (vm *VM) pushResult(obj object.Object) error {
+	if err, ok := obj.(*object.Error); ok {
+		return err
+	}
+
+	return vm.push(obj)
+}
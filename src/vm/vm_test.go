@@ -0,0 +1,202 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dyxgou/parser/src/compiler"
+	"github.com/dyxgou/parser/src/lexer"
+	"github.com/dyxgou/parser/src/object"
+	"github.com/dyxgou/parser/src/parser"
+)
+
+type vmTestCase struct {
+	input    string
+	expected string
+}
+
+func runVMTests(t *testing.T, tests []vmTestCase) {
+	t.Helper()
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+
+		comp := compiler.New()
+		if err := comp.Compile(program); err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+
+		vm := New(comp.Bytecode())
+
+		var got string
+		if err := vm.Run(); err != nil {
+			got = "ERROR : " + err.Error()
+		} else if result := vm.Result(); result != nil {
+			got = result.String()
+		}
+
+		if got != tt.expected {
+			t.Errorf("%s: expected=%q. got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	runVMTests(t, []vmTestCase{
+		{"1 + 2 * 3", "7"},
+		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", "50"},
+		{"1 < 2 == true", "true"},
+		{"!(1 > 2)", "true"},
+		{`"foo" + "bar"`, "foobar"},
+		{"5 + true", "ERROR : type mismatch: INTEGER + BOOLEAN"},
+		{"-true", "ERROR : unknown operator: -BOOLEAN"},
+	})
+}
+
+func TestBindings(t *testing.T) {
+	runVMTests(t, []vmTestCase{
+		{"let a = 1; let b = a + 1; b", "2"},
+		{"let a = 1;", ""},
+		{"let a = 1; let a = a + 1; a", "2"},
+		{"foobar", "ERROR : identifier not found: foobar"},
+		{"if (false) { foobar }", "NULL"},
+		{"let f = fn() { g() }; let g = fn() { 3 }; f()", "3"},
+		{"return 1; 2", "1"},
+	})
+}
+
+func TestFunctions(t *testing.T) {
+	runVMTests(t, []vmTestCase{
+		{"let add = fn(a, b) { a + b }; add(1, add(2, 3))", "6"},
+		{"fn() { }()", "NULL"},
+		{"fn() { return 1; 2 }()", "1"},
+		{"let newAdder = fn(x) { fn(y) { x + y } }; newAdder(2)(3)", "5"},
+		{
+			`let fib = fn(x) { if (x < 2) { x } else { fib(x - 1) + fib(x - 2) } }; fib(15)`,
+			"610",
+		},
+		{
+			`let count = fn(n) {
+        let iter = fn(i, acc) { if (i == 0) { acc } else { iter(i - 1, acc + 1) } };
+        iter(n, 0)
+      };
+      count(10)`,
+			"10",
+		},
+		{"fn(a) { a }()", "ERROR : wrong number of arguments: want=1, got=0"},
+		{"5()", `ERROR : not a function. got="5"`},
+	})
+}
+
+func TestCollections(t *testing.T) {
+	runVMTests(t, []vmTestCase{
+		{"[1, 2, 3][1]", "2"},
+		{"[1, 2, 3][3]", "ERROR : index out of bounds. got=3"},
+		{`{"a": 1, 2: "b"}[2]`, "b"},
+		{`{"a": 1}["b"]`, "NULL"},
+		{`{[1]: 1}`, "ERROR : unusable as hash key: ARRAY"},
+		{"let a = [1]; push(a, 2); len(a)", "2"},
+		{`len(1)`, "ERROR : argument to 'len' not supported. got=string"},
+	})
+}
+
+func TestGlobalsStore(t *testing.T) {
+	globals := make([]object.Object, GlobalsSize)
+	symbolTable := compiler.NewSymbolTable()
+	for i, b := range object.Builtins {
+		symbolTable.DefineBuiltin(i, b.Name)
+	}
+
+	constants := []object.Object{}
+
+	for i, input := range []string{"let a = 2;", "let b = a * 3;", "a + b"} {
+		comp := compiler.NewWithState(symbolTable, constants)
+		if err := comp.Compile(parser.New(lexer.New(input)).ParseProgram()); err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+
+		bytecode := comp.Bytecode()
+		constants = bytecode.Constants
+
+		vm := NewWithGlobalsStore(bytecode, globals)
+		if err := vm.Run(); err != nil {
+			t.Fatalf("vm error: %s", err)
+		}
+
+		if i == 2 && vm.Result().String() != "8" {
+			t.Errorf("result expected=8. got=%s", vm.Result())
+		}
+	}
+}
+
+func newTestVM(t *testing.T, input string) *VM {
+	t.Helper()
+
+	comp := compiler.New()
+	if err := comp.Compile(parser.New(lexer.New(input)).ParseProgram()); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+
+	return New(comp.Bytecode())
+}
+
+func TestMaxSteps(t *testing.T) {
+	vm := newTestVM(t, "let i = 0; while (true) { i += 1 }")
+	vm.MaxSteps = 1000
+
+	err := vm.Run()
+	if !errors.Is(err, object.ErrBudgetExceeded) {
+		t.Fatalf("expected a budget error. got=%v", err)
+	}
+
+	if err.Error() != "execution budget exceeded: more than 1000 steps" {
+		t.Errorf("wrong message. got=%q", err.Error())
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := newTestVM(t, "while (true) { 1 }").RunContext(ctx)
+	if !errors.Is(err, object.ErrCancelled) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a cancelled error. got=%v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := newTestVM(t, "1").RunContext(cancelled); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled error. got=%v", err)
+	}
+}
+
+func TestErrorPosition(t *testing.T) {
+	input := `let f = fn(x) { 1 / x };
+let g = fn() { f(0) + 1 };
+g()`
+
+	err := newTestVM(t, input).Run()
+
+	errObj, ok := err.(*object.Error)
+	if !ok {
+		t.Fatalf("expected an *object.Error. got=%T (%v)", err, err)
+	}
+
+	if errObj.Pos.String() != "1:17" {
+		t.Errorf("wrong position. expected=1:17, got=%s", errObj.Pos)
+	}
+
+	expected := []string{"f (2:16)", "g (3:1)"}
+	if len(errObj.Stack) != len(expected) {
+		t.Fatalf("wrong stack. expected=%v, got=%v", expected, errObj.Stack)
+	}
+
+	for i, frame := range errObj.Stack {
+		if frame.String() != expected[i] {
+			t.Errorf("stack[%d] wrong. expected=%q, got=%q", i, expected[i], frame)
+		}
+	}
+}